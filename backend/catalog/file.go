@@ -0,0 +1,247 @@
+// Package catalog carga, valida y sincroniza el catálogo (grupos musculares,
+// equipos y ejercicios) desde un archivo versionado en YAML o JSON
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/goalritmo/gym/backend/models"
+	"gopkg.in/yaml.v3"
+)
+
+// FormatVersion es la versión del formato de archivo que entiende este paquete
+const FormatVersion = 1
+
+// File representa un archivo de catálogo. Cada entrada se identifica por un slug
+// estable, que se usa para las referencias entre entradas y para el upsert
+type File struct {
+	Version      int                `yaml:"version" json:"version"`
+	MuscleGroups []MuscleGroupEntry `yaml:"muscle_groups" json:"muscle_groups"`
+	Equipment    []EquipmentEntry   `yaml:"equipment" json:"equipment"`
+	Exercises    []ExerciseEntry    `yaml:"exercises" json:"exercises"`
+}
+
+// MuscleGroupEntry representa un grupo muscular del catálogo
+type MuscleGroupEntry struct {
+	Slug     string  `yaml:"slug" json:"slug"`
+	Name     string  `yaml:"name" json:"name"`
+	Category *string `yaml:"category,omitempty" json:"category,omitempty"`
+}
+
+// EquipmentEntry representa un equipo del catálogo
+type EquipmentEntry struct {
+	Slug           string    `yaml:"slug" json:"slug"`
+	Name           string    `yaml:"name" json:"name"`
+	Category       string    `yaml:"category" json:"category"`
+	Observations   *string   `yaml:"observations,omitempty" json:"observations,omitempty"`
+	ImageURL       *string   `yaml:"image_url,omitempty" json:"image_url,omitempty"`
+	LoadType       *string   `yaml:"load_type,omitempty" json:"load_type,omitempty"`
+	BarWeight      *float64  `yaml:"bar_weight,omitempty" json:"bar_weight,omitempty"`
+	Plates         []float64 `yaml:"plates,omitempty" json:"plates,omitempty"`
+	StackIncrement *float64  `yaml:"stack_increment,omitempty" json:"stack_increment,omitempty"`
+	DumbbellStep   *float64  `yaml:"dumbbell_step,omitempty" json:"dumbbell_step,omitempty"`
+	MinWeight      *float64  `yaml:"min_weight,omitempty" json:"min_weight,omitempty"`
+	MaxWeight      *float64  `yaml:"max_weight,omitempty" json:"max_weight,omitempty"`
+	Aliases        []string  `yaml:"aliases,omitempty" json:"aliases,omitempty"`
+}
+
+// ExerciseEntry representa un ejercicio del catálogo. Equipment, Primary y
+// Secondary referencian slugs de equipos y grupos musculares
+type ExerciseEntry struct {
+	Slug            string   `yaml:"slug" json:"slug"`
+	Name            string   `yaml:"name" json:"name"`
+	MuscleGroup     string   `yaml:"muscle_group" json:"muscle_group"`
+	Equipment       *string  `yaml:"equipment,omitempty" json:"equipment,omitempty"`
+	VideoURL        *string  `yaml:"video_url,omitempty" json:"video_url,omitempty"`
+	MeasurementMode string   `yaml:"measurement_mode,omitempty" json:"measurement_mode,omitempty"`
+	Primary         []string `yaml:"primary,omitempty" json:"primary,omitempty"`
+	Secondary       []string `yaml:"secondary,omitempty" json:"secondary,omitempty"`
+	Aliases         []string `yaml:"aliases,omitempty" json:"aliases,omitempty"`
+}
+
+// slugPattern es el formato válido de un slug
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// Load lee un archivo de catálogo; el formato se elige por la extensión
+// (.yaml, .yml o .json) y el contenido se normaliza y valida
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file File
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, &file)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &file)
+	default:
+		return nil, fmt.Errorf("extensión no soportada: %s (usar .yaml, .yml o .json)", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo %s: %v", path, err)
+	}
+
+	file.Normalize()
+	if err := file.Validate(); err != nil {
+		return nil, fmt.Errorf("catálogo inválido: %v", err)
+	}
+	return &file, nil
+}
+
+// Save escribe el catálogo en path; el formato se elige por la extensión
+func (f *File) Save(path string) error {
+	data, err := f.Marshal(strings.ToLower(filepath.Ext(path)))
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// Marshal serializa el catálogo como JSON (".json") o YAML (cualquier otra extensión)
+func (f *File) Marshal(ext string) ([]byte, error) {
+	if ext == ".json" {
+		data, err := json.MarshalIndent(f, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	}
+	return yaml.Marshal(f)
+}
+
+// Normalize ordena las entradas por slug y las listas internas, para que el
+// archivo exportado sea estable y las comparaciones no dependan del orden
+func (f *File) Normalize() {
+	sort.Slice(f.MuscleGroups, func(i, j int) bool { return f.MuscleGroups[i].Slug < f.MuscleGroups[j].Slug })
+	sort.Slice(f.Equipment, func(i, j int) bool { return f.Equipment[i].Slug < f.Equipment[j].Slug })
+	sort.Slice(f.Exercises, func(i, j int) bool { return f.Exercises[i].Slug < f.Exercises[j].Slug })
+
+	for i := range f.Equipment {
+		sort.Strings(f.Equipment[i].Aliases)
+	}
+	for i := range f.Exercises {
+		exercise := &f.Exercises[i]
+		if exercise.MeasurementMode == "" {
+			exercise.MeasurementMode = models.MeasurementWeightReps
+		}
+		sort.Strings(exercise.Primary)
+		sort.Strings(exercise.Secondary)
+		sort.Strings(exercise.Aliases)
+	}
+}
+
+// Validate verifica la versión, los slugs, los enums y las referencias internas.
+// Las referencias a slugs que no están en el archivo se resuelven al importar
+func (f *File) Validate() error {
+	if f.Version != FormatVersion {
+		return fmt.Errorf("versión %d no soportada (se espera %d)", f.Version, FormatVersion)
+	}
+
+	muscles := make(map[string]bool)
+	for _, group := range f.MuscleGroups {
+		if err := checkSlug("muscle_groups", group.Slug, muscles); err != nil {
+			return err
+		}
+		if strings.TrimSpace(group.Name) == "" {
+			return fmt.Errorf("muscle_groups %s: name es requerido", group.Slug)
+		}
+		if group.Category != nil && !contains(models.MuscleGroupCategories, *group.Category) {
+			return fmt.Errorf("muscle_groups %s: category inválida %q", group.Slug, *group.Category)
+		}
+	}
+
+	equipment := make(map[string]bool)
+	loadTypes := []string{models.LoadTypePlates, models.LoadTypeStack, models.LoadTypeDumbbells, models.LoadTypeBodyweight}
+	for _, eq := range f.Equipment {
+		if err := checkSlug("equipment", eq.Slug, equipment); err != nil {
+			return err
+		}
+		if strings.TrimSpace(eq.Name) == "" {
+			return fmt.Errorf("equipment %s: name es requerido", eq.Slug)
+		}
+		if !contains(models.EquipmentCategories, eq.Category) {
+			return fmt.Errorf("equipment %s: category inválida %q", eq.Slug, eq.Category)
+		}
+		if eq.LoadType != nil && !contains(loadTypes, *eq.LoadType) {
+			return fmt.Errorf("equipment %s: load_type inválido %q", eq.Slug, *eq.LoadType)
+		}
+	}
+
+	exercises := make(map[string]bool)
+	for _, exercise := range f.Exercises {
+		if err := checkSlug("exercises", exercise.Slug, exercises); err != nil {
+			return err
+		}
+		if strings.TrimSpace(exercise.Name) == "" {
+			return fmt.Errorf("exercises %s: name es requerido", exercise.Slug)
+		}
+		if !contains(models.MuscleGroupRoles, exercise.MuscleGroup) {
+			return fmt.Errorf("exercises %s: muscle_group inválido %q", exercise.Slug, exercise.MuscleGroup)
+		}
+		if !contains(models.MeasurementModes, exercise.MeasurementMode) {
+			return fmt.Errorf("exercises %s: measurement_mode inválido %q", exercise.Slug, exercise.MeasurementMode)
+		}
+		seen := make(map[string]bool)
+		for _, slug := range append(append([]string{}, exercise.Primary...), exercise.Secondary...) {
+			if seen[slug] {
+				return fmt.Errorf("exercises %s: el grupo muscular %s está repetido", exercise.Slug, slug)
+			}
+			seen[slug] = true
+		}
+	}
+
+	return nil
+}
+
+// Slugify genera un slug a partir de un nombre: minúsculas, sin acentos y con
+// guiones en lugar de cualquier otro carácter ("Press de banca" -> "press-de-banca")
+func Slugify(name string) string {
+	replacer := strings.NewReplacer(
+		"á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ü", "u", "ñ", "n",
+		"à", "a", "è", "e", "ì", "i", "ò", "o", "ù", "u",
+	)
+	name = replacer.Replace(strings.ToLower(name))
+
+	var b strings.Builder
+	dash := false
+	for _, r := range name {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// checkSlug valida el formato de un slug y que no esté repetido en la sección
+func checkSlug(section, slug string, seen map[string]bool) error {
+	if !slugPattern.MatchString(slug) {
+		return fmt.Errorf("%s: slug inválido %q", section, slug)
+	}
+	if seen[slug] {
+		return fmt.Errorf("%s: slug repetido %q", section, slug)
+	}
+	seen[slug] = true
+	return nil
+}
+
+// contains indica si value está en values
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
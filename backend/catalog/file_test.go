@@ -0,0 +1,181 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"Press de banca", "press-de-banca"},
+		{"Jalón al pecho", "jalon-al-pecho"},
+		{"  Curl (martillo) ", "curl-martillo"},
+		{"Extensión de cuádriceps 45°", "extension-de-cuadriceps-45"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if slug := Slugify(tt.name); slug != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, slug)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := func() *File {
+		return &File{
+			Version:      FormatVersion,
+			MuscleGroups: []MuscleGroupEntry{{Slug: "pectoral-mayor", Name: "Pectoral mayor"}},
+			Equipment:    []EquipmentEntry{{Slug: "barra-olimpica", Name: "Barra olímpica", Category: "pesas_libres"}},
+			Exercises: []ExerciseEntry{{
+				Slug: "press-de-banca", Name: "Press de banca", MuscleGroup: "pecho",
+				MeasurementMode: "weight_reps", Primary: []string{"pectoral-mayor"},
+			}},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(f *File)
+		errMsg string
+	}{
+		{"Archivo válido", func(f *File) {}, ""},
+		{"Versión no soportada", func(f *File) { f.Version = 2 }, "versión 2 no soportada"},
+		{"Slug inválido", func(f *File) { f.Equipment[0].Slug = "Barra Olímpica" }, "slug inválido"},
+		{"Slug repetido", func(f *File) { f.Exercises = append(f.Exercises, f.Exercises[0]) }, "slug repetido"},
+		{"Categoría inválida", func(f *File) { f.Equipment[0].Category = "otra" }, "category inválida"},
+		{"Modo de medición inválido", func(f *File) { f.Exercises[0].MeasurementMode = "km" }, "measurement_mode inválido"},
+		{"Músculo repetido", func(f *File) { f.Exercises[0].Secondary = []string{"pectoral-mayor"} }, "está repetido"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := valid()
+			tt.modify(f)
+			err := f.Validate()
+			if tt.errMsg == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("Expected error containing %q, got %v", tt.errMsg, err)
+			}
+		})
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	file := &File{
+		Version:   FormatVersion,
+		Equipment: []EquipmentEntry{{Slug: "mancuernas", Name: "Mancuernas", Category: "pesas_libres", Aliases: []string{"dumbbells"}}},
+		Exercises: []ExerciseEntry{
+			{Slug: "sentadilla", Name: "Sentadilla", MuscleGroup: "piernas", Aliases: []string{"squat", "back squat"}},
+			{Slug: "curl", Name: "Curl", MuscleGroup: "biceps", Equipment: stringPtr("mancuernas")},
+		},
+	}
+
+	for _, ext := range []string{".yaml", ".json"} {
+		t.Run(ext, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "catalog"+ext)
+			if err := file.Save(path); err != nil {
+				t.Fatalf("Save: %v", err)
+			}
+			loaded, err := Load(path)
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+
+			// Load normaliza: ordena por slug y completa el modo de medición
+			if loaded.Exercises[0].Slug != "curl" || loaded.Exercises[1].MeasurementMode != "weight_reps" {
+				t.Errorf("Expected normalized exercises, got %+v", loaded.Exercises)
+			}
+			if !reflect.DeepEqual(loaded.Exercises[1].Aliases, []string{"back squat", "squat"}) {
+				t.Errorf("Expected sorted aliases, got %v", loaded.Exercises[1].Aliases)
+			}
+		})
+	}
+}
+
+func TestLoadSeedCatalog(t *testing.T) {
+	if _, err := os.Stat("../database/catalog.yaml"); err != nil {
+		t.Skip("database/catalog.yaml no encontrado")
+	}
+	if _, err := Load("../database/catalog.yaml"); err != nil {
+		t.Errorf("Expected seed catalog to be valid, got %v", err)
+	}
+}
+
+func TestChangedFields(t *testing.T) {
+	current := ExerciseEntry{
+		Slug: "press-de-banca", Name: "Press de banca", MuscleGroup: "pecho", MeasurementMode: "weight_reps",
+		Primary: []string{"pectoral-mayor"},
+	}
+
+	tests := []struct {
+		name     string
+		desired  func(e ExerciseEntry) ExerciseEntry
+		hasSlug  bool
+		expected []string
+	}{
+		{"Sin cambios", func(e ExerciseEntry) ExerciseEntry { return e }, true, nil},
+		{"Lista vacía igual a nil", func(e ExerciseEntry) ExerciseEntry { e.Aliases = []string{}; return e }, true, nil},
+		{"Nombre y equipo", func(e ExerciseEntry) ExerciseEntry {
+			e.Name = "Press banca"
+			e.Equipment = stringPtr("barra-olimpica")
+			return e
+		}, true, []string{"name", "equipment"}},
+		{"Músculos secundarios", func(e ExerciseEntry) ExerciseEntry { e.Secondary = []string{"triceps"}; return e }, true, []string{"secondary"}},
+		{"Fila sin slug en la base", func(e ExerciseEntry) ExerciseEntry { return e }, false, []string{"slug"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := changedFields(current, tt.desired(current), tt.hasSlug)
+			if !reflect.DeepEqual(fields, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, fields)
+			}
+		})
+	}
+}
+
+func TestNewIndexFallbackSlugs(t *testing.T) {
+	rows := []stored[MuscleGroupEntry]{
+		{ID: 1, Name: "Bíceps", Entry: MuscleGroupEntry{Slug: "biceps", Name: "Bíceps"}, HasSlug: true},
+		{ID: 2, Name: "Tríceps", Entry: MuscleGroupEntry{Name: "Tríceps"}},
+		{ID: 3, Name: "Biceps", Entry: MuscleGroupEntry{Name: "Biceps"}},
+	}
+	ix := newIndex(rows, func(e MuscleGroupEntry) string { return e.Slug })
+
+	expected := map[int]string{1: "biceps", 2: "triceps", 3: "biceps-3"}
+	if slugs := ix.slugByID(); !reflect.DeepEqual(slugs, expected) {
+		t.Errorf("Expected %v, got %v", expected, slugs)
+	}
+
+	// Las filas sin slug se encuentran por nombre, sin distinguir mayúsculas
+	if row, ok := ix.find("triceps-braquial", "TRÍCEPS"); !ok || row.ID != 2 {
+		t.Errorf("Expected to find row 2 by name, got %v %v", row, ok)
+	}
+	// Una fila ya encontrada no vuelve a coincidir
+	if _, ok := ix.find("triceps", "Tríceps"); ok {
+		t.Error("Expected matched row not to be found twice")
+	}
+
+	report := &Report{}
+	ix.reportMissing(SectionMuscleGroups, report)
+	if report.Count(ActionMissing) != 2 {
+		t.Errorf("Expected 2 missing rows, got %d", report.Count(ActionMissing))
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,646 @@
+package catalog
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/goalritmo/gym/backend/database"
+	"github.com/lib/pq"
+)
+
+// Secciones del catálogo, usadas en el reporte
+const (
+	SectionMuscleGroups = "muscle_groups"
+	SectionEquipment    = "equipment"
+	SectionExercises    = "exercises"
+)
+
+// Acciones del reporte de sincronización
+const (
+	ActionCreate    = "create"
+	ActionUpdate    = "update"
+	ActionUnchanged = "unchanged"
+	// ActionMissing marca entradas que están en la base pero no en el archivo.
+	// La importación nunca elimina: solo lo informa
+	ActionMissing = "missing"
+)
+
+// Change describe el resultado de sincronizar una entrada
+type Change struct {
+	Section string
+	Slug    string
+	Action  string
+	Fields  []string
+}
+
+// Report es el resultado de una importación
+type Report struct {
+	Changes []Change
+}
+
+func (r *Report) add(section, slug, action string, fields []string) {
+	r.Changes = append(r.Changes, Change{Section: section, Slug: slug, Action: action, Fields: fields})
+}
+
+// Count devuelve la cantidad de cambios con la acción dada
+func (r *Report) Count(action string) int {
+	n := 0
+	for _, change := range r.Changes {
+		if change.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// Write escribe el reporte en formato diff: + creado, ~ actualizado (con los
+// campos), ? ausente del archivo. Los sin cambios solo se listan con verbose
+func (r *Report) Write(w io.Writer, verbose bool) {
+	symbols := map[string]string{ActionCreate: "+", ActionUpdate: "~", ActionUnchanged: "=", ActionMissing: "?"}
+	for _, change := range r.Changes {
+		if change.Action == ActionUnchanged && !verbose {
+			continue
+		}
+		line := fmt.Sprintf("%s %s %s", symbols[change.Action], change.Section, change.Slug)
+		if len(change.Fields) > 0 {
+			line += ": " + strings.Join(change.Fields, ", ")
+		}
+		fmt.Fprintln(w, line)
+	}
+	fmt.Fprintf(w, "%d creados, %d actualizados, %d sin cambios, %d solo en la base\n",
+		r.Count(ActionCreate), r.Count(ActionUpdate), r.Count(ActionUnchanged), r.Count(ActionMissing))
+}
+
+// queryer es la interfaz común de *sql.DB y *sql.Tx para las lecturas
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// stored es una fila del catálogo ya existente en la base
+type stored[T any] struct {
+	ID    int
+	Name  string
+	Entry T
+	// HasSlug es false si la fila todavía no tiene slug en la base y se usa uno
+	// derivado del nombre
+	HasSlug bool
+}
+
+// index permite buscar filas existentes por slug o, para filas creadas antes de
+// tener slug, por nombre
+type index[T any] struct {
+	rows    []stored[T]
+	slugs   []string
+	bySlug  map[string]int
+	byName  map[string]int
+	matched map[int]bool
+}
+
+// newIndex arma el índice; a las filas sin slug les asigna uno derivado del
+// nombre, agregando el ID si ese slug ya está tomado
+func newIndex[T any](rows []stored[T], slugOf func(T) string) *index[T] {
+	ix := &index[T]{
+		rows:    rows,
+		slugs:   make([]string, len(rows)),
+		bySlug:  make(map[string]int),
+		byName:  make(map[string]int),
+		matched: make(map[int]bool),
+	}
+	for i, row := range rows {
+		if row.HasSlug {
+			ix.slugs[i] = slugOf(row.Entry)
+			ix.bySlug[ix.slugs[i]] = i
+		}
+	}
+	for i, row := range rows {
+		if !row.HasSlug {
+			slug := Slugify(row.Name)
+			if _, taken := ix.bySlug[slug]; taken || slug == "" {
+				slug = fmt.Sprintf("%s-%d", slug, row.ID)
+			}
+			ix.slugs[i] = slug
+			ix.bySlug[slug] = i
+		}
+		key := strings.ToLower(row.Name)
+		if _, ok := ix.byName[key]; !ok {
+			ix.byName[key] = i
+		}
+	}
+	return ix
+}
+
+// find busca la fila por slug y, si no la encuentra, por nombre
+func (ix *index[T]) find(slug, name string) (*stored[T], bool) {
+	i, ok := ix.bySlug[slug]
+	if !ok {
+		i, ok = ix.byName[strings.ToLower(name)]
+	}
+	if !ok || ix.matched[i] {
+		return nil, false
+	}
+	ix.matched[i] = true
+	return &ix.rows[i], true
+}
+
+// slugByID devuelve el slug de cada fila (real o derivado) por ID
+func (ix *index[T]) slugByID() map[int]string {
+	slugs := make(map[int]string, len(ix.rows))
+	for i, row := range ix.rows {
+		slugs[row.ID] = ix.slugs[i]
+	}
+	return slugs
+}
+
+// reportMissing informa las filas que no aparecieron en el archivo
+func (ix *index[T]) reportMissing(section string, report *Report) {
+	for i := range ix.rows {
+		if !ix.matched[i] {
+			report.add(section, ix.slugs[i], ActionMissing, nil)
+		}
+	}
+}
+
+// Import sincroniza el catálogo de la base con el archivo en una transacción:
+// crea lo que falta y actualiza lo que difiere, sin eliminar nada. Con dryRun
+// se calcula el reporte y se descartan los cambios
+func Import(file *File, dryRun bool) (*Report, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	report := &Report{}
+	if err := importMuscleGroups(tx, file.MuscleGroups, report); err != nil {
+		return nil, err
+	}
+	if err := importEquipment(tx, file.Equipment, report); err != nil {
+		return nil, err
+	}
+	if err := importExercises(tx, file.Exercises, report); err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		return report, nil
+	}
+	return report, tx.Commit()
+}
+
+// Export lee el catálogo compartido de la base. Los ejercicios privados de los
+// usuarios no se exportan
+func Export() (*File, error) {
+	file := &File{Version: FormatVersion}
+
+	muscles, err := loadMuscleGroups(database.DB)
+	if err != nil {
+		return nil, err
+	}
+	equipment, err := loadEquipment(database.DB)
+	if err != nil {
+		return nil, err
+	}
+	exercises, err := loadExercises(database.DB, equipment.slugByID(), muscles.slugByID())
+	if err != nil {
+		return nil, err
+	}
+
+	for i, row := range muscles.rows {
+		row.Entry.Slug = muscles.slugs[i]
+		file.MuscleGroups = append(file.MuscleGroups, row.Entry)
+	}
+	for i, row := range equipment.rows {
+		row.Entry.Slug = equipment.slugs[i]
+		file.Equipment = append(file.Equipment, row.Entry)
+	}
+	for i, row := range exercises.rows {
+		row.Entry.Slug = exercises.slugs[i]
+		file.Exercises = append(file.Exercises, row.Entry)
+	}
+
+	file.Normalize()
+	return file, nil
+}
+
+func importMuscleGroups(tx *sql.Tx, entries []MuscleGroupEntry, report *Report) error {
+	existing, err := loadMuscleGroups(tx)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		row, found := existing.find(entry.Slug, entry.Name)
+		if !found {
+			_, err := tx.Exec(`INSERT INTO muscle_groups (slug, name, category) VALUES ($1, $2, $3)`,
+				entry.Slug, entry.Name, entry.Category)
+			if err != nil {
+				return fmt.Errorf("muscle_groups %s: %v", entry.Slug, err)
+			}
+			report.add(SectionMuscleGroups, entry.Slug, ActionCreate, nil)
+			continue
+		}
+
+		fields := changedFields(row.Entry, entry, row.HasSlug)
+		if len(fields) == 0 {
+			report.add(SectionMuscleGroups, entry.Slug, ActionUnchanged, nil)
+			continue
+		}
+		_, err := tx.Exec(`UPDATE muscle_groups SET slug = $1, name = $2, category = $3 WHERE id = $4`,
+			entry.Slug, entry.Name, entry.Category, row.ID)
+		if err != nil {
+			return fmt.Errorf("muscle_groups %s: %v", entry.Slug, err)
+		}
+		report.add(SectionMuscleGroups, entry.Slug, ActionUpdate, fields)
+	}
+
+	existing.reportMissing(SectionMuscleGroups, report)
+	return nil
+}
+
+func importEquipment(tx *sql.Tx, entries []EquipmentEntry, report *Report) error {
+	existing, err := loadEquipment(tx)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		plates := entry.Plates
+		if plates == nil {
+			plates = []float64{}
+		}
+		args := []interface{}{
+			entry.Slug, entry.Name, entry.Category, entry.Observations, entry.ImageURL, entry.LoadType,
+			entry.BarWeight, pq.Array(plates), entry.StackIncrement, entry.DumbbellStep, entry.MinWeight, entry.MaxWeight,
+		}
+
+		row, found := existing.find(entry.Slug, entry.Name)
+		if !found {
+			var id int
+			err := tx.QueryRow(`
+				INSERT INTO equipment (slug, name, category, observations, image_url, load_type,
+					bar_weight, plates, stack_increment, dumbbell_step, min_weight, max_weight)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+				RETURNING id
+			`, args...).Scan(&id)
+			if err != nil {
+				return fmt.Errorf("equipment %s: %v", entry.Slug, err)
+			}
+			if err := replaceAliases(tx, "equipment", id, entry.Aliases); err != nil {
+				return fmt.Errorf("equipment %s: %v", entry.Slug, err)
+			}
+			report.add(SectionEquipment, entry.Slug, ActionCreate, nil)
+			continue
+		}
+
+		fields := changedFields(row.Entry, entry, row.HasSlug)
+		if len(fields) == 0 {
+			report.add(SectionEquipment, entry.Slug, ActionUnchanged, nil)
+			continue
+		}
+		_, err := tx.Exec(`
+			UPDATE equipment
+			SET slug = $1, name = $2, category = $3, observations = $4, image_url = $5, load_type = $6,
+				bar_weight = $7, plates = $8, stack_increment = $9, dumbbell_step = $10, min_weight = $11, max_weight = $12
+			WHERE id = $13
+		`, append(args, row.ID)...)
+		if err != nil {
+			return fmt.Errorf("equipment %s: %v", entry.Slug, err)
+		}
+		if containsField(fields, "aliases") {
+			if err := replaceAliases(tx, "equipment", row.ID, entry.Aliases); err != nil {
+				return fmt.Errorf("equipment %s: %v", entry.Slug, err)
+			}
+		}
+		report.add(SectionEquipment, entry.Slug, ActionUpdate, fields)
+	}
+
+	existing.reportMissing(SectionEquipment, report)
+	return nil
+}
+
+func importExercises(tx *sql.Tx, entries []ExerciseEntry, report *Report) error {
+	// Los equipos y músculos ya están sincronizados: se resuelven sus slugs actuales
+	muscles, err := loadMuscleGroups(tx)
+	if err != nil {
+		return err
+	}
+	equipment, err := loadEquipment(tx)
+	if err != nil {
+		return err
+	}
+	muscleSlugs, equipmentSlugs := muscles.slugByID(), equipment.slugByID()
+	muscleIDs, equipmentIDs := invert(muscleSlugs), invert(equipmentSlugs)
+
+	existing, err := loadExercises(tx, equipmentSlugs, muscleSlugs)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		var equipmentID *int
+		if entry.Equipment != nil {
+			id, ok := equipmentIDs[*entry.Equipment]
+			if !ok {
+				return fmt.Errorf("exercises %s: el equipo %s no existe", entry.Slug, *entry.Equipment)
+			}
+			equipmentID = &id
+		}
+		for _, slug := range append(append([]string{}, entry.Primary...), entry.Secondary...) {
+			if _, ok := muscleIDs[slug]; !ok {
+				return fmt.Errorf("exercises %s: el grupo muscular %s no existe", entry.Slug, slug)
+			}
+		}
+		args := []interface{}{entry.Slug, entry.Name, entry.MuscleGroup, equipmentID, entry.VideoURL, entry.MeasurementMode}
+
+		row, found := existing.find(entry.Slug, entry.Name)
+		if !found {
+			var id int
+			err := tx.QueryRow(`
+				INSERT INTO exercises (slug, name, muscle_group, equipment_id, video_url, measurement_mode)
+				VALUES ($1, $2, $3, $4, $5, $6)
+				RETURNING id
+			`, args...).Scan(&id)
+			if err != nil {
+				return fmt.Errorf("exercises %s: %v", entry.Slug, err)
+			}
+			if err := replaceLinks(tx, id, entry, muscleIDs); err != nil {
+				return fmt.Errorf("exercises %s: %v", entry.Slug, err)
+			}
+			if err := replaceAliases(tx, "exercise", id, entry.Aliases); err != nil {
+				return fmt.Errorf("exercises %s: %v", entry.Slug, err)
+			}
+			report.add(SectionExercises, entry.Slug, ActionCreate, nil)
+			continue
+		}
+
+		fields := changedFields(row.Entry, entry, row.HasSlug)
+		if len(fields) == 0 {
+			report.add(SectionExercises, entry.Slug, ActionUnchanged, nil)
+			continue
+		}
+		_, err := tx.Exec(`
+			UPDATE exercises
+			SET slug = $1, name = $2, muscle_group = $3, equipment_id = $4, video_url = $5, measurement_mode = $6
+			WHERE id = $7
+		`, append(args, row.ID)...)
+		if err != nil {
+			return fmt.Errorf("exercises %s: %v", entry.Slug, err)
+		}
+		if containsField(fields, "primary") || containsField(fields, "secondary") {
+			if err := replaceLinks(tx, row.ID, entry, muscleIDs); err != nil {
+				return fmt.Errorf("exercises %s: %v", entry.Slug, err)
+			}
+		}
+		if containsField(fields, "aliases") {
+			if err := replaceAliases(tx, "exercise", row.ID, entry.Aliases); err != nil {
+				return fmt.Errorf("exercises %s: %v", entry.Slug, err)
+			}
+		}
+		report.add(SectionExercises, entry.Slug, ActionUpdate, fields)
+	}
+
+	existing.reportMissing(SectionExercises, report)
+	return nil
+}
+
+// replaceLinks reemplaza los músculos primarios y secundarios de un ejercicio
+func replaceLinks(tx *sql.Tx, exerciseID int, entry ExerciseEntry, muscleIDs map[string]int) error {
+	if _, err := tx.Exec(`DELETE FROM exercise_muscle_groups WHERE exercise_id = $1`, exerciseID); err != nil {
+		return err
+	}
+	for role, slugs := range map[string][]string{"primary": entry.Primary, "secondary": entry.Secondary} {
+		for _, slug := range slugs {
+			_, err := tx.Exec(`INSERT INTO exercise_muscle_groups (exercise_id, muscle_group_id, role) VALUES ($1, $2, $3)`,
+				exerciseID, muscleIDs[slug], role)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// replaceAliases reemplaza los sinónimos de búsqueda de una entrada
+func replaceAliases(tx *sql.Tx, entityType string, entityID int, aliases []string) error {
+	if _, err := tx.Exec(`DELETE FROM catalog_aliases WHERE entity_type = $1 AND entity_id = $2`, entityType, entityID); err != nil {
+		return err
+	}
+	for _, alias := range aliases {
+		_, err := tx.Exec(`INSERT INTO catalog_aliases (entity_type, entity_id, alias) VALUES ($1, $2, $3)`,
+			entityType, entityID, alias)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func loadMuscleGroups(q queryer) (*index[MuscleGroupEntry], error) {
+	rows, err := q.Query(`SELECT id, slug, name, category FROM muscle_groups ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []stored[MuscleGroupEntry]
+	for rows.Next() {
+		var row stored[MuscleGroupEntry]
+		var slug *string
+		if err := rows.Scan(&row.ID, &slug, &row.Entry.Name, &row.Entry.Category); err != nil {
+			return nil, err
+		}
+		row.Name = row.Entry.Name
+		row.Entry.Slug, row.HasSlug = deref(slug)
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return newIndex(result, func(e MuscleGroupEntry) string { return e.Slug }), nil
+}
+
+func loadEquipment(q queryer) (*index[EquipmentEntry], error) {
+	aliases, err := loadAliases(q, "equipment")
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.Query(`
+		SELECT id, slug, name, category, observations, image_url, load_type,
+			bar_weight, plates, stack_increment, dumbbell_step, min_weight, max_weight
+		FROM equipment
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []stored[EquipmentEntry]
+	for rows.Next() {
+		var row stored[EquipmentEntry]
+		var slug *string
+		var plates pq.Float64Array
+		entry := &row.Entry
+		err := rows.Scan(
+			&row.ID, &slug, &entry.Name, &entry.Category, &entry.Observations, &entry.ImageURL, &entry.LoadType,
+			&entry.BarWeight, &plates, &entry.StackIncrement, &entry.DumbbellStep, &entry.MinWeight, &entry.MaxWeight,
+		)
+		if err != nil {
+			return nil, err
+		}
+		row.Name = entry.Name
+		entry.Slug, row.HasSlug = deref(slug)
+		if len(plates) > 0 {
+			entry.Plates = []float64(plates)
+		}
+		entry.Aliases = aliases[row.ID]
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return newIndex(result, func(e EquipmentEntry) string { return e.Slug }), nil
+}
+
+func loadExercises(q queryer, equipmentSlugs, muscleSlugs map[int]string) (*index[ExerciseEntry], error) {
+	aliases, err := loadAliases(q, "exercise")
+	if err != nil {
+		return nil, err
+	}
+
+	links := make(map[int]map[string][]string)
+	linkRows, err := q.Query(`SELECT exercise_id, muscle_group_id, role FROM exercise_muscle_groups`)
+	if err != nil {
+		return nil, err
+	}
+	defer linkRows.Close()
+	for linkRows.Next() {
+		var exerciseID, muscleID int
+		var role string
+		if err := linkRows.Scan(&exerciseID, &muscleID, &role); err != nil {
+			return nil, err
+		}
+		if links[exerciseID] == nil {
+			links[exerciseID] = make(map[string][]string)
+		}
+		links[exerciseID][role] = append(links[exerciseID][role], muscleSlugs[muscleID])
+	}
+	if err := linkRows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err := q.Query(`
+		SELECT id, slug, name, muscle_group, equipment_id, video_url, measurement_mode
+		FROM exercises
+		WHERE owner_id IS NULL
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []stored[ExerciseEntry]
+	for rows.Next() {
+		var row stored[ExerciseEntry]
+		var slug *string
+		var equipmentID *int
+		entry := &row.Entry
+		err := rows.Scan(&row.ID, &slug, &entry.Name, &entry.MuscleGroup, &equipmentID, &entry.VideoURL, &entry.MeasurementMode)
+		if err != nil {
+			return nil, err
+		}
+		row.Name = entry.Name
+		entry.Slug, row.HasSlug = deref(slug)
+		if equipmentID != nil {
+			equipmentSlug := equipmentSlugs[*equipmentID]
+			entry.Equipment = &equipmentSlug
+		}
+		entry.Primary = links[row.ID]["primary"]
+		entry.Secondary = links[row.ID]["secondary"]
+		entry.Aliases = aliases[row.ID]
+		sort.Strings(entry.Primary)
+		sort.Strings(entry.Secondary)
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return newIndex(result, func(e ExerciseEntry) string { return e.Slug }), nil
+}
+
+// loadAliases obtiene los sinónimos ordenados de un tipo de entrada, por ID
+func loadAliases(q queryer, entityType string) (map[int][]string, error) {
+	rows, err := q.Query(`
+		SELECT entity_id, alias FROM catalog_aliases WHERE entity_type = $1 ORDER BY alias
+	`, entityType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	aliases := make(map[int][]string)
+	for rows.Next() {
+		var id int
+		var alias string
+		if err := rows.Scan(&id, &alias); err != nil {
+			return nil, err
+		}
+		aliases[id] = append(aliases[id], alias)
+	}
+	return aliases, rows.Err()
+}
+
+// changedFields compara dos entradas campo a campo y devuelve los nombres (según
+// la etiqueta yaml) de los que difieren. Si la fila no tenía slug en la base, el
+// slug siempre se informa como cambiado para que se guarde
+func changedFields(current, desired interface{}, hasSlug bool) []string {
+	a, b := reflect.ValueOf(current), reflect.ValueOf(desired)
+	var fields []string
+	for i := 0; i < a.NumField(); i++ {
+		name := strings.Split(a.Type().Field(i).Tag.Get("yaml"), ",")[0]
+		if name == "slug" && !hasSlug {
+			fields = append(fields, name)
+			continue
+		}
+		if !equalValues(a.Field(i), b.Field(i)) {
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
+
+// equalValues compara dos valores tratando los slices vacíos y nil como iguales
+func equalValues(a, b reflect.Value) bool {
+	if a.Kind() == reflect.Slice && a.Len() == 0 && b.Len() == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
+
+// containsField indica si field está en fields
+func containsField(fields []string, field string) bool {
+	return contains(fields, field)
+}
+
+// invert devuelve el mapa slug -> ID
+func invert(slugs map[int]string) map[string]int {
+	ids := make(map[string]int, len(slugs))
+	for id, slug := range slugs {
+		ids[slug] = id
+	}
+	return ids
+}
+
+// deref devuelve el valor de un texto nullable e indica si no era NULL
+func deref(value *string) (string, bool) {
+	if value == nil {
+		return "", false
+	}
+	return *value, true
+}
@@ -0,0 +1,25 @@
+-- Migraciones para tokens de acceso personales (atajos del teléfono, scripts de
+-- planillas, etc.). Solo se guarda el SHA-256 del token; el valor completo se
+-- muestra una única vez al crearlo
+
+CREATE TABLE IF NOT EXISTS public.personal_access_tokens (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES auth.users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL CHECK (length(name) BETWEEN 1 AND 100),
+    -- Comienzo del token, para que el usuario lo reconozca en el listado
+    token_prefix TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    scopes TEXT[] NOT NULL CHECK (cardinality(scopes) > 0 AND scopes <@ ARRAY['read', 'workouts:write']),
+    expires_at TIMESTAMP WITH TIME ZONE,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_personal_access_tokens_user ON public.personal_access_tokens(user_id, created_at DESC);
+
+ALTER TABLE public.personal_access_tokens ENABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS "Users can manage own access tokens" ON public.personal_access_tokens;
+CREATE POLICY "Users can manage own access tokens" ON public.personal_access_tokens
+    FOR ALL USING (auth.uid() = user_id) WITH CHECK (auth.uid() = user_id);
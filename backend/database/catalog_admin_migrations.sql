@@ -0,0 +1,10 @@
+-- Migraciones para la administración del catálogo desde la API
+
+-- 1. Evitar vínculos duplicados ejercicio-músculo
+CREATE UNIQUE INDEX IF NOT EXISTS idx_exercise_muscle_groups_unique
+    ON public.exercise_muscle_groups(exercise_id, muscle_group_id, role);
+
+-- 2. Los administradores se marcan en app_metadata (solo editable desde Supabase):
+-- UPDATE auth.users
+-- SET raw_app_meta_data = raw_app_meta_data || '{"role": "admin"}'
+-- WHERE email = 'coordinador@goalritmo.com';
@@ -0,0 +1,39 @@
+-- Migraciones para la importación/exportación del catálogo (go run main.go catalog ...)
+
+-- 1. Slugs estables: identifican cada entrada del catálogo entre entornos
+ALTER TABLE public.muscle_groups ADD COLUMN IF NOT EXISTS slug TEXT;
+ALTER TABLE public.equipment ADD COLUMN IF NOT EXISTS slug TEXT;
+ALTER TABLE public.exercises ADD COLUMN IF NOT EXISTS slug TEXT;
+
+-- 2. Completar los slugs de las filas existentes a partir del nombre; si dos
+-- nombres generan el mismo slug, se agrega el ID. Los ejercicios privados no
+-- tienen slug
+UPDATE public.muscle_groups m SET slug = s.slug || CASE WHEN s.n > 1 THEN '-' || m.id ELSE '' END
+FROM (
+    SELECT id, trim(both '-' from regexp_replace(lower(public.f_unaccent(name)), '[^a-z0-9]+', '-', 'g')) AS slug,
+        ROW_NUMBER() OVER (PARTITION BY trim(both '-' from regexp_replace(lower(public.f_unaccent(name)), '[^a-z0-9]+', '-', 'g')) ORDER BY id) AS n
+    FROM public.muscle_groups
+) s
+WHERE m.id = s.id AND m.slug IS NULL;
+
+UPDATE public.equipment e SET slug = s.slug || CASE WHEN s.n > 1 THEN '-' || e.id ELSE '' END
+FROM (
+    SELECT id, trim(both '-' from regexp_replace(lower(public.f_unaccent(name)), '[^a-z0-9]+', '-', 'g')) AS slug,
+        ROW_NUMBER() OVER (PARTITION BY trim(both '-' from regexp_replace(lower(public.f_unaccent(name)), '[^a-z0-9]+', '-', 'g')) ORDER BY id) AS n
+    FROM public.equipment
+) s
+WHERE e.id = s.id AND e.slug IS NULL;
+
+UPDATE public.exercises e SET slug = s.slug || CASE WHEN s.n > 1 THEN '-' || e.id ELSE '' END
+FROM (
+    SELECT id, trim(both '-' from regexp_replace(lower(public.f_unaccent(name)), '[^a-z0-9]+', '-', 'g')) AS slug,
+        ROW_NUMBER() OVER (PARTITION BY trim(both '-' from regexp_replace(lower(public.f_unaccent(name)), '[^a-z0-9]+', '-', 'g')) ORDER BY id) AS n
+    FROM public.exercises
+    WHERE owner_id IS NULL
+) s
+WHERE e.id = s.id AND e.slug IS NULL;
+
+-- 3. Unicidad (los NULL no colisionan)
+CREATE UNIQUE INDEX IF NOT EXISTS idx_muscle_groups_slug ON public.muscle_groups(slug) WHERE slug IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_equipment_slug ON public.equipment(slug) WHERE slug IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_exercises_slug ON public.exercises(slug) WHERE slug IS NOT NULL;
@@ -0,0 +1,28 @@
+-- Migraciones para ejercicios personalizados por usuario
+
+-- 1. Dueño del ejercicio: NULL para el catálogo compartido, el usuario para los privados
+ALTER TABLE public.exercises ADD COLUMN IF NOT EXISTS owner_id UUID REFERENCES auth.users(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_exercises_owner ON public.exercises(owner_id) WHERE owner_id IS NOT NULL;
+
+-- 2. Cómo se mide el ejercicio: peso y repeticiones, solo repeticiones (peso corporal) o tiempo
+ALTER TABLE public.exercises ADD COLUMN IF NOT EXISTS measurement_mode TEXT NOT NULL DEFAULT 'weight_reps'
+    CHECK (measurement_mode IN ('weight_reps', 'reps', 'time'));
+
+-- 3. Peso y repeticiones según el modo: los ejercicios de peso corporal se
+-- registran sin peso (o con el lastre) y los de tiempo sin repeticiones. La API
+-- guarda 0 en lo que el modo no usa
+ALTER TABLE public.workouts ALTER COLUMN weight DROP NOT NULL;
+ALTER TABLE public.workouts ALTER COLUMN reps DROP NOT NULL;
+ALTER TABLE public.workouts DROP CONSTRAINT IF EXISTS weight_positive;
+ALTER TABLE public.workouts DROP CONSTRAINT IF EXISTS reps_positive;
+ALTER TABLE public.workouts DROP CONSTRAINT IF EXISTS weight_non_negative;
+ALTER TABLE public.workouts ADD CONSTRAINT weight_non_negative CHECK (weight >= 0);
+ALTER TABLE public.workouts DROP CONSTRAINT IF EXISTS reps_non_negative;
+ALTER TABLE public.workouts ADD CONSTRAINT reps_non_negative CHECK (reps >= 0);
+
+-- 4. Los nombres son únicos dentro del catálogo y dentro de los ejercicios de cada
+-- usuario: un ejercicio privado no choca con los privados de otros usuarios
+ALTER TABLE public.exercises DROP CONSTRAINT IF EXISTS exercises_name_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_exercises_catalog_name ON public.exercises(name) WHERE owner_id IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_exercises_owner_name ON public.exercises(owner_id, name) WHERE owner_id IS NOT NULL;
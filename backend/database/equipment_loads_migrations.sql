@@ -0,0 +1,25 @@
+-- Migraciones para describir las cargas de cada equipo
+
+-- load_type indica cómo se carga el equipo:
+--   'plates'    barra o máquina de discos (bar_weight + discos por lado)
+--   'stack'     máquina de placas con selector (min_weight + stack_increment)
+--   'dumbbells' mancuernas fijas (dumbbell_step entre pares)
+--   'bodyweight' sin carga externa
+ALTER TABLE public.equipment ADD COLUMN IF NOT EXISTS load_type TEXT
+    CHECK (load_type IN ('plates', 'stack', 'dumbbells', 'bodyweight'));
+ALTER TABLE public.equipment ADD COLUMN IF NOT EXISTS bar_weight NUMERIC(5,2) CHECK (bar_weight >= 0);
+-- Pesos de discos disponibles (se asume que hay pares de cada uno)
+ALTER TABLE public.equipment ADD COLUMN IF NOT EXISTS plates NUMERIC(5,2)[] NOT NULL DEFAULT '{}';
+ALTER TABLE public.equipment ADD COLUMN IF NOT EXISTS stack_increment NUMERIC(5,2) CHECK (stack_increment > 0);
+ALTER TABLE public.equipment ADD COLUMN IF NOT EXISTS dumbbell_step NUMERIC(5,2) CHECK (dumbbell_step > 0);
+ALTER TABLE public.equipment ADD COLUMN IF NOT EXISTS min_weight NUMERIC(6,2) CHECK (min_weight >= 0);
+ALTER TABLE public.equipment ADD COLUMN IF NOT EXISTS max_weight NUMERIC(6,2) CHECK (max_weight >= 0);
+
+-- Valores por defecto razonables para los equipos existentes
+UPDATE public.equipment
+SET load_type = 'plates', bar_weight = 20, plates = '{25,20,15,10,5,2.5,1.25}'
+WHERE load_type IS NULL AND category = 'rack';
+
+UPDATE public.equipment
+SET load_type = 'stack', min_weight = 5, stack_increment = 5
+WHERE load_type IS NULL AND category IN ('maquinas', 'cables');
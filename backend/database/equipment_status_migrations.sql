@@ -0,0 +1,32 @@
+-- Migraciones para el estado de disponibilidad y mantenimiento de los equipos
+
+-- 1. Estado actual del equipo
+ALTER TABLE public.equipment ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'available'
+    CHECK (status IN ('available', 'out_of_order', 'removed'));
+
+-- 2. Historial de cambios de estado
+CREATE TABLE IF NOT EXISTS public.equipment_status_history (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    equipment_id BIGINT NOT NULL REFERENCES public.equipment(id) ON DELETE CASCADE,
+    status TEXT NOT NULL CHECK (status IN ('available', 'out_of_order', 'removed')),
+    note TEXT,
+    changed_by UUID REFERENCES auth.users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_equipment_status_history_equipment
+    ON public.equipment_status_history(equipment_id, created_at DESC);
+
+-- 3. Reportes de usuarios sobre equipos rotos
+CREATE TABLE IF NOT EXISTS public.equipment_reports (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    equipment_id BIGINT NOT NULL REFERENCES public.equipment(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES auth.users(id) ON DELETE CASCADE,
+    description TEXT NOT NULL,
+    resolved BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    resolved_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_equipment_reports_open
+    ON public.equipment_reports(equipment_id) WHERE NOT resolved;
@@ -0,0 +1,16 @@
+-- Migraciones para las instrucciones de técnica de los ejercicios
+
+-- 1. Instrucciones por ejercicio e idioma: cada fila es un ítem de una sección
+-- (preparación, pasos de la ejecución, errores comunes o indicaciones breves)
+CREATE TABLE IF NOT EXISTS public.exercise_instructions (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    exercise_id BIGINT NOT NULL REFERENCES public.exercises(id) ON DELETE CASCADE,
+    locale TEXT NOT NULL DEFAULT 'es' CHECK (locale IN ('es', 'en')),
+    section TEXT NOT NULL CHECK (section IN ('setup', 'step', 'mistake', 'cue')),
+    position INTEGER NOT NULL CHECK (position > 0),
+    text TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT exercise_instructions_unique UNIQUE (exercise_id, locale, section, position)
+);
+
+CREATE INDEX IF NOT EXISTS idx_exercise_instructions_exercise ON public.exercise_instructions(exercise_id, locale);
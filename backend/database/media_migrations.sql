@@ -0,0 +1,19 @@
+-- Migraciones para archivos subidos (imágenes de equipos y videos de ejercicios)
+
+-- 1. Archivos guardados en el almacenamiento (ver storage.Init): la clave es la
+-- ruta dentro del directorio local o del bucket S3
+CREATE TABLE IF NOT EXISTS public.media (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    kind TEXT NOT NULL CHECK (kind IN ('image', 'video')),
+    storage_key TEXT NOT NULL UNIQUE,
+    thumbnail_key TEXT,
+    content_type TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL CHECK (size_bytes > 0),
+    uploaded_by UUID REFERENCES auth.users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- 2. Archivo asociado a cada entrada del catálogo. Si está definido tiene
+-- prioridad sobre image_url / video_url
+ALTER TABLE public.equipment ADD COLUMN IF NOT EXISTS image_media_id BIGINT REFERENCES public.media(id) ON DELETE SET NULL;
+ALTER TABLE public.exercises ADD COLUMN IF NOT EXISTS video_media_id BIGINT REFERENCES public.media(id) ON DELETE SET NULL;
@@ -0,0 +1,60 @@
+-- Migraciones para rutinas y programas de varias semanas
+
+-- 1. Rutinas (plantillas) y sus ejercicios
+CREATE TABLE IF NOT EXISTS public.routine_templates (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    name TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS public.routine_template_exercises (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    template_id BIGINT NOT NULL REFERENCES public.routine_templates(id) ON DELETE CASCADE,
+    exercise_id BIGINT NOT NULL REFERENCES public.exercises(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL DEFAULT 1,
+    sets INTEGER NOT NULL DEFAULT 3 CHECK (sets > 0),
+    reps INTEGER NOT NULL DEFAULT 10 CHECK (reps > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_routine_template_exercises_template ON public.routine_template_exercises(template_id, position);
+
+-- 2. Programas: secuencia de semanas y días, cada uno apuntando a una rutina
+CREATE TABLE IF NOT EXISTS public.programs (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    description TEXT,
+    weeks INTEGER NOT NULL CHECK (weeks > 0),
+    -- Reglas de progresión: sumar peso cuando se completan todas las reps,
+    -- y descargar cada N semanas al porcentaje indicado
+    weight_increment NUMERIC(5,2) NOT NULL DEFAULT 2.5 CHECK (weight_increment >= 0),
+    deload_every INTEGER CHECK (deload_every > 0),
+    deload_percent NUMERIC(3,2) NOT NULL DEFAULT 0.90 CHECK (deload_percent > 0 AND deload_percent <= 1),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS public.program_days (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    program_id BIGINT NOT NULL REFERENCES public.programs(id) ON DELETE CASCADE,
+    week INTEGER NOT NULL CHECK (week > 0),
+    -- 1 = lunes ... 7 = domingo
+    day_of_week INTEGER NOT NULL CHECK (day_of_week BETWEEN 1 AND 7),
+    template_id BIGINT NOT NULL REFERENCES public.routine_templates(id) ON DELETE CASCADE,
+    CONSTRAINT program_days_unique UNIQUE (program_id, week, day_of_week)
+);
+
+-- 3. Inscripciones de usuarios (solo una activa por usuario)
+CREATE TABLE IF NOT EXISTS public.program_enrollments (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES auth.users(id) ON DELETE CASCADE,
+    program_id BIGINT NOT NULL REFERENCES public.programs(id) ON DELETE CASCADE,
+    start_date DATE NOT NULL DEFAULT CURRENT_DATE,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_program_enrollments_active_user
+    ON public.program_enrollments(user_id) WHERE active;
+
+-- 4. Índice para buscar el historial de un ejercicio por usuario
+CREATE INDEX IF NOT EXISTS idx_workouts_user_exercise_created_at
+    ON public.workouts(user_id, exercise_id, created_at);
@@ -0,0 +1,11 @@
+-- Migraciones para sugerencias de progresión
+
+-- 1. RPE opcional por serie (1-10), usado por la progresión basada en RPE
+ALTER TABLE public.workouts ADD COLUMN IF NOT EXISTS rpe NUMERIC(3,1);
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'rpe_range') THEN
+        ALTER TABLE public.workouts ADD CONSTRAINT rpe_range CHECK (rpe >= 1 AND rpe <= 10);
+    END IF;
+END $$;
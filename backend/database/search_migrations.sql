@@ -0,0 +1,31 @@
+-- Migraciones para búsqueda sin acentos y tolerante a errores en el catálogo
+
+CREATE EXTENSION IF NOT EXISTS unaccent;
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- unaccent() no es IMMUTABLE, por lo que no se puede usar en índices:
+-- este wrapper fija el diccionario y permite indexar
+CREATE OR REPLACE FUNCTION public.f_unaccent(text)
+RETURNS text AS $$
+    SELECT public.unaccent('public.unaccent', $1)
+$$ LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT;
+
+-- 1. Sinónimos y nombres alternativos (p. ej. "bench press" para "Press de banca")
+CREATE TABLE IF NOT EXISTS public.catalog_aliases (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    entity_type TEXT NOT NULL CHECK (entity_type IN ('exercise', 'equipment')),
+    entity_id BIGINT NOT NULL,
+    alias TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT catalog_aliases_unique UNIQUE (entity_type, entity_id, alias)
+);
+
+CREATE INDEX IF NOT EXISTS idx_catalog_aliases_entity ON public.catalog_aliases(entity_type, entity_id);
+
+-- 2. Índices trigram sobre los nombres normalizados
+CREATE INDEX IF NOT EXISTS idx_exercises_name_trgm
+    ON public.exercises USING gin (public.f_unaccent(lower(name)) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_equipment_name_trgm
+    ON public.equipment USING gin (public.f_unaccent(lower(name)) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_catalog_aliases_alias_trgm
+    ON public.catalog_aliases USING gin (public.f_unaccent(lower(alias)) gin_trgm_ops);
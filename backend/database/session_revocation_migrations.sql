@@ -0,0 +1,32 @@
+-- Migraciones para revocar JWT antes de que venzan (cerrar sesión en un
+-- dispositivo o en todos). El servidor mantiene una copia en memoria y la relee
+-- periódicamente (ver middleware.RevocationList)
+
+-- Tokens revocados: jti, o session_id en los tokens de Supabase. Se borran al
+-- llegar a expires_at: el vencimiento del token, o para un session_id la duración
+-- máxima de la sesión
+CREATE TABLE IF NOT EXISTS public.revoked_tokens (
+    token_id TEXT PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES auth.users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    revoked_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires ON public.revoked_tokens(expires_at);
+
+-- Todos los tokens de un usuario emitidos hasta revoked_before quedan revocados
+CREATE TABLE IF NOT EXISTS public.session_revocations (
+    user_id UUID PRIMARY KEY REFERENCES auth.users(id) ON DELETE CASCADE,
+    revoked_before TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+ALTER TABLE public.revoked_tokens ENABLE ROW LEVEL SECURITY;
+ALTER TABLE public.session_revocations ENABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS "Users can view own revoked tokens" ON public.revoked_tokens;
+CREATE POLICY "Users can view own revoked tokens" ON public.revoked_tokens
+    FOR SELECT USING (auth.uid() = user_id);
+
+DROP POLICY IF EXISTS "Users can view own session revocations" ON public.session_revocations;
+CREATE POLICY "Users can view own session revocations" ON public.session_revocations
+    FOR SELECT USING (auth.uid() = user_id);
@@ -0,0 +1,19 @@
+-- Migraciones para el cronómetro de descanso persistido en el servidor
+
+CREATE TABLE IF NOT EXISTS public.rest_timers (
+    user_id UUID PRIMARY KEY REFERENCES auth.users(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'idle' CHECK (status IN ('idle', 'running', 'paused')),
+    target_seconds INTEGER NOT NULL DEFAULT 45 CHECK (target_seconds > 0 AND target_seconds <= 600),
+    -- Momento en que se inició o reanudó (NULL si no está corriendo)
+    started_at TIMESTAMP WITH TIME ZONE,
+    -- Tiempo acumulado antes de la última pausa
+    accumulated_ms BIGINT NOT NULL DEFAULT 0 CHECK (accumulated_ms >= 0),
+    last_workout_id BIGINT REFERENCES public.workouts(id) ON DELETE SET NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE public.rest_timers ENABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS "Users can manage own timer" ON public.rest_timers;
+CREATE POLICY "Users can manage own timer" ON public.rest_timers
+    FOR ALL USING (auth.uid() = user_id) WITH CHECK (auth.uid() = user_id);
@@ -0,0 +1,44 @@
+-- Migraciones para el catálogo traducido (es/en)
+-- Los textos de las tablas del catálogo están en el idioma por defecto (es); las
+-- traducciones se guardan por entrada, idioma y campo
+
+-- 1. Traducciones de nombres y observaciones
+CREATE TABLE IF NOT EXISTS public.catalog_translations (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    entity_type TEXT NOT NULL CHECK (entity_type IN ('exercise', 'equipment', 'muscle_group')),
+    entity_id BIGINT NOT NULL,
+    locale TEXT NOT NULL CHECK (locale IN ('en')),
+    field TEXT NOT NULL CHECK (field IN ('name', 'observations')),
+    value TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT catalog_translations_unique UNIQUE (entity_type, entity_id, locale, field)
+);
+
+CREATE INDEX IF NOT EXISTS idx_catalog_translations_entity ON public.catalog_translations(entity_type, entity_id);
+
+-- 2. Índice trigram para que la búsqueda encuentre los nombres en cualquier idioma
+CREATE INDEX IF NOT EXISTS idx_catalog_translations_name_trgm
+    ON public.catalog_translations USING gin (public.f_unaccent(lower(value)) gin_trgm_ops)
+    WHERE field = 'name';
+
+-- 3. Las traducciones se eliminan junto con su entrada
+CREATE OR REPLACE FUNCTION public.delete_catalog_translations()
+RETURNS TRIGGER AS $$
+BEGIN
+    DELETE FROM public.catalog_translations WHERE entity_type = TG_ARGV[0] AND entity_id = OLD.id;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS delete_exercise_translations ON public.exercises;
+CREATE TRIGGER delete_exercise_translations AFTER DELETE ON public.exercises
+    FOR EACH ROW EXECUTE FUNCTION public.delete_catalog_translations('exercise');
+
+DROP TRIGGER IF EXISTS delete_equipment_translations ON public.equipment;
+CREATE TRIGGER delete_equipment_translations AFTER DELETE ON public.equipment
+    FOR EACH ROW EXECUTE FUNCTION public.delete_catalog_translations('equipment');
+
+DROP TRIGGER IF EXISTS delete_muscle_group_translations ON public.muscle_groups;
+CREATE TRIGGER delete_muscle_group_translations AFTER DELETE ON public.muscle_groups
+    FOR EACH ROW EXECUTE FUNCTION public.delete_catalog_translations('muscle_group');
@@ -0,0 +1,11 @@
+-- Migraciones para el generador de calentamiento
+
+-- 1. Marcar series de calentamiento (no cuentan para la progresión)
+ALTER TABLE public.workouts ADD COLUMN IF NOT EXISTS is_warmup BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- 2. Esquema de calentamiento por usuario: [{"percent": 0.4, "reps": 5}, ...]
+CREATE TABLE IF NOT EXISTS public.user_warmup_settings (
+    user_id UUID PRIMARY KEY REFERENCES auth.users(id) ON DELETE CASCADE,
+    steps JSONB NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goalritmo/gym/backend/database"
+	"github.com/goalritmo/gym/backend/middleware"
+	"github.com/goalritmo/gym/backend/models"
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+)
+
+// Límites de los tokens de acceso personales
+const (
+	maxAccessTokensPerUser   = 20
+	maxAccessTokenNameLength = 100
+	maxAccessTokenDays       = 365
+)
+
+// accessTokenColumns es la lista de columnas que lee scanAccessToken
+const accessTokenColumns = `id, name, token_prefix, scopes, expires_at, last_used_at, created_at`
+
+func scanAccessToken(row rowScanner, token *models.AccessToken) error {
+	var scopes pq.StringArray
+	err := row.Scan(
+		&token.ID,
+		&token.Name,
+		&token.Prefix,
+		&scopes,
+		&token.ExpiresAt,
+		&token.LastUsedAt,
+		&token.CreatedAt,
+	)
+	token.Scopes = []string(scopes)
+	return err
+}
+
+// GetAccessTokensHandler lista los tokens de acceso activos del usuario actual
+func GetAccessTokensHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, ok := middleware.CurrentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT `+accessTokenColumns+`
+		FROM personal_access_tokens
+		WHERE user_id = $1 AND revoked_at IS NULL
+		ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		http.Error(w, "Error consultando tokens", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	tokens := []models.AccessToken{}
+	for rows.Next() {
+		var token models.AccessToken
+		if err := scanAccessToken(rows, &token); err != nil {
+			http.Error(w, "Error escaneando token", http.StatusInternalServerError)
+			return
+		}
+		tokens = append(tokens, token)
+	}
+
+	json.NewEncoder(w).Encode(tokens)
+}
+
+// CreateAccessTokenHandler crea un token de acceso personal. El token completo
+// solo se devuelve en esta respuesta
+func CreateAccessTokenHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, ok := middleware.CurrentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.CreateAccessTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+	if err := validateAccessTokenRequest(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var active int
+	err := database.DB.QueryRow(`
+		SELECT COUNT(*) FROM personal_access_tokens
+		WHERE user_id = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())
+	`, userID).Scan(&active)
+	if err != nil {
+		http.Error(w, "Error consultando tokens", http.StatusInternalServerError)
+		return
+	}
+	if active >= maxAccessTokensPerUser {
+		http.Error(w, fmt.Sprintf("Se permiten como máximo %d tokens activos", maxAccessTokensPerUser), http.StatusConflict)
+		return
+	}
+
+	secret, prefix, hash, err := middleware.GenerateAccessToken()
+	if err != nil {
+		http.Error(w, "Error generando token", http.StatusInternalServerError)
+		return
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresInDays != nil {
+		t := time.Now().AddDate(0, 0, *req.ExpiresInDays)
+		expiresAt = &t
+	}
+
+	created := models.CreatedAccessToken{Token: secret}
+	err = scanAccessToken(database.DB.QueryRow(`
+		INSERT INTO personal_access_tokens (user_id, name, token_prefix, token_hash, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+accessTokenColumns,
+		userID, req.Name, prefix, hash, pq.Array(req.Scopes), expiresAt,
+	), &created.AccessToken)
+	if err != nil {
+		http.Error(w, "Error creando token", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// RevokeAccessTokenHandler revoca un token del usuario actual. Deja de aceptarse
+// de inmediato
+func RevokeAccessTokenHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := middleware.CurrentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := database.DB.Exec(`
+		UPDATE personal_access_tokens SET revoked_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+	`, id, userID)
+	if err != nil {
+		http.Error(w, "Error revocando token", http.StatusInternalServerError)
+		return
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected == 0 {
+		http.Error(w, "Token no encontrado", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validateAccessTokenRequest valida nombre, alcances y vencimiento, y normaliza el nombre
+func validateAccessTokenRequest(req *models.CreateAccessTokenRequest) error {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return fmt.Errorf("El nombre es requerido")
+	}
+	if len([]rune(req.Name)) > maxAccessTokenNameLength {
+		return fmt.Errorf("El nombre no puede superar los %d caracteres", maxAccessTokenNameLength)
+	}
+
+	if len(req.Scopes) == 0 {
+		return fmt.Errorf("Se requiere al menos un alcance (%s)", strings.Join(models.AccessTokenScopes, ", "))
+	}
+	seen := make(map[string]bool)
+	for _, scope := range req.Scopes {
+		if !containsString(models.AccessTokenScopes, scope) {
+			return fmt.Errorf("Alcance inválido: %s (%s)", scope, strings.Join(models.AccessTokenScopes, ", "))
+		}
+		if seen[scope] {
+			return fmt.Errorf("Alcance repetido: %s", scope)
+		}
+		seen[scope] = true
+	}
+
+	if req.ExpiresInDays != nil && (*req.ExpiresInDays <= 0 || *req.ExpiresInDays > maxAccessTokenDays) {
+		return fmt.Errorf("expires_in_days debe estar entre 1 y %d", maxAccessTokenDays)
+	}
+	return nil
+}
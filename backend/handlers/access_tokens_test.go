@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/goalritmo/gym/backend/models"
+)
+
+func TestValidateAccessTokenRequest(t *testing.T) {
+	req := models.CreateAccessTokenRequest{Name: "  Atajo del teléfono ", Scopes: []string{"read", "workouts:write"}, ExpiresInDays: intPtr(90)}
+	if err := validateAccessTokenRequest(&req); err != nil {
+		t.Fatal(err)
+	}
+	if req.Name != "Atajo del teléfono" {
+		t.Errorf("Expected trimmed name, got %q", req.Name)
+	}
+
+	invalid := []models.CreateAccessTokenRequest{
+		{Name: "", Scopes: []string{"read"}},
+		{Name: "Planilla", Scopes: nil},
+		{Name: "Planilla", Scopes: []string{"admin"}},
+		{Name: "Planilla", Scopes: []string{"read", "read"}},
+		{Name: "Planilla", Scopes: []string{"read"}, ExpiresInDays: intPtr(0)},
+		{Name: "Planilla", Scopes: []string{"read"}, ExpiresInDays: intPtr(400)},
+	}
+	for _, req := range invalid {
+		if err := validateAccessTokenRequest(&req); err == nil {
+			t.Errorf("Expected error for %+v", req)
+		}
+	}
+}
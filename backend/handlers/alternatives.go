@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/goalritmo/gym/backend/database"
+	"github.com/goalritmo/gym/backend/middleware"
+	"github.com/goalritmo/gym/backend/models"
+	"github.com/gorilla/mux"
+)
+
+// Parámetros de las sugerencias de reemplazo
+const (
+	defaultAlternativesLimit = 10
+	maxAlternativesLimit     = 50
+)
+
+// muscleRoleWeight es el peso de un músculo según su rol en el ejercicio
+var muscleRoleWeight = map[string]float64{
+	models.MuscleRolePrimary:   1,
+	models.MuscleRoleSecondary: 0.5,
+}
+
+// alternativeCandidate agrupa los datos de un ejercicio candidato
+type alternativeCandidate struct {
+	alternative models.ExerciseAlternative
+	muscles     map[int]string // muscle_group_id -> rol
+}
+
+// alternativesParams representa los parámetros de GET /api/exercises/{id}/alternatives
+type alternativesParams struct {
+	ExcludeEquipment   map[int]bool
+	IncludeUnavailable bool
+	Limit              int
+}
+
+// GetAlternativesHandler sugiere ejercicios para reemplazar a otro, ordenados por
+// coincidencia de músculos primarios y secundarios. Se descartan los equipos que
+// no están disponibles (salvo include_unavailable=true) y los indicados en
+// exclude_equipment, p. ej. los que están ocupados
+func GetAlternativesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	exerciseID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := middleware.CurrentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	params, err := parseAlternativesParams(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	exercise, err := loadExercise(exerciseID)
+	if err != nil || !exerciseVisibleTo(exercise, userID) {
+		http.Error(w, "Ejercicio no encontrado", http.StatusNotFound)
+		return
+	}
+
+	alternatives, err := suggestAlternatives(exerciseID, userID, params)
+	if err != nil {
+		http.Error(w, "Error consultando ejercicios alternativos", http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(alternatives)
+}
+
+// suggestAlternatives obtiene los reemplazos ordenados para un ejercicio
+func suggestAlternatives(exerciseID int, userID string, params alternativesParams) ([]models.ExerciseAlternative, error) {
+	source, muscleNames, err := exerciseMuscles(exerciseID)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := alternativeCandidates(exerciseID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return rankAlternatives(source, muscleNames, candidates, params), nil
+}
+
+// parseAlternativesParams lee exclude_equipment (IDs separados por coma) y limit
+func parseAlternativesParams(q url.Values) (alternativesParams, error) {
+	params := alternativesParams{
+		ExcludeEquipment: make(map[int]bool),
+		Limit:            defaultAlternativesLimit,
+	}
+
+	if value := q.Get("exclude_equipment"); value != "" {
+		for _, part := range strings.Split(value, ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil {
+				return params, fmt.Errorf("exclude_equipment inválido")
+			}
+			params.ExcludeEquipment[id] = true
+		}
+	}
+
+	if value := q.Get("include_unavailable"); value != "" {
+		include, err := strconv.ParseBool(value)
+		if err != nil {
+			return params, fmt.Errorf("include_unavailable inválido")
+		}
+		params.IncludeUnavailable = include
+	}
+
+	if err := parseIntParam(q.Get("limit"), &params.Limit); err != nil || params.Limit <= 0 {
+		return params, fmt.Errorf("limit inválido")
+	}
+	if params.Limit > maxAlternativesLimit {
+		params.Limit = maxAlternativesLimit
+	}
+
+	return params, nil
+}
+
+// exerciseMuscles obtiene los músculos de un ejercicio (muscle_group_id -> rol) y sus nombres
+func exerciseMuscles(exerciseID int) (map[int]string, map[int]string, error) {
+	rows, err := database.DB.Query(`
+		SELECT emg.muscle_group_id, emg.role, mg.name
+		FROM exercise_muscle_groups emg
+		JOIN muscle_groups mg ON emg.muscle_group_id = mg.id
+		WHERE emg.exercise_id = $1
+	`, exerciseID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	muscles := make(map[int]string)
+	names := make(map[int]string)
+	for rows.Next() {
+		var id int
+		var role, name string
+		if err := rows.Scan(&id, &role, &name); err != nil {
+			return nil, nil, err
+		}
+		// Si un músculo figura con ambos roles, prevalece el primario
+		if muscles[id] != models.MuscleRolePrimary {
+			muscles[id] = role
+		}
+		names[id] = name
+	}
+	return muscles, names, rows.Err()
+}
+
+// alternativeCandidates obtiene los ejercicios visibles que comparten al menos un
+// músculo con el ejercicio dado, con todos sus músculos
+func alternativeCandidates(exerciseID int, userID string) ([]alternativeCandidate, error) {
+	rows, err := database.DB.Query(`
+		SELECT e.id, e.name, e.equipment_id, eq.name, eq.status, emg.muscle_group_id, emg.role
+		FROM exercises e
+		JOIN exercise_muscle_groups emg ON emg.exercise_id = e.id
+		LEFT JOIN equipment eq ON e.equipment_id = eq.id
+		WHERE e.id <> $1
+			AND (e.owner_id IS NULL OR e.owner_id = $2)
+			AND e.id IN (
+				SELECT c.exercise_id FROM exercise_muscle_groups c
+				WHERE c.muscle_group_id IN (SELECT muscle_group_id FROM exercise_muscle_groups WHERE exercise_id = $1)
+			)
+		ORDER BY e.id
+	`, exerciseID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var candidates []alternativeCandidate
+	for rows.Next() {
+		var alternative models.ExerciseAlternative
+		var muscleID int
+		var role string
+		err := rows.Scan(&alternative.ExerciseID, &alternative.Name, &alternative.EquipmentID, &alternative.Equipment, &alternative.EquipmentStatus, &muscleID, &role)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(candidates) == 0 || candidates[len(candidates)-1].alternative.ExerciseID != alternative.ExerciseID {
+			candidates = append(candidates, alternativeCandidate{alternative: alternative, muscles: make(map[int]string)})
+		}
+		current := &candidates[len(candidates)-1]
+		if current.muscles[muscleID] != models.MuscleRolePrimary {
+			current.muscles[muscleID] = role
+		}
+	}
+	return candidates, rows.Err()
+}
+
+// alternativeScore mide de 0 a 1 cuánto cubre el candidato los músculos del
+// ejercicio original: cada músculo aporta el producto de los pesos de su rol en
+// ambos ejercicios, normalizado por el máximo posible
+func alternativeScore(source, candidate map[int]string) float64 {
+	var score, best float64
+	for muscleID, role := range source {
+		weight := muscleRoleWeight[role]
+		best += weight * weight
+		score += weight * muscleRoleWeight[candidate[muscleID]]
+	}
+	if best == 0 {
+		return 0
+	}
+	return math.Round(score/best*100) / 100
+}
+
+// rankAlternatives puntúa y ordena los candidatos, descartando los equipos excluidos
+// o no disponibles. A igual puntaje se prefieren los ejercicios sin equipo
+func rankAlternatives(source map[int]string, muscleNames map[int]string, candidates []alternativeCandidate, params alternativesParams) []models.ExerciseAlternative {
+	alternatives := []models.ExerciseAlternative{}
+	for _, candidate := range candidates {
+		alternative := candidate.alternative
+		if alternative.EquipmentID != nil && params.ExcludeEquipment[*alternative.EquipmentID] {
+			continue
+		}
+		if !params.IncludeUnavailable && alternative.EquipmentStatus != nil && *alternative.EquipmentStatus != models.EquipmentAvailable {
+			continue
+		}
+
+		alternative.Score = alternativeScore(source, candidate.muscles)
+		if alternative.Score == 0 {
+			continue
+		}
+
+		alternative.SharedPrimary = []string{}
+		alternative.SharedSecondary = []string{}
+		for muscleID, role := range source {
+			if _, ok := candidate.muscles[muscleID]; !ok {
+				continue
+			}
+			if role == models.MuscleRolePrimary {
+				alternative.SharedPrimary = append(alternative.SharedPrimary, muscleNames[muscleID])
+			} else {
+				alternative.SharedSecondary = append(alternative.SharedSecondary, muscleNames[muscleID])
+			}
+		}
+		sort.Strings(alternative.SharedPrimary)
+		sort.Strings(alternative.SharedSecondary)
+
+		alternatives = append(alternatives, alternative)
+	}
+
+	sort.SliceStable(alternatives, func(i, j int) bool {
+		a, b := alternatives[i], alternatives[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if (a.EquipmentID == nil) != (b.EquipmentID == nil) {
+			return a.EquipmentID == nil
+		}
+		return a.Name < b.Name
+	})
+
+	if len(alternatives) > params.Limit {
+		alternatives = alternatives[:params.Limit]
+	}
+	return alternatives
+}
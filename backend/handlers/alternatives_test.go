@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/goalritmo/gym/backend/models"
+)
+
+func TestAlternativeScore(t *testing.T) {
+	// Press de banca: pectoral (1) primario, tríceps (2) y deltoides anterior (3) secundarios
+	source := map[int]string{1: "primary", 2: "secondary", 3: "secondary"}
+
+	tests := []struct {
+		name      string
+		candidate map[int]string
+		expected  float64
+	}{
+		{"Mismos músculos", map[int]string{1: "primary", 2: "secondary", 3: "secondary"}, 1},
+		{"Solo el primario", map[int]string{1: "primary"}, 0.67},
+		{"Primario como secundario", map[int]string{1: "secondary", 2: "secondary"}, 0.5},
+		{"Sin coincidencias", map[int]string{4: "primary"}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if score := alternativeScore(source, tt.candidate); score != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, score)
+			}
+		})
+	}
+}
+
+func TestRankAlternatives(t *testing.T) {
+	source := map[int]string{1: "primary", 2: "secondary"}
+	names := map[int]string{1: "pectoral", 2: "triceps"}
+	machine, cable := 5, 6
+
+	candidates := []alternativeCandidate{
+		{models.ExerciseAlternative{ExerciseID: 10, Name: "Press en máquina", EquipmentID: &machine}, map[int]string{1: "primary", 2: "secondary"}},
+		{models.ExerciseAlternative{ExerciseID: 11, Name: "Flexiones"}, map[int]string{1: "primary", 2: "secondary"}},
+		{models.ExerciseAlternative{ExerciseID: 12, Name: "Cruce de poleas", EquipmentID: &cable}, map[int]string{1: "primary"}},
+	}
+
+	params, err := parseAlternativesParams(url.Values{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ranked := rankAlternatives(source, names, candidates, params)
+	if len(ranked) != 3 || ranked[0].ExerciseID != 11 || ranked[1].ExerciseID != 10 || ranked[2].ExerciseID != 12 {
+		t.Fatalf("Unexpected ranking: %+v", ranked)
+	}
+	if len(ranked[0].SharedPrimary) != 1 || ranked[0].SharedPrimary[0] != "pectoral" || len(ranked[2].SharedSecondary) != 0 {
+		t.Errorf("Unexpected shared muscles: %+v", ranked)
+	}
+
+	params, err = parseAlternativesParams(url.Values{"exclude_equipment": {"5, 6"}, "limit": {"1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ranked = rankAlternatives(source, names, candidates, params)
+	if len(ranked) != 1 || ranked[0].ExerciseID != 11 {
+		t.Errorf("Expected only bodyweight alternative, got %+v", ranked)
+	}
+
+	broken := "out_of_order"
+	candidates[0].alternative.EquipmentStatus = &broken
+
+	params, _ = parseAlternativesParams(url.Values{})
+	ranked = rankAlternatives(source, names, candidates, params)
+	if len(ranked) != 2 || ranked[0].ExerciseID != 11 || ranked[1].ExerciseID != 12 {
+		t.Errorf("Expected broken machine to be skipped, got %+v", ranked)
+	}
+
+	params, _ = parseAlternativesParams(url.Values{"include_unavailable": {"true"}})
+	if ranked = rankAlternatives(source, names, candidates, params); len(ranked) != 3 {
+		t.Errorf("Expected broken machine with include_unavailable, got %+v", ranked)
+	}
+
+	for _, q := range []url.Values{{"exclude_equipment": {"a"}}, {"limit": {"0"}}, {"include_unavailable": {"maybe"}}} {
+		if _, err := parseAlternativesParams(q); err == nil {
+			t.Errorf("Expected error for %v", q)
+		}
+	}
+}
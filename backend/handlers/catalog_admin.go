@@ -0,0 +1,584 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/goalritmo/gym/backend/database"
+	"github.com/goalritmo/gym/backend/models"
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+)
+
+// Códigos de error de PostgreSQL usados para responder 409/400
+const (
+	pgUniqueViolation     = "23505"
+	pgForeignKeyViolation = "23503"
+)
+
+// CreateExerciseHandler crea un ejercicio del catálogo (solo administradores)
+func CreateExerciseHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req models.ExerciseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+	if err := validateExerciseRequest(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if status, err := checkEquipmentReference(req.EquipmentID); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	var id int
+	err := database.DB.QueryRow(`
+		INSERT INTO exercises (name, muscle_group, equipment_id, video_url, measurement_mode)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, req.Name, req.MuscleGroup, req.EquipmentID, req.VideoURL, req.MeasurementMode).Scan(&id)
+	if err != nil {
+		writeCatalogWriteError(w, err, "Error creando ejercicio")
+		return
+	}
+
+	exercise, err := loadExercise(id)
+	if err != nil {
+		http.Error(w, "Error consultando ejercicio creado", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(exercise)
+}
+
+// UpdateExerciseHandler actualiza un ejercicio del catálogo (solo administradores)
+func UpdateExerciseHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	var req models.ExerciseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+	if err := validateExerciseRequest(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if status, err := checkEquipmentReference(req.EquipmentID); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	result, err := database.DB.Exec(`
+		UPDATE exercises
+		SET name = $1, muscle_group = $2, equipment_id = $3, video_url = $4, measurement_mode = $5
+		WHERE id = $6
+	`, req.Name, req.MuscleGroup, req.EquipmentID, req.VideoURL, req.MeasurementMode, id)
+	if err != nil {
+		writeCatalogWriteError(w, err, "Error actualizando ejercicio")
+		return
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected == 0 {
+		http.Error(w, "Ejercicio no encontrado", http.StatusNotFound)
+		return
+	}
+
+	exercise, err := loadExercise(id)
+	if err != nil {
+		http.Error(w, "Error consultando ejercicio actualizado", http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(exercise)
+}
+
+// DeleteExerciseHandler elimina un ejercicio del catálogo (solo administradores)
+func DeleteExerciseHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	deleteExercise(w, id, "")
+}
+
+// deleteExercise elimina un ejercicio y sus vínculos musculares. Se rechaza si
+// tiene series registradas o está en rutinas, porque las claves foráneas en
+// cascada borrarían el historial. Con ownerID solo elimina ejercicios de ese
+// usuario: cualquier otro ID responde 404 antes de revisar su uso, para no revelar
+// si existe
+func deleteExercise(w http.ResponseWriter, id int, ownerID string) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		http.Error(w, "Error eliminando ejercicio", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	query := `SELECT id FROM exercises WHERE id = $1`
+	args := []interface{}{id}
+	if ownerID != "" {
+		query += ` AND owner_id = $2`
+		args = append(args, ownerID)
+	}
+
+	var lockedID int
+	err = tx.QueryRow(query+` FOR UPDATE`, args...).Scan(&lockedID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Ejercicio no encontrado", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error verificando ejercicio", http.StatusInternalServerError)
+		return
+	}
+
+	var inUse bool
+	err = tx.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM workouts WHERE exercise_id = $1)
+			OR EXISTS(SELECT 1 FROM routine_template_exercises WHERE exercise_id = $1)
+	`, id).Scan(&inUse)
+	if err != nil {
+		http.Error(w, "Error verificando uso del ejercicio", http.StatusInternalServerError)
+		return
+	}
+	if inUse {
+		http.Error(w, "El ejercicio tiene series registradas o está en rutinas", http.StatusConflict)
+		return
+	}
+
+	if _, err := tx.Exec(`DELETE FROM exercise_muscle_groups WHERE exercise_id = $1`, id); err != nil {
+		http.Error(w, "Error eliminando músculos del ejercicio", http.StatusInternalServerError)
+		return
+	}
+
+	if _, err := tx.Exec(`DELETE FROM exercises WHERE id = $1`, id); err != nil {
+		writeCatalogWriteError(w, err, "Error eliminando ejercicio")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error eliminando ejercicio", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SetExerciseMuscleGroupsHandler reemplaza los músculos primarios y secundarios de
+// un ejercicio (solo administradores)
+func SetExerciseMuscleGroupsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	var req models.SetExerciseMuscleGroupsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+	if err := validateMuscleLinks(req.Links); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var exists bool
+	if err := database.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM exercises WHERE id = $1)`, id).Scan(&exists); err != nil {
+		http.Error(w, "Error verificando ejercicio", http.StatusInternalServerError)
+		return
+	}
+	if !exists {
+		http.Error(w, "Ejercicio no encontrado", http.StatusNotFound)
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		http.Error(w, "Error actualizando músculos", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	if status, err := replaceExerciseMuscleGroups(tx, id, req.Links); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error actualizando músculos", http.StatusInternalServerError)
+		return
+	}
+
+	exercise, err := loadExercise(id)
+	if err != nil {
+		http.Error(w, "Error consultando ejercicio actualizado", http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(exercise)
+}
+
+// replaceExerciseMuscleGroups reemplaza dentro de tx los vínculos musculares de un
+// ejercicio. En caso de error devuelve también el status HTTP correspondiente
+func replaceExerciseMuscleGroups(tx *sql.Tx, exerciseID int, links []models.ExerciseMuscleGroupLink) (int, error) {
+	if _, err := tx.Exec(`DELETE FROM exercise_muscle_groups WHERE exercise_id = $1`, exerciseID); err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Error actualizando músculos")
+	}
+
+	for _, link := range links {
+		_, err := tx.Exec(`
+			INSERT INTO exercise_muscle_groups (exercise_id, muscle_group_id, role)
+			VALUES ($1, $2, $3)
+		`, exerciseID, link.MuscleGroupID, link.Role)
+		if isPgError(err, pgForeignKeyViolation) {
+			return http.StatusBadRequest, fmt.Errorf("Grupo muscular %d no encontrado", link.MuscleGroupID)
+		}
+		if err != nil {
+			return http.StatusInternalServerError, fmt.Errorf("Error actualizando músculos")
+		}
+	}
+	return http.StatusOK, nil
+}
+
+// CreateEquipmentHandler crea un equipo del catálogo (solo administradores)
+func CreateEquipmentHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req models.EquipmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+	if err := validateEquipmentRequest(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var equipment models.Equipment
+	err := scanEquipment(database.DB.QueryRow(`
+		INSERT INTO equipment (name, category, observations, image_url,
+			load_type, bar_weight, plates, stack_increment, dumbbell_step, min_weight, max_weight)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING `+equipmentColumns,
+		req.Name, req.Category, req.Observations, req.ImageURL,
+		req.LoadType, req.BarWeight, pq.Array(req.Plates), req.StackIncrement, req.DumbbellStep, req.MinWeight, req.MaxWeight,
+	), &equipment)
+	if err != nil {
+		writeCatalogWriteError(w, err, "Error creando equipo")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(equipment)
+}
+
+// UpdateEquipmentHandler actualiza un equipo del catálogo (solo administradores)
+func UpdateEquipmentHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	var req models.EquipmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+	if err := validateEquipmentRequest(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var equipment models.Equipment
+	err = scanEquipment(database.DB.QueryRow(`
+		UPDATE equipment
+		SET name = $1, category = $2, observations = $3, image_url = $4,
+			load_type = $5, bar_weight = $6, plates = $7, stack_increment = $8,
+			dumbbell_step = $9, min_weight = $10, max_weight = $11
+		WHERE id = $12
+		RETURNING `+equipmentColumns,
+		req.Name, req.Category, req.Observations, req.ImageURL,
+		req.LoadType, req.BarWeight, pq.Array(req.Plates), req.StackIncrement, req.DumbbellStep, req.MinWeight, req.MaxWeight,
+		id,
+	), &equipment)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Equipo no encontrado", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		writeCatalogWriteError(w, err, "Error actualizando equipo")
+		return
+	}
+
+	json.NewEncoder(w).Encode(equipment)
+}
+
+// DeleteEquipmentHandler elimina un equipo del catálogo (solo administradores).
+// Se rechaza si hay ejercicios que lo usan
+func DeleteEquipmentHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	var inUse bool
+	err = database.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM exercises WHERE equipment_id = $1)`, id).Scan(&inUse)
+	if err != nil {
+		http.Error(w, "Error verificando uso del equipo", http.StatusInternalServerError)
+		return
+	}
+	if inUse {
+		http.Error(w, "Hay ejercicios que usan este equipo", http.StatusConflict)
+		return
+	}
+
+	result, err := database.DB.Exec(`DELETE FROM equipment WHERE id = $1`, id)
+	if err != nil {
+		writeCatalogWriteError(w, err, "Error eliminando equipo")
+		return
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected == 0 {
+		http.Error(w, "Equipo no encontrado", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CreateMuscleGroupHandler crea un grupo muscular (solo administradores)
+func CreateMuscleGroupHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req models.MuscleGroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+	if err := validateMuscleGroupRequest(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	group := models.MuscleGroup{Name: req.Name, Category: req.Category}
+	err := database.DB.QueryRow(`
+		INSERT INTO muscle_groups (name, category) VALUES ($1, $2) RETURNING id
+	`, req.Name, req.Category).Scan(&group.ID)
+	if err != nil {
+		writeCatalogWriteError(w, err, "Error creando grupo muscular")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(group)
+}
+
+// UpdateMuscleGroupHandler actualiza un grupo muscular (solo administradores)
+func UpdateMuscleGroupHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	var req models.MuscleGroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+	if err := validateMuscleGroupRequest(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := database.DB.Exec(`
+		UPDATE muscle_groups SET name = $1, category = $2 WHERE id = $3
+	`, req.Name, req.Category, id)
+	if err != nil {
+		writeCatalogWriteError(w, err, "Error actualizando grupo muscular")
+		return
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected == 0 {
+		http.Error(w, "Grupo muscular no encontrado", http.StatusNotFound)
+		return
+	}
+
+	json.NewEncoder(w).Encode(models.MuscleGroup{ID: id, Name: req.Name, Category: req.Category})
+}
+
+// DeleteMuscleGroupHandler elimina un grupo muscular (solo administradores).
+// Se rechaza si está vinculado a ejercicios
+func DeleteMuscleGroupHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	var inUse bool
+	err = database.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM exercise_muscle_groups WHERE muscle_group_id = $1)`, id).Scan(&inUse)
+	if err != nil {
+		http.Error(w, "Error verificando uso del grupo muscular", http.StatusInternalServerError)
+		return
+	}
+	if inUse {
+		http.Error(w, "El grupo muscular está vinculado a ejercicios", http.StatusConflict)
+		return
+	}
+
+	result, err := database.DB.Exec(`DELETE FROM muscle_groups WHERE id = $1`, id)
+	if err != nil {
+		writeCatalogWriteError(w, err, "Error eliminando grupo muscular")
+		return
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected == 0 {
+		http.Error(w, "Grupo muscular no encontrado", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validateExerciseRequest normaliza y valida un ejercicio
+func validateExerciseRequest(req *models.ExerciseRequest) error {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return fmt.Errorf("El nombre es requerido")
+	}
+	if !containsString(models.MuscleGroupRoles, req.MuscleGroup) {
+		return fmt.Errorf("muscle_group inválido (%s)", strings.Join(models.MuscleGroupRoles, ", "))
+	}
+	if req.MeasurementMode == "" {
+		req.MeasurementMode = models.MeasurementWeightReps
+	}
+	if !containsString(models.MeasurementModes, req.MeasurementMode) {
+		return fmt.Errorf("measurement_mode inválido (%s)", strings.Join(models.MeasurementModes, ", "))
+	}
+	return nil
+}
+
+// validateEquipmentRequest normaliza y valida un equipo
+func validateEquipmentRequest(req *models.EquipmentRequest) error {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return fmt.Errorf("El nombre es requerido")
+	}
+	if !containsString(models.EquipmentCategories, req.Category) {
+		return fmt.Errorf("category inválida (%s)", strings.Join(models.EquipmentCategories, ", "))
+	}
+
+	loadTypes := []string{models.LoadTypePlates, models.LoadTypeStack, models.LoadTypeDumbbells, models.LoadTypeBodyweight}
+	if req.LoadType != nil && !containsString(loadTypes, *req.LoadType) {
+		return fmt.Errorf("load_type inválido (%s)", strings.Join(loadTypes, ", "))
+	}
+	for _, plate := range req.Plates {
+		if plate <= 0 {
+			return fmt.Errorf("Los discos deben pesar más de 0")
+		}
+	}
+	if req.Plates == nil {
+		req.Plates = []float64{}
+	}
+	if req.MinWeight != nil && req.MaxWeight != nil && *req.MinWeight > *req.MaxWeight {
+		return fmt.Errorf("min_weight no puede ser mayor que max_weight")
+	}
+	return nil
+}
+
+// validateMuscleGroupRequest normaliza y valida un grupo muscular
+func validateMuscleGroupRequest(req *models.MuscleGroupRequest) error {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return fmt.Errorf("El nombre es requerido")
+	}
+	if req.Category == nil || !containsString(models.MuscleGroupCategories, *req.Category) {
+		return fmt.Errorf("category es requerida (%s)", strings.Join(models.MuscleGroupCategories, ", "))
+	}
+	return nil
+}
+
+// validateMuscleLinks valida roles y duplicados de los vínculos ejercicio-músculo
+func validateMuscleLinks(links []models.ExerciseMuscleGroupLink) error {
+	seen := make(map[int]bool)
+	for _, link := range links {
+		if link.MuscleGroupID <= 0 {
+			return fmt.Errorf("muscle_group_id inválido")
+		}
+		if link.Role != models.MuscleRolePrimary && link.Role != models.MuscleRoleSecondary {
+			return fmt.Errorf("role inválido (primary o secondary)")
+		}
+		if seen[link.MuscleGroupID] {
+			return fmt.Errorf("El grupo muscular %d está repetido", link.MuscleGroupID)
+		}
+		seen[link.MuscleGroupID] = true
+	}
+	return nil
+}
+
+// checkEquipmentReference verifica que el equipo referenciado exista
+func checkEquipmentReference(equipmentID *int) (int, error) {
+	if equipmentID == nil {
+		return http.StatusOK, nil
+	}
+	var exists bool
+	err := database.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM equipment WHERE id = $1)`, *equipmentID).Scan(&exists)
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Error verificando equipo")
+	}
+	if !exists {
+		return http.StatusBadRequest, fmt.Errorf("Equipo %d no encontrado", *equipmentID)
+	}
+	return http.StatusOK, nil
+}
+
+// writeCatalogWriteError traduce errores de escritura de PostgreSQL a respuestas HTTP
+func writeCatalogWriteError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case isPgError(err, pgUniqueViolation):
+		http.Error(w, "Ya existe un registro con ese nombre", http.StatusConflict)
+	case isPgError(err, pgForeignKeyViolation):
+		http.Error(w, "El registro está referenciado por otros datos", http.StatusConflict)
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
+
+// isPgError indica si err es un error de PostgreSQL con el código dado
+func isPgError(err error, code string) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && string(pqErr.Code) == code
+}
+
+// containsString indica si value está en values
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
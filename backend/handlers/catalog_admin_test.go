@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/goalritmo/gym/backend/models"
+)
+
+func TestValidateExerciseRequest(t *testing.T) {
+	req := models.ExerciseRequest{Name: "  Press militar ", MuscleGroup: "hombros"}
+	if err := validateExerciseRequest(&req); err != nil {
+		t.Fatal(err)
+	}
+	if req.Name != "Press militar" || req.MeasurementMode != models.MeasurementWeightReps {
+		t.Errorf("Expected trimmed name and default mode, got %+v", req)
+	}
+
+	invalid := []models.ExerciseRequest{
+		{Name: "", MuscleGroup: "pecho"},
+		{Name: "Press", MuscleGroup: "cuello"},
+		{Name: "Plancha", MuscleGroup: "abdominales", MeasurementMode: "distance"},
+	}
+	for _, req := range invalid {
+		if err := validateExerciseRequest(&req); err == nil {
+			t.Errorf("Expected error for %+v", req)
+		}
+	}
+}
+
+func TestValidateEquipmentRequest(t *testing.T) {
+	req := models.EquipmentRequest{Name: "Barra olímpica", Category: "pesas_libres", LoadType: stringPtr("plates")}
+	if err := validateEquipmentRequest(&req); err != nil {
+		t.Fatal(err)
+	}
+	if req.Plates == nil {
+		t.Error("Expected empty plates slice")
+	}
+
+	invalid := []models.EquipmentRequest{
+		{Name: "Barra", Category: "gimnasio"},
+		{Name: "Barra", Category: "pesas_libres", LoadType: stringPtr("cadenas")},
+		{Name: "Barra", Category: "pesas_libres", Plates: []float64{20, 0}},
+		{Name: "Polea", Category: "cables", MinWeight: floatPtr(50), MaxWeight: floatPtr(10)},
+	}
+	for _, req := range invalid {
+		if err := validateEquipmentRequest(&req); err == nil {
+			t.Errorf("Expected error for %+v", req)
+		}
+	}
+}
+
+func TestValidateMuscleGroupRequest(t *testing.T) {
+	req := models.MuscleGroupRequest{Name: " Pectoral mayor ", Category: stringPtr("empuje")}
+	if err := validateMuscleGroupRequest(&req); err != nil {
+		t.Fatal(err)
+	}
+	if req.Name != "Pectoral mayor" {
+		t.Errorf("Expected trimmed name, got %q", req.Name)
+	}
+
+	invalid := []models.MuscleGroupRequest{
+		{Name: "", Category: stringPtr("empuje")},
+		{Name: "Pectoral mayor"},
+		{Name: "Pectoral mayor", Category: stringPtr("brazos")},
+	}
+	for _, req := range invalid {
+		if err := validateMuscleGroupRequest(&req); err == nil {
+			t.Errorf("Expected error for %+v", req)
+		}
+	}
+}
+
+func TestValidateMuscleLinks(t *testing.T) {
+	valid := []models.ExerciseMuscleGroupLink{
+		{MuscleGroupID: 1, Role: "primary"},
+		{MuscleGroupID: 2, Role: "secondary"},
+	}
+	if err := validateMuscleLinks(valid); err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := [][]models.ExerciseMuscleGroupLink{
+		{{MuscleGroupID: 0, Role: "primary"}},
+		{{MuscleGroupID: 1, Role: "main"}},
+		{{MuscleGroupID: 1, Role: "primary"}, {MuscleGroupID: 1, Role: "secondary"}},
+	}
+	for _, links := range invalid {
+		if err := validateMuscleLinks(links); err == nil {
+			t.Errorf("Expected error for %+v", links)
+		}
+	}
+}
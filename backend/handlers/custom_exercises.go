@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/goalritmo/gym/backend/database"
+	"github.com/goalritmo/gym/backend/middleware"
+	"github.com/goalritmo/gym/backend/models"
+	"github.com/gorilla/mux"
+)
+
+// GetMyExercisesHandler lista los ejercicios privados del usuario actual
+func GetMyExercisesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, ok := middleware.CurrentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	query := `SELECT ` + exerciseFullColumns + exerciseFullJoins + `
+		WHERE e.owner_id = $1` + exerciseFullGroupBy + `
+		ORDER BY e.name ASC`
+
+	rows, err := database.DB.Query(query, userID)
+	if err != nil {
+		http.Error(w, "Error consultando ejercicios", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	exercises := []models.Exercise{}
+	for rows.Next() {
+		var exercise models.Exercise
+		if err := scanExercise(rows, &exercise); err != nil {
+			http.Error(w, "Error escaneando ejercicio", http.StatusInternalServerError)
+			return
+		}
+		exercises = append(exercises, exercise)
+	}
+
+	json.NewEncoder(w).Encode(exercises)
+}
+
+// CreateCustomExerciseHandler crea un ejercicio privado del usuario actual
+func CreateCustomExerciseHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, ok := middleware.CurrentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.CustomExerciseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+	if err := validateCustomExerciseRequest(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if status, err := checkEquipmentReference(req.EquipmentID); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		http.Error(w, "Error creando ejercicio", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow(`
+		INSERT INTO exercises (name, muscle_group, equipment_id, video_url, measurement_mode, owner_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`, req.Name, req.MuscleGroup, req.EquipmentID, req.VideoURL, req.MeasurementMode, userID).Scan(&id)
+	if err != nil {
+		writeCatalogWriteError(w, err, "Error creando ejercicio")
+		return
+	}
+
+	if status, err := replaceExerciseMuscleGroups(tx, id, req.MuscleGroups); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error creando ejercicio", http.StatusInternalServerError)
+		return
+	}
+
+	exercise, err := loadExercise(id)
+	if err != nil {
+		http.Error(w, "Error consultando ejercicio creado", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(exercise)
+}
+
+// UpdateCustomExerciseHandler actualiza un ejercicio privado del usuario actual.
+// Si se envía muscle_groups, reemplaza sus músculos
+func UpdateCustomExerciseHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := middleware.CurrentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.CustomExerciseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+	if err := validateCustomExerciseRequest(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if status, err := checkEquipmentReference(req.EquipmentID); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		http.Error(w, "Error actualizando ejercicio", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE exercises
+		SET name = $1, muscle_group = $2, equipment_id = $3, video_url = $4, measurement_mode = $5
+		WHERE id = $6 AND owner_id = $7
+	`, req.Name, req.MuscleGroup, req.EquipmentID, req.VideoURL, req.MeasurementMode, id, userID)
+	if err != nil {
+		writeCatalogWriteError(w, err, "Error actualizando ejercicio")
+		return
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected == 0 {
+		http.Error(w, "Ejercicio no encontrado", http.StatusNotFound)
+		return
+	}
+
+	if req.MuscleGroups != nil {
+		if status, err := replaceExerciseMuscleGroups(tx, id, req.MuscleGroups); err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error actualizando ejercicio", http.StatusInternalServerError)
+		return
+	}
+
+	exercise, err := loadExercise(id)
+	if err != nil {
+		http.Error(w, "Error consultando ejercicio actualizado", http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(exercise)
+}
+
+// DeleteCustomExerciseHandler elimina un ejercicio privado del usuario actual
+func DeleteCustomExerciseHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := middleware.CurrentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	deleteExercise(w, id, userID)
+}
+
+// PromoteExerciseHandler pasa un ejercicio privado al catálogo compartido
+// (solo administradores). Las series ya registradas por su dueño se conservan
+func PromoteExerciseHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	var ownerID *string
+	err = database.DB.QueryRow(`SELECT owner_id FROM exercises WHERE id = $1`, id).Scan(&ownerID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Ejercicio no encontrado", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error consultando ejercicio", http.StatusInternalServerError)
+		return
+	}
+	if ownerID == nil {
+		http.Error(w, "El ejercicio ya es parte del catálogo", http.StatusConflict)
+		return
+	}
+
+	if _, err := database.DB.Exec(`UPDATE exercises SET owner_id = NULL WHERE id = $1`, id); err != nil {
+		writeCatalogWriteError(w, err, "Error promoviendo ejercicio")
+		return
+	}
+
+	exercise, err := loadExercise(id)
+	if err != nil {
+		http.Error(w, "Error consultando ejercicio promovido", http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(exercise)
+}
+
+// validateCustomExerciseRequest valida un ejercicio privado y sus músculos
+func validateCustomExerciseRequest(req *models.CustomExerciseRequest) error {
+	if err := validateExerciseRequest(&req.ExerciseRequest); err != nil {
+		return err
+	}
+	return validateMuscleLinks(req.MuscleGroups)
+}
+
+// exerciseMeasurementMode obtiene el modo de medición de un ejercicio visible para
+// el usuario. Devuelve sql.ErrNoRows si no existe o es privado de otro usuario
+func exerciseMeasurementMode(exerciseID int, userID string) (string, error) {
+	var mode string
+	err := database.DB.QueryRow(`
+		SELECT measurement_mode FROM exercises
+		WHERE id = $1 AND (owner_id IS NULL OR owner_id = $2)
+	`, exerciseID, userID).Scan(&mode)
+	return mode, err
+}
+
+// validateWorkoutMeasurement valida una serie según el modo de medición del
+// ejercicio: peso y repeticiones son obligatorios solo en los modos que los usan
+// (el peso es el lastre opcional en "reps") y los ejercicios por tiempo requieren
+// la duración en seconds. Los valores negativos se rechazan antes, en el handler
+func validateWorkoutMeasurement(mode string, req *models.CreateWorkoutRequest) error {
+	switch mode {
+	case models.MeasurementTime:
+		if req.Seconds == nil || *req.Seconds <= 0 {
+			return fmt.Errorf("La duración (seconds) debe ser mayor a 0")
+		}
+	case models.MeasurementReps:
+		if req.Reps == 0 {
+			return fmt.Errorf("Las repeticiones deben ser mayores a 0")
+		}
+	default:
+		if req.Weight == 0 {
+			return fmt.Errorf("El peso debe ser mayor a 0")
+		}
+		if req.Reps == 0 {
+			return fmt.Errorf("Las repeticiones deben ser mayores a 0")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/goalritmo/gym/backend/models"
+)
+
+func TestValidateWorkoutMeasurement(t *testing.T) {
+	seconds := 60
+	zero := 0
+
+	cases := []struct {
+		mode  string
+		req   models.CreateWorkoutRequest
+		valid bool
+	}{
+		{models.MeasurementWeightReps, models.CreateWorkoutRequest{Weight: 80, Reps: 8}, true},
+		{models.MeasurementWeightReps, models.CreateWorkoutRequest{Reps: 8}, false},
+		{models.MeasurementWeightReps, models.CreateWorkoutRequest{Weight: 80}, false},
+		{models.MeasurementReps, models.CreateWorkoutRequest{Reps: 12}, true},
+		{models.MeasurementReps, models.CreateWorkoutRequest{Weight: 10, Reps: 12}, true},
+		{models.MeasurementReps, models.CreateWorkoutRequest{}, false},
+		{models.MeasurementTime, models.CreateWorkoutRequest{Seconds: &seconds}, true},
+		{models.MeasurementTime, models.CreateWorkoutRequest{}, false},
+		{models.MeasurementTime, models.CreateWorkoutRequest{Seconds: &zero}, false},
+	}
+	for _, c := range cases {
+		err := validateWorkoutMeasurement(c.mode, &c.req)
+		if (err == nil) != c.valid {
+			t.Errorf("mode=%s req=%+v: expected valid=%v, got %v", c.mode, c.req, c.valid, err)
+		}
+	}
+}
+
+func TestValidateCustomExerciseRequest(t *testing.T) {
+	req := models.CustomExerciseRequest{
+		ExerciseRequest: models.ExerciseRequest{Name: "Dominadas lastradas", MuscleGroup: "espalda", MeasurementMode: "reps"},
+		MuscleGroups:    []models.ExerciseMuscleGroupLink{{MuscleGroupID: 3, Role: "primary"}},
+	}
+	if err := validateCustomExerciseRequest(&req); err != nil {
+		t.Fatal(err)
+	}
+
+	req.MuscleGroups = append(req.MuscleGroups, models.ExerciseMuscleGroupLink{MuscleGroupID: 3, Role: "secondary"})
+	if err := validateCustomExerciseRequest(&req); err == nil {
+		t.Error("Expected error for repeated muscle group")
+	}
+}
@@ -7,19 +7,80 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/goalritmo/gym/backend/database"
+	"github.com/goalritmo/gym/backend/middleware"
 	"github.com/goalritmo/gym/backend/models"
+	"github.com/lib/pq"
 )
 
-// GetEquipmentHandler obtiene la lista de equipos con filtros
+// equipmentColumns es la lista de columnas que lee scanEquipment, incluyendo las
+// claves de la imagen subida (ver media_migrations.sql)
+const equipmentColumns = `id, name, category, observations, image_url,
+		load_type, bar_weight, plates, stack_increment, dumbbell_step, min_weight, max_weight,
+		status, created_at,
+		(SELECT storage_key FROM media WHERE media.id = image_media_id) AS image_key,
+		(SELECT thumbnail_key FROM media WHERE media.id = image_media_id) AS image_thumbnail_key`
+
+// rowScanner es la interfaz común de *sql.Row y *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanEquipment escanea una fila con equipmentColumns. Si la imagen se subió, se
+// devuelve su URL firmada en lugar de image_url
+func scanEquipment(row rowScanner, eq *models.Equipment) error {
+	var plates pq.Float64Array
+	var imageKey, thumbnailKey *string
+	err := row.Scan(
+		&eq.ID,
+		&eq.Name,
+		&eq.Category,
+		&eq.Observations,
+		&eq.ImageURL,
+		&eq.LoadType,
+		&eq.BarWeight,
+		&plates,
+		&eq.StackIncrement,
+		&eq.DumbbellStep,
+		&eq.MinWeight,
+		&eq.MaxWeight,
+		&eq.Status,
+		&eq.CreatedAt,
+		&imageKey,
+		&thumbnailKey,
+	)
+	if err != nil {
+		return err
+	}
+	eq.Plates = []float64(plates)
+
+	if imageKey != nil {
+		if url := signedMediaURL(*imageKey); url != nil {
+			eq.ImageURL = url
+		}
+	}
+	if thumbnailKey != nil {
+		eq.ImageThumbnailURL = signedMediaURL(*thumbnailKey)
+	}
+	return nil
+}
+
+// GetEquipmentHandler obtiene la lista de equipos con filtros, en el idioma de Accept-Language
 func GetEquipmentHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
+	locale := requestLocale(w, r)
 
 	// Obtener parámetros de query
 	category := r.URL.Query().Get("category")
 	search := r.URL.Query().Get("search")
+	status := r.URL.Query().Get("status")
+
+	if status != "" && !containsString(models.EquipmentStatuses, status) {
+		http.Error(w, "status inválido (available, out_of_order o removed)", http.StatusBadRequest)
+		return
+	}
 
 	query := `
-		SELECT id, name, category, observations, image_url, created_at
+		SELECT ` + equipmentColumns + `
 		FROM equipment
 		WHERE 1=1
 	`
@@ -34,11 +95,22 @@ func GetEquipmentHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if search != "" {
-		query += ` AND name ILIKE $` + strconv.Itoa(argIndex)
+		// Se busca en el nombre original y en sus traducciones
+		query += ` AND (f_unaccent(name) ILIKE f_unaccent($` + strconv.Itoa(argIndex) + `) OR EXISTS (
+			SELECT 1 FROM catalog_translations f_tr
+			WHERE f_tr.entity_type = 'equipment' AND f_tr.entity_id = equipment.id AND f_tr.field = 'name'
+				AND f_unaccent(f_tr.value) ILIKE f_unaccent($` + strconv.Itoa(argIndex) + `)
+		))`
 		args = append(args, "%"+search+"%")
 		argIndex++
 	}
 
+	if status != "" {
+		query += ` AND status = $` + strconv.Itoa(argIndex)
+		args = append(args, status)
+		argIndex++
+	}
+
 	query += ` ORDER BY name ASC`
 
 	rows, err := database.DB.Query(query, args...)
@@ -51,14 +123,7 @@ func GetEquipmentHandler(w http.ResponseWriter, r *http.Request) {
 	var equipment []models.Equipment
 	for rows.Next() {
 		var eq models.Equipment
-		err := rows.Scan(
-			&eq.ID,
-			&eq.Name,
-			&eq.Category,
-			&eq.Observations,
-			&eq.ImageURL,
-			&eq.CreatedAt,
-		)
+		err := scanEquipment(rows, &eq)
 		if err != nil {
 			http.Error(w, "Error escaneando equipo", http.StatusInternalServerError)
 			return
@@ -66,12 +131,18 @@ func GetEquipmentHandler(w http.ResponseWriter, r *http.Request) {
 		equipment = append(equipment, eq)
 	}
 
+	if err := localizeEquipment(locale, equipment); err != nil {
+		http.Error(w, "Error traduciendo equipos", http.StatusInternalServerError)
+		return
+	}
+
 	json.NewEncoder(w).Encode(equipment)
 }
 
-// GetEquipmentByIdHandler obtiene un equipo específico por ID
+// GetEquipmentByIdHandler obtiene un equipo específico por ID, en el idioma de Accept-Language
 func GetEquipmentByIdHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
+	locale := requestLocale(w, r)
 
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
@@ -81,25 +152,74 @@ func GetEquipmentByIdHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	query := `
-		SELECT id, name, category, observations, image_url, created_at
+		SELECT ` + equipmentColumns + `
 		FROM equipment
 		WHERE id = $1
 	`
 
 	var equipment models.Equipment
-	err = database.DB.QueryRow(query, id).Scan(
-		&equipment.ID,
-		&equipment.Name,
-		&equipment.Category,
-		&equipment.Observations,
-		&equipment.ImageURL,
-		&equipment.CreatedAt,
-	)
+	err = scanEquipment(database.DB.QueryRow(query, id), &equipment)
 
 	if err != nil {
 		http.Error(w, "Equipo no encontrado", http.StatusNotFound)
 		return
 	}
 
-	json.NewEncoder(w).Encode(equipment)
+	// Con include=exercises se agregan los ejercicios que usan el equipo
+	if r.URL.Query().Get("include") == "exercises" {
+		userID, ok := middleware.CurrentUserID(w, r)
+		if !ok {
+			return
+		}
+
+		equipment.Exercises, err = queryExercises(userID, `e.equipment_id = $2`, id)
+		if err != nil {
+			http.Error(w, "Error consultando ejercicios del equipo", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	localized := []models.Equipment{equipment}
+	if err := localizeEquipment(locale, localized); err != nil {
+		http.Error(w, "Error traduciendo equipo", http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(localized[0])
+}
+
+// GetEquipmentExercisesHandler lista los ejercicios que se pueden hacer con un equipo
+func GetEquipmentExercisesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := middleware.CurrentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var exists bool
+	err = database.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM equipment WHERE id = $1)`, id).Scan(&exists)
+	if err != nil {
+		http.Error(w, "Error consultando equipo", http.StatusInternalServerError)
+		return
+	}
+	if !exists {
+		http.Error(w, "Equipo no encontrado", http.StatusNotFound)
+		return
+	}
+
+	exercises, err := queryExercises(userID, `e.equipment_id = $2`, id)
+	if err != nil {
+		http.Error(w, "Error consultando ejercicios del equipo", http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(exercises)
 }
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/goalritmo/gym/backend/database"
+	"github.com/goalritmo/gym/backend/middleware"
+	"github.com/goalritmo/gym/backend/models"
+	"github.com/gorilla/mux"
+)
+
+// UpdateEquipmentStatusHandler cambia el estado de un equipo y lo registra en el
+// historial (solo administradores). Al volver a available se resuelven los
+// reportes abiertos del equipo
+func UpdateEquipmentStatusHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := middleware.CurrentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateEquipmentStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+	if !containsString(models.EquipmentStatuses, req.Status) {
+		http.Error(w, "status inválido (available, out_of_order o removed)", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		http.Error(w, "Error actualizando estado del equipo", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`UPDATE equipment SET status = $1 WHERE id = $2`, req.Status, id)
+	if err != nil {
+		http.Error(w, "Error actualizando estado del equipo", http.StatusInternalServerError)
+		return
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected == 0 {
+		http.Error(w, "Equipo no encontrado", http.StatusNotFound)
+		return
+	}
+
+	change := models.EquipmentStatusChange{EquipmentID: id, Status: req.Status, Note: req.Note, ChangedBy: &userID}
+	err = tx.QueryRow(`
+		INSERT INTO equipment_status_history (equipment_id, status, note, changed_by)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`, id, req.Status, req.Note, userID).Scan(&change.ID, &change.CreatedAt)
+	if err != nil {
+		http.Error(w, "Error registrando historial del equipo", http.StatusInternalServerError)
+		return
+	}
+
+	if req.Status == models.EquipmentAvailable {
+		_, err := tx.Exec(`
+			UPDATE equipment_reports SET resolved = TRUE, resolved_at = NOW()
+			WHERE equipment_id = $1 AND NOT resolved
+		`, id)
+		if err != nil {
+			http.Error(w, "Error resolviendo reportes del equipo", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error actualizando estado del equipo", http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(change)
+}
+
+// GetEquipmentStatusHistoryHandler obtiene el historial de estados de un equipo
+func GetEquipmentStatusHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT id, equipment_id, status, note, changed_by, created_at
+		FROM equipment_status_history
+		WHERE equipment_id = $1
+		ORDER BY created_at DESC
+	`, id)
+	if err != nil {
+		http.Error(w, "Error consultando historial del equipo", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	history := []models.EquipmentStatusChange{}
+	for rows.Next() {
+		var change models.EquipmentStatusChange
+		err := rows.Scan(&change.ID, &change.EquipmentID, &change.Status, &change.Note, &change.ChangedBy, &change.CreatedAt)
+		if err != nil {
+			http.Error(w, "Error escaneando historial del equipo", http.StatusInternalServerError)
+			return
+		}
+		history = append(history, change)
+	}
+
+	json.NewEncoder(w).Encode(history)
+}
+
+// CreateEquipmentReportHandler registra el reporte de un usuario sobre un equipo roto
+func CreateEquipmentReportHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := middleware.CurrentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.CreateEquipmentReportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+	req.Description = strings.TrimSpace(req.Description)
+	if req.Description == "" || len(req.Description) > 1000 {
+		http.Error(w, "La descripción es requerida (máximo 1000 caracteres)", http.StatusBadRequest)
+		return
+	}
+
+	var status string
+	if err := database.DB.QueryRow(`SELECT status FROM equipment WHERE id = $1`, id).Scan(&status); err != nil {
+		http.Error(w, "Equipo no encontrado", http.StatusNotFound)
+		return
+	}
+	if status == models.EquipmentRemoved {
+		http.Error(w, "El equipo fue retirado del gimnasio", http.StatusConflict)
+		return
+	}
+
+	report := models.EquipmentReport{EquipmentID: id, UserID: userID, Description: req.Description}
+	err = database.DB.QueryRow(`
+		INSERT INTO equipment_reports (equipment_id, user_id, description)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`, id, userID, req.Description).Scan(&report.ID, &report.CreatedAt)
+	if err != nil {
+		http.Error(w, "Error registrando reporte", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetEquipmentReportsHandler lista los reportes de equipos, por defecto solo los
+// abiertos (?resolved=true para los resueltos). Solo administradores
+func GetEquipmentReportsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	resolved := false
+	if value := r.URL.Query().Get("resolved"); value != "" {
+		var err error
+		resolved, err = strconv.ParseBool(value)
+		if err != nil {
+			http.Error(w, "resolved inválido", http.StatusBadRequest)
+			return
+		}
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT id, equipment_id, user_id, description, resolved, created_at, resolved_at
+		FROM equipment_reports
+		WHERE resolved = $1
+		ORDER BY created_at DESC
+	`, resolved)
+	if err != nil {
+		http.Error(w, "Error consultando reportes", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	reports := []models.EquipmentReport{}
+	for rows.Next() {
+		var report models.EquipmentReport
+		err := rows.Scan(
+			&report.ID, &report.EquipmentID, &report.UserID, &report.Description,
+			&report.Resolved, &report.CreatedAt, &report.ResolvedAt,
+		)
+		if err != nil {
+			http.Error(w, "Error escaneando reporte", http.StatusInternalServerError)
+			return
+		}
+		reports = append(reports, report)
+	}
+
+	json.NewEncoder(w).Encode(reports)
+}
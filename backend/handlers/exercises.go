@@ -2,29 +2,113 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/goalritmo/gym/backend/database"
+	"github.com/goalritmo/gym/backend/middleware"
 	"github.com/goalritmo/gym/backend/models"
 	"github.com/lib/pq"
 )
 
-// GetExercisesHandler obtiene la lista de ejercicios con filtros
+// maxExercisesLimit es el tamaño máximo de página del listado de ejercicios
+const maxExercisesLimit = 200
+
+// exerciseSortColumns son las columnas por las que se puede ordenar el listado
+var exerciseSortColumns = map[string]string{
+	"id":           "e.id",
+	"name":         "e.name",
+	"muscle_group": "e.muscle_group",
+	"created_at":   "e.created_at",
+}
+
+// exerciseFullColumns son las columnas del formato completo de ejercicio (ver scanExercise)
+const exerciseFullColumns = `e.id, e.name, e.muscle_group,
+		   COALESCE(array_agg(DISTINCT mp.name) FILTER (WHERE mp.name IS NOT NULL AND emg_p.role = 'primary'), '{}') as primary_muscles,
+		   COALESCE(array_agg(DISTINCT ms.name) FILTER (WHERE ms.name IS NOT NULL AND emg_s.role = 'secondary'), '{}') as secondary_muscles,
+		   eq.name as equipment, e.video_url, e.created_at, e.measurement_mode, e.owner_id, vm.storage_key as video_key`
+
+// exerciseFullJoins son los joins necesarios para exerciseFullColumns
+const exerciseFullJoins = `
+		FROM exercises e
+		LEFT JOIN equipment eq ON e.equipment_id = eq.id
+		LEFT JOIN exercise_muscle_groups emg_p ON e.id = emg_p.exercise_id AND emg_p.role = 'primary'
+		LEFT JOIN muscle_groups mp ON emg_p.muscle_group_id = mp.id
+		LEFT JOIN exercise_muscle_groups emg_s ON e.id = emg_s.exercise_id AND emg_s.role = 'secondary'
+		LEFT JOIN muscle_groups ms ON emg_s.muscle_group_id = ms.id
+		LEFT JOIN media vm ON e.video_media_id = vm.id`
+
+// exerciseFullGroupBy agrupa las filas de exerciseFullJoins por ejercicio
+const exerciseFullGroupBy = ` GROUP BY e.id, e.name, e.muscle_group, eq.name, e.video_url, e.created_at, e.measurement_mode, e.owner_id, vm.storage_key`
+
+// exerciseVisibleCondition limita a ejercicios del catálogo compartido o propios del usuario
+const exerciseVisibleCondition = ` AND (e.owner_id IS NULL OR e.owner_id = $%d)`
+
+// GetExercisesHandler obtiene la lista de ejercicios con filtros, en el idioma de Accept-Language
 func GetExercisesHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
+	locale := requestLocale(w, r)
 
-	// Query simple para obtener solo id y name para el select del frontend
-	query := `SELECT id, name FROM exercises ORDER BY name ASC`
+	userID, ok := middleware.CurrentUserID(w, r)
+	if !ok {
+		return
+	}
 
-	rows, err := database.DB.Query(query)
+	filter, err := parseExerciseFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter.UserID = userID
+
+	where, args := buildExerciseWhere(filter)
+
+	// Total sin paginar, para que el cliente pueda paginar
+	var total int
+	countQuery := `SELECT COUNT(*) FROM exercises e LEFT JOIN equipment eq ON e.equipment_id = eq.id` + where
+	if err := database.DB.QueryRow(countQuery, args...).Scan(&total); err != nil {
+		http.Error(w, "Error consultando ejercicios", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+
+	query := buildExerciseListQuery(filter, where, len(args))
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+	}
+	if filter.Offset > 0 {
+		args = append(args, filter.Offset)
+	}
+
+	rows, err := database.DB.Query(query, args...)
 	if err != nil {
 		http.Error(w, "Error consultando ejercicios", http.StatusInternalServerError)
 		return
 	}
 	defer rows.Close()
 
+	if filter.Fields == "full" {
+		exercises := []models.Exercise{}
+		for rows.Next() {
+			var exercise models.Exercise
+			if err := scanExercise(rows, &exercise); err != nil {
+				http.Error(w, "Error escaneando ejercicio", http.StatusInternalServerError)
+				return
+			}
+			exercises = append(exercises, exercise)
+		}
+		if err := localizeExercises(locale, exercises); err != nil {
+			http.Error(w, "Error traduciendo ejercicios", http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(exercises)
+		return
+	}
+
 	// Estructura simple para el select
 	type SimpleExercise struct {
 		ID   int    `json:"id"`
@@ -44,12 +128,29 @@ func GetExercisesHandler(w http.ResponseWriter, r *http.Request) {
 		exercises = append(exercises, exercise)
 	}
 
+	ids := make([]int, len(exercises))
+	for i, exercise := range exercises {
+		ids[i] = exercise.ID
+	}
+	names, err := translatedNames(locale, models.TranslationExercise, ids)
+	if err != nil {
+		http.Error(w, "Error traduciendo ejercicios", http.StatusInternalServerError)
+		return
+	}
+	for i := range exercises {
+		if name, ok := names[exercises[i].ID]; ok {
+			exercises[i].Name = name
+		}
+	}
+
 	json.NewEncoder(w).Encode(exercises)
 }
 
-// GetExerciseHandler obtiene un ejercicio específico por ID
+// GetExerciseHandler obtiene un ejercicio específico por ID con sus instrucciones
+// de técnica, en el idioma de Accept-Language
 func GetExerciseHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
+	locale := requestLocale(w, r)
 
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
@@ -58,26 +159,81 @@ func GetExerciseHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	query := `
-		SELECT e.id, e.name, e.muscle_group,
-			   COALESCE(array_agg(DISTINCT mp.name) FILTER (WHERE mp.name IS NOT NULL AND emg_p.role = 'primary'), '{}') as primary_muscles,
-			   COALESCE(array_agg(DISTINCT ms.name) FILTER (WHERE ms.name IS NOT NULL AND emg_s.role = 'secondary'), '{}') as secondary_muscles,
-			   eq.name as equipment, e.video_url, e.created_at
-		FROM exercises e
-		LEFT JOIN equipment eq ON e.equipment_id = eq.id
-		LEFT JOIN exercise_muscle_groups emg_p ON e.id = emg_p.exercise_id AND emg_p.role = 'primary'
-		LEFT JOIN muscle_groups mp ON emg_p.muscle_group_id = mp.id
-		LEFT JOIN exercise_muscle_groups emg_s ON e.id = emg_s.exercise_id AND emg_s.role = 'secondary'
-		LEFT JOIN muscle_groups ms ON emg_s.muscle_group_id = ms.id
-		WHERE e.id = $1
-		GROUP BY e.id, e.name, e.muscle_group, eq.name, e.video_url, e.created_at
-	`
+	userID, ok := middleware.CurrentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	exercise, err := loadExercise(id)
+	if err != nil || !exerciseVisibleTo(exercise, userID) {
+		http.Error(w, "Ejercicio no encontrado", http.StatusNotFound)
+		return
+	}
+
+	localized := []models.Exercise{*exercise}
+	if err := localizeExercises(locale, localized); err != nil {
+		http.Error(w, "Error traduciendo ejercicio", http.StatusInternalServerError)
+		return
+	}
+
+	localized[0].Instructions, err = loadInstructions(id, locale)
+	if err != nil {
+		http.Error(w, "Error consultando instrucciones del ejercicio", http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(localized[0])
+}
+
+// queryExercises lista ejercicios en formato completo visibles para el usuario,
+// con una condición adicional sobre exercises e. El usuario se pasa como $1 y los
+// argumentos de la condición empiezan en $2
+func queryExercises(userID string, condition string, args ...interface{}) ([]models.Exercise, error) {
+	query := `SELECT ` + exerciseFullColumns + exerciseFullJoins + `
+		WHERE (e.owner_id IS NULL OR e.owner_id = $1) AND ` + condition + exerciseFullGroupBy + `
+		ORDER BY e.name ASC, e.id ASC`
+
+	rows, err := database.DB.Query(query, append([]interface{}{userID}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	exercises := []models.Exercise{}
+	for rows.Next() {
+		var exercise models.Exercise
+		if err := scanExercise(rows, &exercise); err != nil {
+			return nil, err
+		}
+		exercises = append(exercises, exercise)
+	}
+	return exercises, rows.Err()
+}
+
+// exerciseVisibleTo indica si el ejercicio es del catálogo o pertenece al usuario
+func exerciseVisibleTo(exercise *models.Exercise, userID string) bool {
+	return exercise.OwnerID == nil || *exercise.OwnerID == userID
+}
+
+// loadExercise obtiene un ejercicio en formato completo por ID
+func loadExercise(id int) (*models.Exercise, error) {
+	query := `SELECT ` + exerciseFullColumns + exerciseFullJoins + `
+		WHERE e.id = $1` + exerciseFullGroupBy
 
 	var exercise models.Exercise
+	if err := scanExercise(database.DB.QueryRow(query, id), &exercise); err != nil {
+		return nil, err
+	}
+	return &exercise, nil
+}
+
+// scanExercise escanea una fila con exerciseFullColumns. Si el video se subió, se
+// devuelve su URL firmada en lugar de video_url
+func scanExercise(row rowScanner, exercise *models.Exercise) error {
 	var primaryMuscles, secondaryMuscles pq.StringArray
-	var equipmentName *string
+	var equipmentName, videoKey *string
 
-	err = database.DB.QueryRow(query, id).Scan(
+	err := row.Scan(
 		&exercise.ID,
 		&exercise.Name,
 		&exercise.MuscleGroup,
@@ -86,19 +242,154 @@ func GetExerciseHandler(w http.ResponseWriter, r *http.Request) {
 		&equipmentName,
 		&exercise.VideoURL,
 		&exercise.CreatedAt,
+		&exercise.MeasurementMode,
+		&exercise.OwnerID,
+		&videoKey,
 	)
-
 	if err != nil {
-		http.Error(w, "Ejercicio no encontrado", http.StatusNotFound)
-		return
+		return err
+	}
+
+	if videoKey != nil {
+		if url := signedMediaURL(*videoKey); url != nil {
+			exercise.VideoURL = url
+		}
 	}
 
 	exercise.PrimaryMuscles = []string(primaryMuscles)
 	exercise.SecondaryMuscles = []string(secondaryMuscles)
-	
+
 	if equipmentName != nil {
 		exercise.Equipment = *equipmentName
 	}
+	return nil
+}
+
+// parseExerciseFilter lee y valida los filtros del listado de ejercicios
+func parseExerciseFilter(q url.Values) (models.ExerciseFilter, error) {
+	filter := models.ExerciseFilter{
+		MuscleGroup:     q.Get("muscle_group"),
+		Equipment:       q.Get("equipment"),
+		Search:          q.Get("search"),
+		PrimaryMuscle:   q.Get("primary_muscle"),
+		SecondaryMuscle: q.Get("secondary_muscle"),
+		Fields:          q.Get("fields"),
+		Sort:            q.Get("sort"),
+		Order:           strings.ToLower(q.Get("order")),
+	}
+
+	if filter.Fields != "" && filter.Fields != "full" {
+		return filter, fmt.Errorf("fields inválido (solo se admite 'full')")
+	}
+	if filter.Sort == "" {
+		filter.Sort = "name"
+	}
+	if _, ok := exerciseSortColumns[filter.Sort]; !ok {
+		return filter, fmt.Errorf("sort inválido (id, name, muscle_group o created_at)")
+	}
+	if filter.Order == "" {
+		filter.Order = "asc"
+	}
+	if filter.Order != "asc" && filter.Order != "desc" {
+		return filter, fmt.Errorf("order inválido (asc o desc)")
+	}
+
+	if err := parseIntParam(q.Get("limit"), &filter.Limit); err != nil || filter.Limit < 0 {
+		return filter, fmt.Errorf("limit inválido")
+	}
+	if filter.Limit > maxExercisesLimit {
+		filter.Limit = maxExercisesLimit
+	}
+	if err := parseIntParam(q.Get("offset"), &filter.Offset); err != nil || filter.Offset < 0 {
+		return filter, fmt.Errorf("offset inválido")
+	}
+
+	return filter, nil
+}
+
+// buildExerciseWhere arma la cláusula WHERE (sobre exercises e y equipment eq) y sus argumentos
+func buildExerciseWhere(filter models.ExerciseFilter) (string, []interface{}) {
+	where := ` WHERE 1=1`
+	args := []interface{}{}
+	argIndex := 1
+
+	if filter.MuscleGroup != "" {
+		where += fmt.Sprintf(" AND e.muscle_group::text = $%d", argIndex)
+		args = append(args, filter.MuscleGroup)
+		argIndex++
+	}
+
+	if filter.Equipment != "" {
+		// Se acepta el nombre del equipo o su categoría
+		where += fmt.Sprintf(" AND (eq.name ILIKE $%d OR eq.category::text = $%d)", argIndex, argIndex)
+		args = append(args, filter.Equipment)
+		argIndex++
+	}
+
+	if filter.Search != "" {
+		// Se busca en el nombre original y en sus traducciones
+		where += fmt.Sprintf(` AND (f_unaccent(e.name) ILIKE f_unaccent($%d) OR EXISTS (
+			SELECT 1 FROM catalog_translations f_tr
+			WHERE f_tr.entity_type = 'exercise' AND f_tr.entity_id = e.id AND f_tr.field = 'name'
+				AND f_unaccent(f_tr.value) ILIKE f_unaccent($%d)
+		))`, argIndex, argIndex)
+		args = append(args, "%"+filter.Search+"%")
+		argIndex++
+	}
+
+	if filter.PrimaryMuscle != "" {
+		where += fmt.Sprintf(muscleRoleCondition, "primary", argIndex)
+		args = append(args, filter.PrimaryMuscle)
+		argIndex++
+	}
+
+	if filter.SecondaryMuscle != "" {
+		where += fmt.Sprintf(muscleRoleCondition, "secondary", argIndex)
+		args = append(args, filter.SecondaryMuscle)
+		argIndex++
+	}
+
+	if filter.UserID != "" {
+		where += fmt.Sprintf(exerciseVisibleCondition, argIndex)
+		args = append(args, filter.UserID)
+		argIndex++
+	} else {
+		where += " AND e.owner_id IS NULL"
+	}
+
+	return where, args
+}
+
+// muscleRoleCondition filtra ejercicios que trabajan un músculo con un rol dado
+const muscleRoleCondition = ` AND EXISTS (
+		SELECT 1 FROM exercise_muscle_groups f_emg
+		JOIN muscle_groups f_mg ON f_emg.muscle_group_id = f_mg.id
+		WHERE f_emg.exercise_id = e.id AND f_emg.role = '%s' AND f_mg.name ILIKE $%d
+	)`
+
+// buildExerciseListQuery arma la consulta del listado según el formato, el orden
+// y la paginación. argCount es la cantidad de argumentos ya usados por where
+func buildExerciseListQuery(filter models.ExerciseFilter, where string, argCount int) string {
+	var query string
+	if filter.Fields == "full" {
+		query = `SELECT ` + exerciseFullColumns + exerciseFullJoins + where + exerciseFullGroupBy
+	} else {
+		query = `SELECT e.id, e.name FROM exercises e LEFT JOIN equipment eq ON e.equipment_id = eq.id` + where
+	}
+
+	query += fmt.Sprintf(" ORDER BY %s %s", exerciseSortColumns[filter.Sort], strings.ToUpper(filter.Order))
+	if filter.Sort != "id" {
+		query += ", e.id ASC"
+	}
+
+	argIndex := argCount + 1
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT $%d", argIndex)
+		argIndex++
+	}
+	if filter.Offset > 0 {
+		query += fmt.Sprintf(" OFFSET $%d", argIndex)
+	}
 
-	json.NewEncoder(w).Encode(exercise)
+	return query
 }
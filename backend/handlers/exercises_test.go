@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/goalritmo/gym/backend/models"
+)
+
+func TestParseExerciseFilter(t *testing.T) {
+	filter, err := parseExerciseFilter(url.Values{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filter.Sort != "name" || filter.Order != "asc" || filter.Limit != 0 {
+		t.Errorf("Unexpected defaults: %+v", filter)
+	}
+
+	filter, err = parseExerciseFilter(url.Values{"limit": {"1000"}, "order": {"DESC"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filter.Limit != maxExercisesLimit || filter.Order != "desc" {
+		t.Errorf("Expected capped limit and desc order, got %+v", filter)
+	}
+
+	invalid := []url.Values{
+		{"fields": {"partial"}},
+		{"sort": {"name; DROP TABLE exercises"}},
+		{"order": {"sideways"}},
+		{"limit": {"-1"}},
+		{"offset": {"abc"}},
+	}
+	for _, q := range invalid {
+		if _, err := parseExerciseFilter(q); err == nil {
+			t.Errorf("Expected error for %v", q)
+		}
+	}
+}
+
+func TestBuildExerciseListQuery(t *testing.T) {
+	filter, _ := parseExerciseFilter(url.Values{
+		"muscle_group":   {"pecho"},
+		"primary_muscle": {"pectoral"},
+		"search":         {"press"},
+		"fields":         {"full"},
+		"sort":           {"created_at"},
+		"order":          {"desc"},
+		"limit":          {"20"},
+		"offset":         {"40"},
+	})
+
+	filter.UserID = "user-1"
+
+	where, args := buildExerciseWhere(filter)
+	if len(args) != 4 {
+		t.Fatalf("Expected 4 args, got %v", args)
+	}
+	if args[1] != "%press%" {
+		t.Errorf("Expected search pattern, got %v", args[1])
+	}
+
+	query := buildExerciseListQuery(filter, where, len(args))
+	for _, expected := range []string{"array_agg", "f_emg.role = 'primary'", "f_tr.entity_type = 'exercise'", "e.owner_id = $4", "ORDER BY e.created_at DESC", "LIMIT $5", "OFFSET $6"} {
+		if !strings.Contains(query, expected) {
+			t.Errorf("Expected query to contain %q:\n%s", expected, query)
+		}
+	}
+}
+
+func TestBuildExerciseWhereWithoutUser(t *testing.T) {
+	where, args := buildExerciseWhere(models.ExerciseFilter{})
+	if len(args) != 0 || !strings.Contains(where, "e.owner_id IS NULL") {
+		t.Errorf("Expected shared catalog only, got %q %v", where, args)
+	}
+}
+
+func TestExerciseVisibleTo(t *testing.T) {
+	owner := "user-1"
+	if !exerciseVisibleTo(&models.Exercise{}, "user-2") {
+		t.Error("Catalog exercises should be visible to everyone")
+	}
+	if !exerciseVisibleTo(&models.Exercise{OwnerID: &owner}, "user-1") {
+		t.Error("Private exercises should be visible to their owner")
+	}
+	if exerciseVisibleTo(&models.Exercise{OwnerID: &owner}, "user-2") {
+		t.Error("Private exercises should be hidden from other users")
+	}
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/goalritmo/gym/backend/database"
+	"github.com/goalritmo/gym/backend/models"
+	"github.com/gorilla/mux"
+)
+
+// Límites de las instrucciones de un ejercicio
+const (
+	maxInstructionItems  = 20
+	maxInstructionLength = 500
+)
+
+// instructionSectionFields son los nombres JSON de cada sección, para los errores
+var instructionSectionFields = map[string]string{
+	models.InstructionSetup:   "setup",
+	models.InstructionStep:    "steps",
+	models.InstructionMistake: "common_mistakes",
+	models.InstructionCue:     "cues",
+}
+
+// SetExerciseInstructionsHandler reemplaza las instrucciones de un ejercicio en
+// un idioma (solo administradores). Enviar todas las listas vacías las elimina
+func SetExerciseInstructionsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+	locale := vars["locale"]
+	if !containsString(models.Locales, locale) {
+		http.Error(w, fmt.Sprintf("Idioma no soportado (%s)", strings.Join(models.Locales, ", ")), http.StatusBadRequest)
+		return
+	}
+
+	var req models.ExerciseInstructions
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+	req.Locale = locale
+	if err := validateInstructions(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var exists bool
+	if err := database.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM exercises WHERE id = $1)`, id).Scan(&exists); err != nil {
+		http.Error(w, "Error verificando ejercicio", http.StatusInternalServerError)
+		return
+	}
+	if !exists {
+		http.Error(w, "Ejercicio no encontrado", http.StatusNotFound)
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		http.Error(w, "Error guardando instrucciones", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM exercise_instructions WHERE exercise_id = $1 AND locale = $2`, id, locale); err != nil {
+		http.Error(w, "Error guardando instrucciones", http.StatusInternalServerError)
+		return
+	}
+	for section, items := range req.Sections() {
+		for i, text := range *items {
+			_, err := tx.Exec(`
+				INSERT INTO exercise_instructions (exercise_id, locale, section, position, text)
+				VALUES ($1, $2, $3, $4, $5)
+			`, id, locale, section, i+1, text)
+			if err != nil {
+				http.Error(w, "Error guardando instrucciones", http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error guardando instrucciones", http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(req)
+}
+
+// validateInstructions recorta los textos y valida la cantidad y el largo de los
+// ítems de cada sección. Las listas ausentes quedan vacías
+func validateInstructions(instructions *models.ExerciseInstructions) error {
+	for section, items := range instructions.Sections() {
+		field := instructionSectionFields[section]
+		if len(*items) > maxInstructionItems {
+			return fmt.Errorf("%s admite hasta %d ítems", field, maxInstructionItems)
+		}
+
+		cleaned := []string{}
+		for _, text := range *items {
+			text = strings.TrimSpace(text)
+			if text == "" {
+				return fmt.Errorf("%s no puede tener ítems vacíos", field)
+			}
+			if len([]rune(text)) > maxInstructionLength {
+				return fmt.Errorf("Cada ítem de %s admite hasta %d caracteres", field, maxInstructionLength)
+			}
+			cleaned = append(cleaned, text)
+		}
+		*items = cleaned
+	}
+	return nil
+}
+
+// loadInstructions obtiene las instrucciones de un ejercicio en el idioma pedido o,
+// si no están cargadas en ese idioma, en el idioma por defecto. Devuelve nil si
+// el ejercicio no tiene instrucciones
+func loadInstructions(exerciseID int, locale string) (*models.ExerciseInstructions, error) {
+	rows, err := database.DB.Query(`
+		SELECT locale, section, text
+		FROM exercise_instructions
+		WHERE exercise_id = $1 AND locale IN ($2, $3)
+		ORDER BY locale = $2 DESC, section, position
+	`, exerciseID, locale, models.DefaultLocale)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var instructions *models.ExerciseInstructions
+	for rows.Next() {
+		var rowLocale, section, text string
+		if err := rows.Scan(&rowLocale, &section, &text); err != nil {
+			return nil, err
+		}
+		// Las filas del idioma pedido vienen primero: se usa un solo idioma
+		if instructions == nil {
+			instructions = &models.ExerciseInstructions{
+				Locale: rowLocale, Setup: []string{}, Steps: []string{}, CommonMistakes: []string{}, Cues: []string{},
+			}
+		}
+		if rowLocale != instructions.Locale {
+			break
+		}
+		if items, ok := instructions.Sections()[section]; ok {
+			*items = append(*items, text)
+		}
+	}
+	return instructions, rows.Err()
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/goalritmo/gym/backend/models"
+)
+
+func TestValidateInstructions(t *testing.T) {
+	instructions := models.ExerciseInstructions{
+		Setup: []string{"  Acostarse en el banco con los ojos bajo la barra "},
+		Steps: []string{"Bajar la barra al pecho", "Empujar hasta extender los brazos"},
+	}
+	if err := validateInstructions(&instructions); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if instructions.Setup[0] != "Acostarse en el banco con los ojos bajo la barra" {
+		t.Errorf("Expected trimmed text, got %q", instructions.Setup[0])
+	}
+	if !reflect.DeepEqual(instructions.Cues, []string{}) || !reflect.DeepEqual(instructions.CommonMistakes, []string{}) {
+		t.Errorf("Expected missing sections as empty lists, got %+v", instructions)
+	}
+
+	tooMany := make([]string, maxInstructionItems+1)
+	for i := range tooMany {
+		tooMany[i] = "Paso"
+	}
+
+	invalid := []struct {
+		name         string
+		instructions models.ExerciseInstructions
+		errMsg       string
+	}{
+		{"Ítem vacío", models.ExerciseInstructions{Cues: []string{"Pecho arriba", "  "}}, "cues"},
+		{"Demasiados ítems", models.ExerciseInstructions{Steps: tooMany}, "steps"},
+		{"Texto largo", models.ExerciseInstructions{CommonMistakes: []string{strings.Repeat("a", maxInstructionLength+1)}}, "common_mistakes"},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateInstructions(&tt.instructions)
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("Expected error about %s, got %v", tt.errMsg, err)
+			}
+		})
+	}
+}
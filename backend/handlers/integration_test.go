@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"bytes"
-	"context"
 	"database/sql"
 	"encoding/json"
 	"net/http"
@@ -10,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/goalritmo/gym/backend/database"
+	"github.com/goalritmo/gym/backend/middleware"
 	"github.com/goalritmo/gym/backend/models"
 )
 
@@ -74,7 +74,7 @@ func TestEndToEndWorkoutFlow(t *testing.T) {
 		req.Header.Set("Content-Type", "application/json")
 		
 		// Agregar contexto de usuario
-		ctx := context.WithValue(req.Context(), "user_id", "test_user_integration")
+		ctx := middleware.WithPrincipal(req.Context(), &middleware.Principal{UserID: "test_user_integration", Role: middleware.RoleUser})
 		req = req.WithContext(ctx)
 
 		rr := httptest.NewRecorder()
@@ -88,7 +88,7 @@ func TestEndToEndWorkoutFlow(t *testing.T) {
 
 	t.Run("Get workouts", func(t *testing.T) {
 		req, _ := http.NewRequest("GET", "/api/workouts", nil)
-		ctx := context.WithValue(req.Context(), "user_id", "test_user_integration")
+		ctx := middleware.WithPrincipal(req.Context(), &middleware.Principal{UserID: "test_user_integration", Role: middleware.RoleUser})
 		req = req.WithContext(ctx)
 
 		rr := httptest.NewRecorder()
@@ -114,7 +114,7 @@ func BenchmarkCreateWorkout(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		req, _ := http.NewRequest("POST", "/api/workouts", bytes.NewBuffer(body))
 		req.Header.Set("Content-Type", "application/json")
-		ctx := context.WithValue(req.Context(), "user_id", "bench_user")
+		ctx := middleware.WithPrincipal(req.Context(), &middleware.Principal{UserID: "bench_user", Role: middleware.RoleUser})
 		req = req.WithContext(ctx)
 
 		rr := httptest.NewRecorder()
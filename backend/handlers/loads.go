@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/goalritmo/gym/backend/database"
+	"github.com/goalritmo/gym/backend/models"
+	"github.com/gorilla/mux"
+)
+
+// Valores por defecto cuando el equipo no describe sus cargas
+const (
+	defaultBarWeight      = 20.0
+	defaultStackIncrement = 5.0
+	defaultDumbbellStep   = 2.0
+)
+
+// GetEquipmentLoadHandler calcula la carga alcanzable en un equipo para un peso objetivo
+// y, si es de discos, el desglose por lado
+func GetEquipmentLoadHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	target, err := strconv.ParseFloat(r.URL.Query().Get("target"), 64)
+	if err != nil || target <= 0 {
+		http.Error(w, "El parámetro target debe ser un peso mayor a 0", http.StatusBadRequest)
+		return
+	}
+
+	var equipment models.Equipment
+	query := `SELECT ` + equipmentColumns + ` FROM equipment WHERE id = $1`
+	if err := scanEquipment(database.DB.QueryRow(query, id), &equipment); err != nil {
+		http.Error(w, "Equipo no encontrado", http.StatusNotFound)
+		return
+	}
+
+	json.NewEncoder(w).Encode(computeLoad(equipment, target))
+}
+
+// exerciseEquipment obtiene el equipo asociado a un ejercicio visible para el
+// usuario. Devuelve sql.ErrNoRows si el ejercicio no existe y nil si no tiene equipo
+func exerciseEquipment(exerciseID int, userID string) (*models.Equipment, error) {
+	var equipmentID *int
+	err := database.DB.QueryRow(`
+		SELECT equipment_id FROM exercises
+		WHERE id = $1 AND (owner_id IS NULL OR owner_id = $2)
+	`, exerciseID, userID).Scan(&equipmentID)
+	if err != nil {
+		return nil, err
+	}
+	if equipmentID == nil {
+		return nil, nil
+	}
+
+	var equipment models.Equipment
+	query := `SELECT ` + equipmentColumns + ` FROM equipment WHERE id = $1`
+	err = scanEquipment(database.DB.QueryRow(query, *equipmentID), &equipment)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &equipment, nil
+}
+
+// equipmentIncrement devuelve el salto mínimo de carga de un equipo
+func equipmentIncrement(eq *models.Equipment) float64 {
+	if eq == nil {
+		return categoryIncrement(nil)
+	}
+
+	loadType := ""
+	if eq.LoadType != nil {
+		loadType = *eq.LoadType
+	}
+
+	switch {
+	case loadType == models.LoadTypePlates && len(eq.Plates) > 0:
+		return 2 * smallestPlate(eq.Plates)
+	case loadType == models.LoadTypeStack && eq.StackIncrement != nil:
+		return *eq.StackIncrement
+	case loadType == models.LoadTypeDumbbells && eq.DumbbellStep != nil:
+		return *eq.DumbbellStep
+	default:
+		return categoryIncrement(&eq.Category)
+	}
+}
+
+// roundLoad redondea un peso a la carga alcanzable más cercana en el equipo
+func roundLoad(eq *models.Equipment, weight float64) float64 {
+	if eq == nil {
+		return roundToIncrement(weight, categoryIncrement(nil))
+	}
+	return computeLoad(*eq, weight).Achievable
+}
+
+// computeLoad calcula la carga alcanzable más cercana al objetivo según el tipo de carga
+func computeLoad(eq models.Equipment, target float64) models.LoadCalculation {
+	result := models.LoadCalculation{
+		EquipmentID: eq.ID,
+		LoadType:    eq.LoadType,
+		Target:      target,
+	}
+
+	loadType := ""
+	if eq.LoadType != nil {
+		loadType = *eq.LoadType
+	}
+
+	// Sin discos configurados no hay desglose posible: como en equipmentIncrement,
+	// se redondea con el salto de la categoría
+	var achievable float64
+	switch {
+	case loadType == models.LoadTypePlates && len(eq.Plates) > 0:
+		bar := defaultBarWeight
+		if eq.BarWeight != nil {
+			bar = *eq.BarWeight
+		}
+		result.BarWeight = &bar
+		var perSide []models.PlateCount
+		achievable, perSide = plateBreakdown(bar, eq.Plates, target, eq.MaxWeight)
+		result.PerSide = perSide
+
+	case loadType == models.LoadTypeStack:
+		increment := defaultStackIncrement
+		if eq.StackIncrement != nil {
+			increment = *eq.StackIncrement
+		}
+		min := increment
+		if eq.MinWeight != nil {
+			min = *eq.MinWeight
+		}
+		achievable = min + math.Round((target-min)/increment)*increment
+		achievable = clampLoad(achievable, &min, eq.MaxWeight)
+
+	case loadType == models.LoadTypeDumbbells:
+		step := defaultDumbbellStep
+		if eq.DumbbellStep != nil {
+			step = *eq.DumbbellStep
+		}
+		min := step
+		if eq.MinWeight != nil {
+			min = *eq.MinWeight
+		}
+		achievable = min + math.Round((target-min)/step)*step
+		achievable = clampLoad(achievable, &min, eq.MaxWeight)
+
+	default:
+		achievable = roundToIncrement(target, categoryIncrement(&eq.Category))
+	}
+
+	result.Achievable = math.Round(achievable*100) / 100
+	result.Difference = math.Round((result.Achievable-target)*100) / 100
+	return result
+}
+
+// plateBreakdown reparte el peso sobre la barra en discos por lado, usando los
+// discos más pesados primero. Devuelve el peso total cargado y el desglose
+func plateBreakdown(bar float64, plates []float64, target float64, max *float64) (float64, []models.PlateCount) {
+	if len(plates) == 0 || target <= bar {
+		return bar, nil
+	}
+
+	if max != nil && target > *max {
+		target = *max
+	}
+
+	sorted := append([]float64(nil), plates...)
+	sort.Sort(sort.Reverse(sort.Float64Slice(sorted)))
+
+	// Redondear el peso por lado al disco más chico; si así se pasaría del máximo,
+	// redondear hacia abajo
+	smallest := sorted[len(sorted)-1]
+	remaining := math.Round((target-bar)/2/smallest) * smallest
+	if max != nil && bar+2*remaining > *max+1e-9 {
+		remaining = math.Floor((target-bar)/2/smallest+1e-9) * smallest
+	}
+
+	var perSide []models.PlateCount
+	loaded := 0.0
+	for _, plate := range sorted {
+		count := int((remaining + 1e-9) / plate)
+		if count == 0 {
+			continue
+		}
+		perSide = append(perSide, models.PlateCount{Weight: plate, Count: count})
+		remaining -= float64(count) * plate
+		loaded += float64(count) * plate
+	}
+
+	return bar + 2*loaded, perSide
+}
+
+// smallestPlate devuelve el disco más liviano disponible
+func smallestPlate(plates []float64) float64 {
+	smallest := plates[0]
+	for _, plate := range plates[1:] {
+		if plate < smallest {
+			smallest = plate
+		}
+	}
+	return smallest
+}
+
+// clampLoad limita una carga al rango del equipo
+func clampLoad(weight float64, min, max *float64) float64 {
+	if min != nil && weight < *min {
+		weight = *min
+	}
+	if max != nil && weight > *max {
+		weight = *max
+	}
+	return weight
+}
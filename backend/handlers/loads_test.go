@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/goalritmo/gym/backend/models"
+)
+
+func TestComputeLoadPlates(t *testing.T) {
+	loadType := models.LoadTypePlates
+	bar := 20.0
+	eq := models.Equipment{
+		ID:        1,
+		LoadType:  &loadType,
+		BarWeight: &bar,
+		Plates:    []float64{1.25, 2.5, 5, 10, 20, 25},
+	}
+
+	result := computeLoad(eq, 102)
+	if result.Achievable != 102.5 {
+		t.Errorf("Expected achievable 102.5, got %v", result.Achievable)
+	}
+	want := []models.PlateCount{{Weight: 25, Count: 1}, {Weight: 10, Count: 1}, {Weight: 5, Count: 1}, {Weight: 1.25, Count: 1}}
+	if len(result.PerSide) != len(want) {
+		t.Fatalf("Expected %v per side, got %v", want, result.PerSide)
+	}
+	for i := range want {
+		if result.PerSide[i] != want[i] {
+			t.Errorf("Expected %v per side, got %v", want, result.PerSide)
+			break
+		}
+	}
+
+	// Por debajo del peso de la barra solo se usa la barra
+	if result := computeLoad(eq, 15); result.Achievable != 20 || result.PerSide != nil {
+		t.Errorf("Expected empty bar, got %+v", result)
+	}
+
+	// Con un máximo fuera de la grilla de discos se redondea hacia abajo
+	max := 103.0
+	capped := models.Equipment{LoadType: &loadType, BarWeight: &bar, Plates: []float64{20, 10, 5, 2.5}, MaxWeight: &max}
+	if result := computeLoad(capped, 110); result.Achievable != 100 {
+		t.Errorf("Expected 100 under max 103, got %+v", result)
+	}
+
+	// Sin discos configurados se redondea con el salto de la categoría, no se
+	// devuelve la barra
+	noPlates := models.Equipment{LoadType: &loadType, BarWeight: &bar, Category: "pesas_libres"}
+	if result := computeLoad(noPlates, 61); result.Achievable != 60 || result.PerSide != nil {
+		t.Errorf("Expected category rounding without plates, got %+v", result)
+	}
+}
+
+func TestComputeLoadStackAndDumbbells(t *testing.T) {
+	stack := models.LoadTypeStack
+	increment, min, max := 7.0, 7.0, 98.0
+	machine := models.Equipment{LoadType: &stack, StackIncrement: &increment, MinWeight: &min, MaxWeight: &max}
+
+	tests := []struct {
+		target float64
+		want   float64
+	}{
+		{40, 42},
+		{3, 7},
+		{150, 98},
+	}
+	for _, tt := range tests {
+		if got := computeLoad(machine, tt.target).Achievable; got != tt.want {
+			t.Errorf("Stack target %v: expected %v, got %v", tt.target, tt.want, got)
+		}
+	}
+
+	dumbbells := models.LoadTypeDumbbells
+	step := 2.5
+	rack := models.Equipment{LoadType: &dumbbells, DumbbellStep: &step}
+	if got := computeLoad(rack, 23).Achievable; got != 22.5 {
+		t.Errorf("Dumbbells: expected 22.5, got %v", got)
+	}
+
+	// La serie empieza en el mínimo del rack: 2.5, 4.5, 6.5...
+	oddStep, rackMin := 2.0, 2.5
+	oddRack := models.Equipment{LoadType: &dumbbells, DumbbellStep: &oddStep, MinWeight: &rackMin}
+	if got := computeLoad(oddRack, 5).Achievable; got != 4.5 {
+		t.Errorf("Dumbbells from min: expected 4.5, got %v", got)
+	}
+}
+
+func TestEquipmentIncrement(t *testing.T) {
+	plates := models.LoadTypePlates
+	eq := &models.Equipment{LoadType: &plates, Plates: []float64{20, 1.25, 5}}
+	if got := equipmentIncrement(eq); got != 2.5 {
+		t.Errorf("Expected 2.5, got %v", got)
+	}
+	if got := equipmentIncrement(&models.Equipment{Category: "maquinas"}); got != 5 {
+		t.Errorf("Expected category fallback 5, got %v", got)
+	}
+}
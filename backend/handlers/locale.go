@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/goalritmo/gym/backend/database"
+	"github.com/goalritmo/gym/backend/models"
+	"github.com/lib/pq"
+)
+
+// translationTables son las tablas de cada tipo de entrada traducible
+var translationTables = map[string]string{
+	models.TranslationExercise:    "exercises",
+	models.TranslationEquipment:   "equipment",
+	models.TranslationMuscleGroup: "muscle_groups",
+}
+
+// requestLocale elige el idioma de la respuesta según Accept-Language y lo
+// informa en Content-Language
+func requestLocale(w http.ResponseWriter, r *http.Request) string {
+	locale := parseAcceptLanguage(r.Header.Get("Accept-Language"))
+	w.Header().Set("Content-Language", locale)
+	w.Header().Add("Vary", "Accept-Language")
+	return locale
+}
+
+// parseAcceptLanguage devuelve el idioma soportado con mayor peso (q) del header
+// ("en-US,en;q=0.9,es;q=0.8" -> "en"), o el idioma por defecto si no hay ninguno
+func parseAcceptLanguage(header string) string {
+	best, bestWeight := models.DefaultLocale, 0.0
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		weight := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			weight = parsed
+		}
+
+		language, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if weight > bestWeight && containsString(models.Locales, language) {
+			best, bestWeight = language, weight
+		}
+	}
+	return best
+}
+
+// loadTranslations obtiene los campos traducidos (entity_id -> campo -> valor)
+// de las entradas indicadas
+func loadTranslations(locale, entityType string, ids []int) (map[int]map[string]string, error) {
+	translations := make(map[int]map[string]string)
+	if locale == models.DefaultLocale || len(ids) == 0 {
+		return translations, nil
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT entity_id, field, value
+		FROM catalog_translations
+		WHERE entity_type = $1 AND locale = $2 AND entity_id = ANY($3)
+	`, entityType, locale, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var field, value string
+		if err := rows.Scan(&id, &field, &value); err != nil {
+			return nil, err
+		}
+		if translations[id] == nil {
+			translations[id] = make(map[string]string)
+		}
+		translations[id][field] = value
+	}
+	return translations, rows.Err()
+}
+
+// loadNameTranslations obtiene los nombres traducidos de un tipo de entrada
+// indexados por el nombre original, para los textos que se devuelven sin su ID
+// (p. ej. el equipo y los músculos de un ejercicio)
+func loadNameTranslations(locale, entityType string) (map[string]string, error) {
+	names := make(map[string]string)
+	if locale == models.DefaultLocale {
+		return names, nil
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT b.name, t.value
+		FROM catalog_translations t
+		JOIN `+translationTables[entityType]+` b ON b.id = t.entity_id
+		WHERE t.entity_type = $1 AND t.locale = $2 AND t.field = 'name'
+	`, entityType, locale)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name, value string
+		if err := rows.Scan(&name, &value); err != nil {
+			return nil, err
+		}
+		names[name] = value
+	}
+	return names, rows.Err()
+}
+
+// translateNames reemplaza cada nombre por su traducción, si existe
+func translateNames(names []string, translations map[string]string) []string {
+	for i, name := range names {
+		if translated, ok := translations[name]; ok {
+			names[i] = translated
+		}
+	}
+	return names
+}
+
+// localizeExercises traduce el nombre, el equipo y los músculos de los ejercicios
+func localizeExercises(locale string, exercises []models.Exercise) error {
+	if locale == models.DefaultLocale || len(exercises) == 0 {
+		return nil
+	}
+
+	ids := make([]int, len(exercises))
+	for i, exercise := range exercises {
+		ids[i] = exercise.ID
+	}
+	translations, err := loadTranslations(locale, models.TranslationExercise, ids)
+	if err != nil {
+		return err
+	}
+	muscles, err := loadNameTranslations(locale, models.TranslationMuscleGroup)
+	if err != nil {
+		return err
+	}
+	equipment, err := loadNameTranslations(locale, models.TranslationEquipment)
+	if err != nil {
+		return err
+	}
+
+	for i := range exercises {
+		exercise := &exercises[i]
+		if name, ok := translations[exercise.ID][models.TranslationFieldName]; ok {
+			exercise.Name = name
+		}
+		if name, ok := equipment[exercise.Equipment]; ok {
+			exercise.Equipment = name
+		}
+		exercise.PrimaryMuscles = translateNames(exercise.PrimaryMuscles, muscles)
+		exercise.SecondaryMuscles = translateNames(exercise.SecondaryMuscles, muscles)
+	}
+	return nil
+}
+
+// localizeEquipment traduce el nombre y las observaciones de los equipos y, si
+// se incluyeron, sus ejercicios
+func localizeEquipment(locale string, equipment []models.Equipment) error {
+	if locale == models.DefaultLocale || len(equipment) == 0 {
+		return nil
+	}
+
+	ids := make([]int, len(equipment))
+	for i, eq := range equipment {
+		ids[i] = eq.ID
+	}
+	translations, err := loadTranslations(locale, models.TranslationEquipment, ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range equipment {
+		eq := &equipment[i]
+		if name, ok := translations[eq.ID][models.TranslationFieldName]; ok {
+			eq.Name = name
+		}
+		if observations, ok := translations[eq.ID][models.TranslationFieldObservations]; ok {
+			eq.Observations = &observations
+		}
+		if err := localizeExercises(locale, eq.Exercises); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// translatedNames obtiene los nombres traducidos (ID -> nombre) de las entradas indicadas
+func translatedNames(locale, entityType string, ids []int) (map[int]string, error) {
+	translations, err := loadTranslations(locale, entityType, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[int]string)
+	for id, fields := range translations {
+		if name, ok := fields[models.TranslationFieldName]; ok {
+			names[id] = name
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/goalritmo/gym/backend/models"
+)
+
+func TestParseAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected string
+	}{
+		{"", "es"},
+		{"en", "en"},
+		{"en-US,en;q=0.9,es;q=0.8", "en"},
+		{"es-AR,es;q=0.9,en;q=0.8", "es"},
+		{"fr-FR,fr;q=0.9,en;q=0.5", "en"},
+		{"es;q=0.3, en;q=0.7", "en"},
+		{"de, *;q=0.5", "es"},
+		{"en;q=abc", "es"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if locale := parseAcceptLanguage(tt.header); locale != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, locale)
+			}
+		})
+	}
+}
+
+func TestTranslateNames(t *testing.T) {
+	names := translateNames([]string{"Pectoral mayor", "Tríceps"}, map[string]string{"Pectoral mayor": "Pectoralis major"})
+	if !reflect.DeepEqual(names, []string{"Pectoralis major", "Tríceps"}) {
+		t.Errorf("Expected untranslated names to fall back, got %v", names)
+	}
+}
+
+func TestLocalizeDefaultLocale(t *testing.T) {
+	// En el idioma por defecto no se consulta la base
+	exercises := []models.Exercise{{ID: 1, Name: "Press de banca"}}
+	if err := localizeExercises(models.DefaultLocale, exercises); err != nil || exercises[0].Name != "Press de banca" {
+		t.Errorf("Expected exercises unchanged, got %v %v", exercises, err)
+	}
+	names, err := translatedNames(models.DefaultLocale, models.TranslationExercise, []int{1})
+	if err != nil || len(names) != 0 {
+		t.Errorf("Expected no translations, got %v %v", names, err)
+	}
+}
+
+func TestValidateTranslationRequest(t *testing.T) {
+	name, blank, observations := "Bench press", "  ", "Olympic bar"
+
+	tests := []struct {
+		name       string
+		entityType string
+		locale     string
+		req        models.TranslationRequest
+		expected   map[string]*string
+		wantErr    bool
+	}{
+		{"Nombre de ejercicio", "exercise", "en", models.TranslationRequest{Name: &name}, map[string]*string{"name": &name}, false},
+		{"Nombre vacío elimina", "exercise", "en", models.TranslationRequest{Name: &blank}, map[string]*string{"name": nil}, false},
+		{"Equipo con observaciones", "equipment", "en", models.TranslationRequest{Name: &name, Observations: &observations},
+			map[string]*string{"name": &name, "observations": &observations}, false},
+		{"Observaciones en ejercicio", "exercise", "en", models.TranslationRequest{Observations: &observations}, nil, true},
+		{"Idioma por defecto", "exercise", "es", models.TranslationRequest{Name: &name}, nil, true},
+		{"Idioma no soportado", "exercise", "fr", models.TranslationRequest{Name: &name}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields, err := validateTranslationRequest(tt.entityType, tt.locale, &tt.req)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !reflect.DeepEqual(fields, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, fields)
+			}
+		})
+	}
+}
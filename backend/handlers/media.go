@@ -0,0 +1,362 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+
+	"github.com/goalritmo/gym/backend/database"
+	"github.com/goalritmo/gym/backend/middleware"
+	"github.com/goalritmo/gym/backend/models"
+	"github.com/goalritmo/gym/backend/storage"
+	"github.com/gorilla/mux"
+)
+
+// Límites de los archivos subidos
+const (
+	maxImageBytes = 5 << 20
+	maxVideoBytes = 50 << 20
+	// maxImagePixels es el máximo de píxeles de una imagen. Un PNG o JPEG chico
+	// puede declarar dimensiones enormes y decodificado ocupar gigabytes
+	maxImagePixels = 25_000_000
+	// thumbnailSize es el lado máximo de las miniaturas, en píxeles
+	thumbnailSize = 320
+)
+
+// errImageTooLarge indica que la imagen supera maxImagePixels
+var errImageTooLarge = fmt.Errorf("La imagen supera el máximo de %d megapíxeles", maxImagePixels/1_000_000)
+
+// mediaType describe un tipo de contenido aceptado
+type mediaType struct {
+	Kind      string
+	Extension string
+}
+
+// mediaTypes son los tipos de contenido aceptados, detectados por el contenido
+// del archivo y no por el nombre o el Content-Type que envía el cliente
+var mediaTypes = map[string]mediaType{
+	"image/jpeg": {models.MediaImage, ".jpg"},
+	"image/png":  {models.MediaImage, ".png"},
+	"video/mp4":  {models.MediaVideo, ".mp4"},
+	"video/webm": {models.MediaVideo, ".webm"},
+}
+
+// maxMediaBytes es el tamaño máximo por tipo de archivo
+var maxMediaBytes = map[string]int64{
+	models.MediaImage: maxImageBytes,
+	models.MediaVideo: maxVideoBytes,
+}
+
+// UploadEquipmentImageHandler sube la imagen de un equipo (multipart, campo
+// "file") y genera su miniatura. Solo administradores
+func UploadEquipmentImageHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	var previous *int
+	if err := database.DB.QueryRow(`SELECT image_media_id FROM equipment WHERE id = $1`, id).Scan(&previous); err != nil {
+		http.Error(w, "Equipo no encontrado", http.StatusNotFound)
+		return
+	}
+
+	media, status, err := storeUpload(w, r, models.MediaImage)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	var equipment models.Equipment
+	err = scanEquipment(database.DB.QueryRow(`
+		UPDATE equipment SET image_media_id = $1 WHERE id = $2
+		RETURNING `+equipmentColumns,
+		media.ID, id,
+	), &equipment)
+	if err != nil {
+		deleteMedia(media.ID)
+		http.Error(w, "Error asociando imagen al equipo", http.StatusInternalServerError)
+		return
+	}
+	if previous != nil {
+		deleteMedia(*previous)
+	}
+
+	json.NewEncoder(w).Encode(equipment)
+}
+
+// UploadExerciseVideoHandler sube el video corto de un ejercicio (multipart,
+// campo "file"). Solo administradores
+func UploadExerciseVideoHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	var previous *int
+	if err := database.DB.QueryRow(`SELECT video_media_id FROM exercises WHERE id = $1`, id).Scan(&previous); err != nil {
+		http.Error(w, "Ejercicio no encontrado", http.StatusNotFound)
+		return
+	}
+
+	media, status, err := storeUpload(w, r, models.MediaVideo)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	if _, err := database.DB.Exec(`UPDATE exercises SET video_media_id = $1 WHERE id = $2`, media.ID, id); err != nil {
+		deleteMedia(media.ID)
+		http.Error(w, "Error asociando video al ejercicio", http.StatusInternalServerError)
+		return
+	}
+	if previous != nil {
+		deleteMedia(*previous)
+	}
+
+	exercise, err := loadExercise(id)
+	if err != nil {
+		http.Error(w, "Error consultando ejercicio", http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(exercise)
+}
+
+// storeUpload valida el archivo del campo "file", lo guarda (con su miniatura si
+// es una imagen) y lo registra en media. Devuelve el status HTTP a usar si falla
+func storeUpload(w http.ResponseWriter, r *http.Request, kind string) (*models.Media, int, error) {
+	if storage.Media == nil {
+		return nil, http.StatusServiceUnavailable, fmt.Errorf("Almacenamiento de archivos no configurado")
+	}
+
+	principal, ok := middleware.PrincipalFrom(r.Context())
+	if !ok {
+		return nil, http.StatusUnauthorized, fmt.Errorf("Unauthorized: no hay usuario autenticado")
+	}
+	userID := principal.UserID
+
+	limit := maxMediaBytes[kind]
+	r.Body = http.MaxBytesReader(w, r.Body, limit+1<<20)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("Se requiere el archivo en el campo file (máximo %d MB)", limit>>20)
+	}
+	defer file.Close()
+
+	if header.Size > limit {
+		return nil, http.StatusRequestEntityTooLarge, fmt.Errorf("El archivo supera el máximo de %d MB", limit>>20)
+	}
+
+	contentType, extension, err := detectMediaType(file, kind)
+	if err != nil {
+		return nil, http.StatusUnsupportedMediaType, err
+	}
+
+	media := &models.Media{Kind: kind, ContentType: contentType, SizeBytes: header.Size, UploadedBy: &userID}
+	media.StorageKey, err = newMediaKey(kind, extension)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("Error guardando archivo")
+	}
+
+	var thumbnail []byte
+	if kind == models.MediaImage {
+		data, err := io.ReadAll(file)
+		if err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("Error leyendo archivo")
+		}
+		if thumbnail, err = makeThumbnail(data); err == errImageTooLarge {
+			return nil, http.StatusRequestEntityTooLarge, err
+		} else if err != nil {
+			return nil, http.StatusUnsupportedMediaType, fmt.Errorf("La imagen no se puede procesar")
+		}
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return nil, http.StatusInternalServerError, fmt.Errorf("Error guardando archivo")
+		}
+	}
+
+	if err := putMedia(r.Context(), media, file, thumbnail); err != nil {
+		log.Printf("Error guardando archivo %s: %v", media.StorageKey, err)
+		return nil, http.StatusBadGateway, fmt.Errorf("Error guardando archivo")
+	}
+
+	err = database.DB.QueryRow(`
+		INSERT INTO media (kind, storage_key, thumbnail_key, content_type, size_bytes, uploaded_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`, media.Kind, media.StorageKey, media.ThumbnailKey, media.ContentType, media.SizeBytes, userID).Scan(&media.ID, &media.CreatedAt)
+	if err != nil {
+		removeMediaFiles(media.StorageKey, media.ThumbnailKey)
+		return nil, http.StatusInternalServerError, fmt.Errorf("Error registrando archivo")
+	}
+
+	return media, http.StatusOK, nil
+}
+
+// putMedia guarda el archivo y su miniatura, si la hay
+func putMedia(ctx context.Context, media *models.Media, file multipart.File, thumbnail []byte) error {
+	if err := storage.Media.Put(ctx, media.StorageKey, file, media.SizeBytes, media.ContentType); err != nil {
+		return err
+	}
+	if thumbnail == nil {
+		return nil
+	}
+
+	thumbnailKey := "thumbnails/" + media.StorageKey + ".jpg"
+	if err := storage.Media.Put(ctx, thumbnailKey, bytes.NewReader(thumbnail), int64(len(thumbnail)), "image/jpeg"); err != nil {
+		storage.Media.Delete(ctx, media.StorageKey)
+		return err
+	}
+	media.ThumbnailKey = &thumbnailKey
+	return nil
+}
+
+// detectMediaType identifica el tipo por los primeros bytes del archivo y
+// verifica que sea del tipo esperado. Deja el archivo al principio
+func detectMediaType(file io.ReadSeeker, kind string) (string, string, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return "", "", fmt.Errorf("Error leyendo archivo")
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", "", fmt.Errorf("Error leyendo archivo")
+	}
+
+	contentType := http.DetectContentType(head[:n])
+	detected, ok := mediaTypes[contentType]
+	if !ok || detected.Kind != kind {
+		if kind == models.MediaImage {
+			return "", "", fmt.Errorf("Tipo de archivo no soportado (%s): se acepta JPEG o PNG", contentType)
+		}
+		return "", "", fmt.Errorf("Tipo de archivo no soportado (%s): se acepta MP4 o WebM", contentType)
+	}
+	return contentType, detected.Extension, nil
+}
+
+// makeThumbnail reduce la imagen para que entre en thumbnailSize x thumbnailSize,
+// conservando la proporción, y la codifica como JPEG. Las dimensiones se leen del
+// encabezado antes de decodificar, para rechazar las imágenes de más de
+// maxImagePixels sin reservar su memoria
+func makeThumbnail(data []byte) ([]byte, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if int64(config.Width)*int64(config.Height) > maxImagePixels {
+		return nil, errImageTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return nil, fmt.Errorf("imagen vacía")
+	}
+	scale := float64(thumbnailSize) / float64(max(width, height))
+	if scale > 1 {
+		scale = 1
+	}
+	dstWidth, dstHeight := max(1, int(float64(width)*scale)), max(1, int(float64(height)*scale))
+
+	// Promedio de los píxeles de origen que cubre cada píxel de destino
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < dstHeight; y++ {
+		y0, y1 := y*height/dstHeight, max((y+1)*height/dstHeight, y*height/dstHeight+1)
+		for x := 0; x < dstWidth; x++ {
+			x0, x1 := x*width/dstWidth, max((x+1)*width/dstWidth, x*width/dstWidth+1)
+			var r, g, b, a, count uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(bounds.Min.X+sx, bounds.Min.Y+sy).RGBA()
+					r, g, b, a = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca)
+					count++
+				}
+			}
+			i := dst.PixOffset(x, y)
+			dst.Pix[i] = uint8(r / count >> 8)
+			dst.Pix[i+1] = uint8(g / count >> 8)
+			dst.Pix[i+2] = uint8(b / count >> 8)
+			dst.Pix[i+3] = uint8(a / count >> 8)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// newMediaKey genera una clave aleatoria, p. ej. "images/3f9c...e1.jpg"
+func newMediaKey(kind, extension string) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return kind + "s/" + hex.EncodeToString(random) + extension, nil
+}
+
+// signedMediaURL firma la clave de un archivo subido. Devuelve nil si no hay
+// almacenamiento configurado o la clave no se puede firmar
+func signedMediaURL(key string) *string {
+	if storage.Media == nil {
+		return nil
+	}
+	url, err := storage.Media.SignedURL(key, storage.URLExpiry)
+	if err != nil {
+		log.Printf("Error firmando URL de %s: %v", key, err)
+		return nil
+	}
+	return &url
+}
+
+// deleteMedia elimina un archivo que ya no se usa (registro y archivos). Los
+// errores solo se registran: un archivo huérfano no afecta a la respuesta
+func deleteMedia(id int) {
+	var storageKey string
+	var thumbnailKey *string
+	err := database.DB.QueryRow(`DELETE FROM media WHERE id = $1 RETURNING storage_key, thumbnail_key`, id).Scan(&storageKey, &thumbnailKey)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("Error eliminando media %d: %v", id, err)
+		}
+		return
+	}
+	removeMediaFiles(storageKey, thumbnailKey)
+}
+
+// removeMediaFiles elimina un archivo y su miniatura del almacenamiento
+func removeMediaFiles(storageKey string, thumbnailKey *string) {
+	keys := []string{storageKey}
+	if thumbnailKey != nil {
+		keys = append(keys, *thumbnailKey)
+	}
+	for _, key := range keys {
+		if err := storage.Media.Delete(context.Background(), key); err != nil {
+			log.Printf("Error eliminando archivo %s: %v", key, err)
+		}
+	}
+}
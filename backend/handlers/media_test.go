@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/goalritmo/gym/backend/storage"
+)
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("png.Encode: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestDetectMediaType(t *testing.T) {
+	mp4 := append([]byte{0, 0, 0, 0x18}, []byte("ftypmp42\x00\x00\x00\x00mp42isom")...)
+
+	tests := []struct {
+		name        string
+		data        []byte
+		kind        string
+		contentType string
+		wantErr     bool
+	}{
+		{"PNG como imagen", encodePNG(t, 4, 4), "image", "image/png", false},
+		{"MP4 como video", mp4, "video", "video/mp4", false},
+		{"MP4 como imagen", mp4, "image", "", true},
+		{"Texto renombrado", []byte("no soy una imagen"), "image", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contentType, _, err := detectMediaType(bytes.NewReader(tt.data), tt.kind)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error, got %s", contentType)
+				}
+				return
+			}
+			if err != nil || contentType != tt.contentType {
+				t.Errorf("Expected %s, got %s (%v)", tt.contentType, contentType, err)
+			}
+		})
+	}
+}
+
+func TestMakeThumbnail(t *testing.T) {
+	tests := []struct {
+		name           string
+		width, height  int
+		expectedWidth  int
+		expectedHeight int
+	}{
+		{"Horizontal", 1280, 720, 320, 180},
+		{"Vertical", 400, 800, 160, 320},
+		{"Más chica que la miniatura", 100, 50, 100, 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			thumbnail, err := makeThumbnail(encodePNG(t, tt.width, tt.height))
+			if err != nil {
+				t.Fatalf("makeThumbnail: %v", err)
+			}
+			img, err := jpeg.Decode(bytes.NewReader(thumbnail))
+			if err != nil {
+				t.Fatalf("Expected JPEG thumbnail: %v", err)
+			}
+			if size := img.Bounds().Size(); size.X != tt.expectedWidth || size.Y != tt.expectedHeight {
+				t.Errorf("Expected %dx%d, got %dx%d", tt.expectedWidth, tt.expectedHeight, size.X, size.Y)
+			}
+		})
+	}
+
+	if _, err := makeThumbnail([]byte("no soy una imagen")); err == nil {
+		t.Error("Expected error for invalid image")
+	}
+}
+
+func TestMakeThumbnailRejectsHugeImages(t *testing.T) {
+	// PNG chico cuyo encabezado declara 10000x10000: se rechaza sin decodificarlo
+	data := encodePNG(t, 10, 10)
+	ihdr := data[12:29]
+	binary.BigEndian.PutUint32(ihdr[4:8], 10000)
+	binary.BigEndian.PutUint32(ihdr[8:12], 10000)
+	binary.BigEndian.PutUint32(data[29:33], crc32.ChecksumIEEE(ihdr))
+
+	if _, err := makeThumbnail(data); err != errImageTooLarge {
+		t.Errorf("Expected errImageTooLarge, got %v", err)
+	}
+}
+
+func TestNewMediaKey(t *testing.T) {
+	key, err := newMediaKey("image", ".jpg")
+	if err != nil || !strings.HasPrefix(key, "images/") || !strings.HasSuffix(key, ".jpg") || len(key) != len("images/")+32+4 {
+		t.Errorf("Unexpected key %q (%v)", key, err)
+	}
+}
+
+func TestStoreUploadWithoutStorage(t *testing.T) {
+	previous := storage.Media
+	storage.Media = nil
+	defer func() { storage.Media = previous }()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/equipment/1/image", nil)
+	if _, status, err := storeUpload(httptest.NewRecorder(), req, "image"); err == nil || status != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without storage, got %d %v", status, err)
+	}
+	if url := signedMediaURL("images/a.jpg"); url != nil {
+		t.Errorf("Expected no URL without storage, got %s", *url)
+	}
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/goalritmo/gym/backend/database"
+	"github.com/goalritmo/gym/backend/middleware"
+	"github.com/goalritmo/gym/backend/models"
+	"github.com/gorilla/mux"
+)
+
+// GetMuscleGroupsHandler lista los grupos musculares (?category=empuje|tirar|piernas|core)
+// en el idioma de Accept-Language
+func GetMuscleGroupsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	locale := requestLocale(w, r)
+
+	query := `SELECT id, name, category FROM muscle_groups`
+	args := []interface{}{}
+
+	if category := r.URL.Query().Get("category"); category != "" {
+		if !containsString(models.MuscleGroupCategories, category) {
+			http.Error(w, "category inválida", http.StatusBadRequest)
+			return
+		}
+		query += ` WHERE category = $1`
+		args = append(args, category)
+	}
+	query += ` ORDER BY name ASC`
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		http.Error(w, "Error consultando grupos musculares", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	groups := []models.MuscleGroup{}
+	for rows.Next() {
+		var group models.MuscleGroup
+		if err := rows.Scan(&group.ID, &group.Name, &group.Category); err != nil {
+			http.Error(w, "Error escaneando grupo muscular", http.StatusInternalServerError)
+			return
+		}
+		groups = append(groups, group)
+	}
+
+	ids := make([]int, len(groups))
+	for i, group := range groups {
+		ids[i] = group.ID
+	}
+	names, err := translatedNames(locale, models.TranslationMuscleGroup, ids)
+	if err != nil {
+		http.Error(w, "Error traduciendo grupos musculares", http.StatusInternalServerError)
+		return
+	}
+	for i := range groups {
+		if name, ok := names[groups[i].ID]; ok {
+			groups[i].Name = name
+		}
+	}
+
+	json.NewEncoder(w).Encode(groups)
+}
+
+// GetMuscleGroupExercisesHandler lista los ejercicios que trabajan un grupo
+// muscular, opcionalmente solo como primario o secundario (?role=)
+func GetMuscleGroupExercisesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := middleware.CurrentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	condition, args, err := muscleGroupExercisesCondition(id, r.URL.Query().Get("role"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var exists bool
+	err = database.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM muscle_groups WHERE id = $1)`, id).Scan(&exists)
+	if err != nil {
+		http.Error(w, "Error consultando grupo muscular", http.StatusInternalServerError)
+		return
+	}
+	if !exists {
+		http.Error(w, "Grupo muscular no encontrado", http.StatusNotFound)
+		return
+	}
+
+	exercises, err := queryExercises(userID, condition, args...)
+	if err != nil {
+		http.Error(w, "Error consultando ejercicios del grupo muscular", http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(exercises)
+}
+
+// muscleGroupExercisesCondition arma la condición de queryExercises para los
+// ejercicios vinculados a un grupo muscular con el rol indicado (vacío para ambos)
+func muscleGroupExercisesCondition(muscleGroupID int, role string) (string, []interface{}, error) {
+	condition := `EXISTS (
+			SELECT 1 FROM exercise_muscle_groups f_emg
+			WHERE f_emg.exercise_id = e.id AND f_emg.muscle_group_id = $2`
+	args := []interface{}{muscleGroupID}
+
+	switch role {
+	case "":
+	case models.MuscleRolePrimary, models.MuscleRoleSecondary:
+		condition += ` AND f_emg.role = $3`
+		args = append(args, role)
+	default:
+		return "", nil, fmt.Errorf("role inválido (primary o secondary)")
+	}
+
+	return condition + `)`, args, nil
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMuscleGroupExercisesCondition(t *testing.T) {
+	condition, args, err := muscleGroupExercisesCondition(7, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(args) != 1 || strings.Contains(condition, "f_emg.role") {
+		t.Errorf("Expected condition without role, got %q %v", condition, args)
+	}
+
+	condition, args, err = muscleGroupExercisesCondition(7, "secondary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(args) != 2 || args[1] != "secondary" || !strings.Contains(condition, "f_emg.role = $3") {
+		t.Errorf("Expected role condition, got %q %v", condition, args)
+	}
+
+	if _, _, err := muscleGroupExercisesCondition(7, "main"); err == nil {
+		t.Error("Expected error for invalid role")
+	}
+}
@@ -0,0 +1,431 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/goalritmo/gym/backend/database"
+	"github.com/goalritmo/gym/backend/middleware"
+	"github.com/goalritmo/gym/backend/models"
+	"github.com/gorilla/mux"
+)
+
+// setResult representa una serie registrada (peso, repeticiones y RPE opcional)
+type setResult struct {
+	Weight float64
+	Reps   int
+	RPE    *float64
+}
+
+// GetProgramsHandler obtiene la lista de programas disponibles
+func GetProgramsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	query := `
+		SELECT id, name, description, weeks, weight_increment, deload_every, deload_percent, created_at
+		FROM programs
+		ORDER BY name ASC
+	`
+
+	rows, err := database.DB.Query(query)
+	if err != nil {
+		http.Error(w, "Error consultando programas", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	var programs []models.Program
+	for rows.Next() {
+		var program models.Program
+		err := rows.Scan(
+			&program.ID,
+			&program.Name,
+			&program.Description,
+			&program.Weeks,
+			&program.WeightIncrement,
+			&program.DeloadEvery,
+			&program.DeloadPercent,
+			&program.CreatedAt,
+		)
+		if err != nil {
+			http.Error(w, "Error escaneando programa", http.StatusInternalServerError)
+			return
+		}
+		programs = append(programs, program)
+	}
+
+	json.NewEncoder(w).Encode(programs)
+}
+
+// GetProgramHandler obtiene un programa con sus semanas y días
+func GetProgramHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	program, err := loadProgram(id)
+	if err != nil {
+		http.Error(w, "Programa no encontrado", http.StatusNotFound)
+		return
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT pd.id, pd.program_id, pd.week, pd.day_of_week, pd.template_id, rt.name
+		FROM program_days pd
+		JOIN routine_templates rt ON pd.template_id = rt.id
+		WHERE pd.program_id = $1
+		ORDER BY pd.week ASC, pd.day_of_week ASC
+	`, id)
+	if err != nil {
+		http.Error(w, "Error consultando días del programa", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var day models.ProgramDay
+		err := rows.Scan(&day.ID, &day.ProgramID, &day.Week, &day.DayOfWeek, &day.TemplateID, &day.TemplateName)
+		if err != nil {
+			http.Error(w, "Error escaneando día del programa", http.StatusInternalServerError)
+			return
+		}
+		program.Days = append(program.Days, day)
+	}
+
+	json.NewEncoder(w).Encode(program)
+}
+
+// EnrollProgramHandler inscribe al usuario actual en un programa
+func EnrollProgramHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	programID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := middleware.CurrentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.EnrollProgramRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "JSON inválido", http.StatusBadRequest)
+			return
+		}
+	}
+
+	startDate := time.Now()
+	if req.StartDate != nil {
+		startDate = *req.StartDate
+	}
+
+	program, err := loadProgram(programID)
+	if err != nil {
+		http.Error(w, "Programa no encontrado", http.StatusNotFound)
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		http.Error(w, "Error iniciando inscripción", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	// Solo puede haber una inscripción activa por usuario
+	_, err = tx.Exec(`UPDATE program_enrollments SET active = FALSE WHERE user_id = $1 AND active`, userID)
+	if err != nil {
+		http.Error(w, "Error actualizando inscripciones anteriores", http.StatusInternalServerError)
+		return
+	}
+
+	enrollment := models.ProgramEnrollment{
+		UserID:      userID,
+		ProgramID:   program.ID,
+		ProgramName: program.Name,
+	}
+	err = tx.QueryRow(`
+		INSERT INTO program_enrollments (user_id, program_id, start_date)
+		VALUES ($1, $2, $3)
+		RETURNING id, start_date, active, created_at
+	`, userID, program.ID, startDate.Format("2006-01-02")).Scan(
+		&enrollment.ID, &enrollment.StartDate, &enrollment.Active, &enrollment.CreatedAt,
+	)
+	if err != nil {
+		http.Error(w, "Error creando inscripción", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error confirmando inscripción", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(enrollment)
+}
+
+// GetTodayHandler obtiene el entrenamiento planificado para hoy según el programa activo
+func GetTodayHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, ok := middleware.CurrentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var enrollment models.ProgramEnrollment
+	err := database.DB.QueryRow(`
+		SELECT id, program_id, start_date
+		FROM program_enrollments
+		WHERE user_id = $1 AND active
+		LIMIT 1
+	`, userID).Scan(&enrollment.ID, &enrollment.ProgramID, &enrollment.StartDate)
+	if err == sql.ErrNoRows {
+		http.Error(w, "No hay un programa activo", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error consultando inscripción", http.StatusInternalServerError)
+		return
+	}
+
+	program, err := loadProgram(enrollment.ProgramID)
+	if err != nil {
+		http.Error(w, "Programa no encontrado", http.StatusNotFound)
+		return
+	}
+
+	today := time.Now()
+	weekNumber, week, dayOfWeek := programPosition(enrollment.StartDate, today, program.Weeks)
+
+	plan := models.TodayWorkout{
+		Date:        today.Format("2006-01-02"),
+		ProgramID:   program.ID,
+		ProgramName: program.Name,
+		Week:        week,
+		DayOfWeek:   dayOfWeek,
+		Deload:      isDeloadWeek(weekNumber, program.DeloadEvery),
+		Exercises:   []models.PlannedExercise{},
+	}
+
+	// Antes de la fecha de inicio no hay nada planificado
+	if weekNumber == 0 {
+		startsOn := enrollment.StartDate.Format("2006-01-02")
+		plan.NotStarted = true
+		plan.StartsOn = &startsOn
+		json.NewEncoder(w).Encode(plan)
+		return
+	}
+
+	var templateID int
+	var templateName string
+	err = database.DB.QueryRow(`
+		SELECT pd.template_id, rt.name
+		FROM program_days pd
+		JOIN routine_templates rt ON pd.template_id = rt.id
+		WHERE pd.program_id = $1 AND pd.week = $2 AND pd.day_of_week = $3
+	`, program.ID, week, dayOfWeek).Scan(&templateID, &templateName)
+	if err == sql.ErrNoRows {
+		plan.RestDay = true
+		json.NewEncoder(w).Encode(plan)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error consultando día del programa", http.StatusInternalServerError)
+		return
+	}
+	plan.TemplateID = &templateID
+	plan.TemplateName = &templateName
+
+	rows, err := database.DB.Query(`
+		SELECT rte.exercise_id, e.name, rte.sets, rte.reps
+		FROM routine_template_exercises rte
+		JOIN exercises e ON rte.exercise_id = e.id
+		WHERE rte.template_id = $1
+		ORDER BY rte.position ASC
+	`, templateID)
+	if err != nil {
+		http.Error(w, "Error consultando ejercicios de la rutina", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var planned models.PlannedExercise
+		if err := rows.Scan(&planned.ExerciseID, &planned.ExerciseName, &planned.Sets, &planned.Reps); err != nil {
+			http.Error(w, "Error escaneando ejercicio de la rutina", http.StatusInternalServerError)
+			return
+		}
+		plan.Exercises = append(plan.Exercises, planned)
+	}
+	rows.Close()
+
+	for i := range plan.Exercises {
+		planned := &plan.Exercises[i]
+		sets, err := lastSessionSets(userID, planned.ExerciseID)
+		if err != nil {
+			http.Error(w, "Error consultando historial del ejercicio", http.StatusInternalServerError)
+			return
+		}
+		planned.TargetWeight, planned.LastWeight, planned.Reason = computeTargetWeight(
+			sets, planned.Reps, program.WeightIncrement, plan.Deload, program.DeloadPercent,
+		)
+
+		equipment, err := exerciseEquipment(planned.ExerciseID, userID)
+		if err != nil && err != sql.ErrNoRows {
+			http.Error(w, "Error consultando equipo del ejercicio", http.StatusInternalServerError)
+			return
+		}
+
+		// Ajustar el objetivo a una carga que el equipo permita
+		if planned.TargetWeight != nil {
+			weight := roundLoad(equipment, *planned.TargetWeight)
+			planned.TargetWeight = &weight
+		}
+
+		// Si el equipo no está disponible, sugerir el mejor reemplazo
+		if equipment != nil && equipment.Status != models.EquipmentAvailable {
+			planned.EquipmentStatus = &equipment.Status
+			alternatives, err := suggestAlternatives(planned.ExerciseID, userID, alternativesParams{Limit: 1})
+			if err != nil {
+				http.Error(w, "Error consultando reemplazos del ejercicio", http.StatusInternalServerError)
+				return
+			}
+			if len(alternatives) > 0 {
+				planned.Substitute = &alternatives[0]
+			}
+		}
+	}
+
+	json.NewEncoder(w).Encode(plan)
+}
+
+// loadProgram obtiene un programa por ID (sin sus días)
+func loadProgram(id int) (*models.Program, error) {
+	var program models.Program
+	err := database.DB.QueryRow(`
+		SELECT id, name, description, weeks, weight_increment, deload_every, deload_percent, created_at
+		FROM programs
+		WHERE id = $1
+	`, id).Scan(
+		&program.ID,
+		&program.Name,
+		&program.Description,
+		&program.Weeks,
+		&program.WeightIncrement,
+		&program.DeloadEvery,
+		&program.DeloadPercent,
+		&program.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &program, nil
+}
+
+// lastSessionSets obtiene las series del último día en que el usuario hizo el ejercicio
+func lastSessionSets(userID string, exerciseID int) ([]setResult, error) {
+	rows, err := database.DB.Query(`
+		SELECT weight, reps
+		FROM workouts
+		WHERE user_id = $1 AND exercise_id = $2 AND NOT is_warmup
+		  AND DATE(created_at) = (
+			SELECT MAX(DATE(created_at)) FROM workouts WHERE user_id = $1 AND exercise_id = $2 AND NOT is_warmup
+		  )
+		ORDER BY created_at ASC
+	`, userID, exerciseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sets []setResult
+	for rows.Next() {
+		var set setResult
+		if err := rows.Scan(&set.Weight, &set.Reps); err != nil {
+			return nil, err
+		}
+		sets = append(sets, set)
+	}
+	return sets, rows.Err()
+}
+
+// programPosition calcula la semana absoluta desde el inicio, la semana dentro
+// del programa (cíclica) y el día de la semana (1 = lunes ... 7 = domingo). Antes
+// de la fecha de inicio ambas semanas son 0: el programa todavía no empezó
+func programPosition(startDate, today time.Time, weeks int) (int, int, int) {
+	start := time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 0, 0, time.UTC)
+	current := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+
+	dayOfWeek := int(today.Weekday())
+	if dayOfWeek == 0 {
+		dayOfWeek = 7
+	}
+
+	days := int(current.Sub(start).Hours() / 24)
+	if days < 0 {
+		return 0, 0, dayOfWeek
+	}
+
+	weekNumber := days/7 + 1
+	week := weekNumber
+	if weeks > 0 {
+		week = (weekNumber-1)%weeks + 1
+	}
+
+	return weekNumber, week, dayOfWeek
+}
+
+// isDeloadWeek indica si la semana absoluta es de descarga
+func isDeloadWeek(weekNumber int, deloadEvery *int) bool {
+	if deloadEvery == nil || *deloadEvery <= 0 || weekNumber <= 0 {
+		return false
+	}
+	return weekNumber%*deloadEvery == 0
+}
+
+// computeTargetWeight aplica las reglas de progresión del programa sobre la
+// última sesión: sube el peso si se completaron todas las reps con el peso de
+// trabajo, lo mantiene si no, y lo reduce en semanas de descarga
+func computeTargetWeight(sets []setResult, targetReps int, increment float64, deload bool, deloadPercent float64) (*float64, *float64, string) {
+	if len(sets) == 0 {
+		return nil, nil, "Sin historial: elegir un peso cómodo"
+	}
+
+	// El peso de trabajo es el máximo de la sesión
+	working, workSets := workingSets(sets)
+	allRepsHit := minReps(workSets) >= targetReps
+
+	last := working
+	target := working
+	reason := "Mantener peso hasta completar todas las repeticiones"
+
+	if allRepsHit {
+		target = working + increment
+		reason = fmt.Sprintf("Repeticiones completadas: +%g kg", increment)
+	}
+
+	if deload {
+		target = working * deloadPercent
+		reason = fmt.Sprintf("Semana de descarga: %g%% del peso de trabajo", deloadPercent*100)
+	}
+
+	target = math.Round(target*100) / 100
+	return &target, &last, reason
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProgramPosition(t *testing.T) {
+	// 2024-01-01 fue lunes
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		today      time.Time
+		weeks      int
+		weekNumber int
+		week       int
+		dayOfWeek  int
+	}{
+		{"Primer día", start, 4, 1, 1, 1},
+		{"Domingo de la primera semana", time.Date(2024, 1, 7, 18, 0, 0, 0, time.UTC), 4, 1, 1, 7},
+		{"Segunda semana", time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC), 4, 2, 2, 3},
+		{"El programa vuelve a empezar", time.Date(2024, 1, 29, 0, 0, 0, 0, time.UTC), 4, 5, 1, 1},
+		{"Fecha anterior al inicio", time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC), 4, 0, 0, 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			weekNumber, week, dayOfWeek := programPosition(start, tt.today, tt.weeks)
+			if weekNumber != tt.weekNumber || week != tt.week || dayOfWeek != tt.dayOfWeek {
+				t.Errorf("got (%d, %d, %d), want (%d, %d, %d)",
+					weekNumber, week, dayOfWeek, tt.weekNumber, tt.week, tt.dayOfWeek)
+			}
+		})
+	}
+}
+
+func TestIsDeloadWeek(t *testing.T) {
+	every := 4
+	if isDeloadWeek(3, &every) {
+		t.Error("La semana 3 no debería ser de descarga")
+	}
+	if !isDeloadWeek(4, &every) {
+		t.Error("La semana 4 debería ser de descarga")
+	}
+	if isDeloadWeek(4, nil) {
+		t.Error("Sin deload_every no hay semanas de descarga")
+	}
+	if isDeloadWeek(0, &every) {
+		t.Error("Antes del inicio no hay semanas de descarga")
+	}
+}
+
+func TestComputeTargetWeight(t *testing.T) {
+	tests := []struct {
+		name   string
+		sets   []setResult
+		deload bool
+		target *float64
+	}{
+		{"Sin historial", nil, false, nil},
+		{"Todas las reps completadas", []setResult{{60, 10, nil}, {60, 10, nil}, {60, 11, nil}}, false, floatPtr(62.5)},
+		{"Reps incompletas", []setResult{{60, 10, nil}, {60, 8, nil}}, false, floatPtr(60)},
+		{"Series de aproximación no cuentan", []setResult{{40, 5, nil}, {60, 10, nil}}, false, floatPtr(62.5)},
+		{"Semana de descarga", []setResult{{60, 10, nil}}, true, floatPtr(54)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, _, reason := computeTargetWeight(tt.sets, 10, 2.5, tt.deload, 0.9)
+			if reason == "" {
+				t.Error("Reason no debe estar vacío")
+			}
+			if tt.target == nil {
+				if target != nil {
+					t.Errorf("Expected nil target, got %v", *target)
+				}
+				return
+			}
+			if target == nil || *target != *tt.target {
+				t.Errorf("Expected target %v, got %v", *tt.target, target)
+			}
+		})
+	}
+}
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
@@ -0,0 +1,321 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/goalritmo/gym/backend/database"
+	"github.com/goalritmo/gym/backend/middleware"
+	"github.com/goalritmo/gym/backend/models"
+	"github.com/gorilla/mux"
+)
+
+// progressionParams agrupa los parámetros de una estrategia de progresión
+type progressionParams struct {
+	RepMin    int
+	RepMax    int
+	Reps      int
+	TargetRPE float64
+	Increment float64
+}
+
+// GetNextTargetHandler sugiere peso y repeticiones para la próxima serie de un ejercicio
+func GetNextTargetHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	exerciseID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := middleware.CurrentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	// Obtener parámetros de query
+	q := r.URL.Query()
+	strategy := q.Get("strategy")
+	if strategy == "" {
+		strategy = models.ProgressionDouble
+	}
+	if strategy != models.ProgressionDouble && strategy != models.ProgressionLinear && strategy != models.ProgressionRPE {
+		http.Error(w, "Estrategia inválida (double, linear o rpe)", http.StatusBadRequest)
+		return
+	}
+
+	params := progressionParams{RepMin: 8, RepMax: 12, Reps: 5, TargetRPE: 8}
+	if err := parseIntParam(q.Get("rep_min"), &params.RepMin); err != nil {
+		http.Error(w, "rep_min inválido", http.StatusBadRequest)
+		return
+	}
+	if err := parseIntParam(q.Get("rep_max"), &params.RepMax); err != nil {
+		http.Error(w, "rep_max inválido", http.StatusBadRequest)
+		return
+	}
+	if err := parseIntParam(q.Get("reps"), &params.Reps); err != nil {
+		http.Error(w, "reps inválido", http.StatusBadRequest)
+		return
+	}
+	if v := q.Get("rpe"); v != "" {
+		rpe, err := strconv.ParseFloat(v, 64)
+		if err != nil || rpe < 1 || rpe > 10 {
+			http.Error(w, "rpe inválido (1-10)", http.StatusBadRequest)
+			return
+		}
+		params.TargetRPE = rpe
+	}
+	if params.RepMin <= 0 || params.RepMax < params.RepMin || params.Reps <= 0 {
+		http.Error(w, "Rango de repeticiones inválido", http.StatusBadRequest)
+		return
+	}
+
+	equipment, err := exerciseEquipment(exerciseID, userID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Ejercicio no encontrado", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error consultando ejercicio", http.StatusInternalServerError)
+		return
+	}
+	params.Increment = equipmentIncrement(equipment)
+
+	sessions, err := recentSessions(userID, exerciseID, 3)
+	if err != nil {
+		http.Error(w, "Error consultando historial del ejercicio", http.StatusInternalServerError)
+		return
+	}
+
+	var target models.NextTarget
+	switch strategy {
+	case models.ProgressionLinear:
+		target = suggestLinear(sessions, params)
+	case models.ProgressionRPE:
+		target = suggestRPE(sessions, params)
+	default:
+		target = suggestDouble(sessions, params)
+	}
+	target.ExerciseID = exerciseID
+
+	// Ajustar la sugerencia a una carga que el equipo permita
+	if target.Weight != nil {
+		weight := roundLoad(equipment, *target.Weight)
+		target.Weight = &weight
+	}
+
+	json.NewEncoder(w).Encode(target)
+}
+
+// parseIntParam parsea un parámetro entero opcional, manteniendo el valor por defecto si está vacío
+func parseIntParam(value string, dest *int) error {
+	if value == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return err
+	}
+	*dest = n
+	return nil
+}
+
+// categoryIncrement devuelve el salto mínimo de carga según la categoría del equipo
+func categoryIncrement(category *string) float64 {
+	if category == nil {
+		return 2.5
+	}
+	switch *category {
+	case "maquinas":
+		return 5
+	case "pesas_libres", "rack", "cables":
+		return 2.5
+	default:
+		return 1
+	}
+}
+
+// recentSessions obtiene las series de los últimos n días en que el usuario
+// hizo el ejercicio, de la más reciente a la más antigua
+func recentSessions(userID string, exerciseID, n int) ([][]setResult, error) {
+	rows, err := database.DB.Query(`
+		SELECT DATE(created_at), weight, reps, rpe
+		FROM workouts
+		WHERE user_id = $1 AND exercise_id = $2 AND NOT is_warmup
+		  AND DATE(created_at) IN (
+			SELECT DISTINCT DATE(created_at) FROM workouts
+			WHERE user_id = $1 AND exercise_id = $2 AND NOT is_warmup
+			ORDER BY 1 DESC
+			LIMIT $3
+		  )
+		ORDER BY DATE(created_at) DESC, created_at ASC
+	`, userID, exerciseID, n)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions [][]setResult
+	var currentDay time.Time
+	for rows.Next() {
+		var day time.Time
+		var set setResult
+		if err := rows.Scan(&day, &set.Weight, &set.Reps, &set.RPE); err != nil {
+			return nil, err
+		}
+		if len(sessions) == 0 || !day.Equal(currentDay) {
+			sessions = append(sessions, []setResult{})
+			currentDay = day
+		}
+		sessions[len(sessions)-1] = append(sessions[len(sessions)-1], set)
+	}
+	return sessions, rows.Err()
+}
+
+// workingSets devuelve el peso de trabajo (máximo) de una sesión y las series hechas con él
+func workingSets(sets []setResult) (float64, []setResult) {
+	working := 0.0
+	for _, set := range sets {
+		if set.Weight > working {
+			working = set.Weight
+		}
+	}
+
+	var result []setResult
+	for _, set := range sets {
+		if set.Weight == working {
+			result = append(result, set)
+		}
+	}
+	return working, result
+}
+
+// minReps devuelve la menor cantidad de repeticiones entre las series
+func minReps(sets []setResult) int {
+	min := 0
+	for i, set := range sets {
+		if i == 0 || set.Reps < min {
+			min = set.Reps
+		}
+	}
+	return min
+}
+
+// roundToIncrement redondea un peso al salto de carga más cercano
+func roundToIncrement(weight, increment float64) float64 {
+	if increment > 0 {
+		weight = math.Round(weight/increment) * increment
+	}
+	return math.Round(weight*100) / 100
+}
+
+// suggestDouble aplica doble progresión: subir repeticiones dentro del rango y,
+// al llegar al máximo en todas las series, subir el peso y volver al mínimo
+func suggestDouble(sessions [][]setResult, p progressionParams) models.NextTarget {
+	target := models.NextTarget{Strategy: models.ProgressionDouble, Increment: p.Increment, Reps: p.RepMin}
+	if len(sessions) == 0 {
+		target.Reason = "Sin historial: elegir un peso que permita " + strconv.Itoa(p.RepMin) + " repeticiones"
+		return target
+	}
+
+	working, sets := workingSets(sessions[0])
+	reps := minReps(sets)
+	target.LastWeight = &working
+	target.LastReps = &reps
+
+	weight := working
+	if reps >= p.RepMax {
+		weight = roundToIncrement(working+p.Increment, p.Increment)
+		target.Reps = p.RepMin
+		target.Reason = fmt.Sprintf("Se alcanzaron %d repeticiones en todas las series: +%g kg", p.RepMax, p.Increment)
+	} else {
+		target.Reps = reps + 1
+		if target.Reps < p.RepMin {
+			target.Reps = p.RepMin
+		}
+		target.Reason = fmt.Sprintf("Mantener peso y sumar repeticiones hasta %d", p.RepMax)
+	}
+	target.Weight = &weight
+	return target
+}
+
+// suggestLinear aplica progresión lineal: subir peso cada sesión completada y
+// descargar un 10% tras dos sesiones seguidas fallidas con el mismo peso
+func suggestLinear(sessions [][]setResult, p progressionParams) models.NextTarget {
+	target := models.NextTarget{Strategy: models.ProgressionLinear, Increment: p.Increment, Reps: p.Reps}
+	if len(sessions) == 0 {
+		target.Reason = "Sin historial: elegir un peso cómodo"
+		return target
+	}
+
+	working, sets := workingSets(sessions[0])
+	reps := minReps(sets)
+	target.LastWeight = &working
+	target.LastReps = &reps
+
+	weight := working
+	switch {
+	case reps >= p.Reps:
+		weight = roundToIncrement(working+p.Increment, p.Increment)
+		target.Reason = fmt.Sprintf("Repeticiones completadas: +%g kg", p.Increment)
+	case len(sessions) > 1 && failedAt(sessions[1], working, p.Reps):
+		weight = roundToIncrement(working*0.9, p.Increment)
+		target.Reason = "Dos sesiones fallidas con el mismo peso: descarga del 10%"
+	default:
+		target.Reason = "Repetir el peso hasta completar todas las repeticiones"
+	}
+	target.Weight = &weight
+	return target
+}
+
+// failedAt indica si en la sesión no se completaron las repeticiones con ese peso
+func failedAt(session []setResult, weight float64, reps int) bool {
+	working, sets := workingSets(session)
+	return working == weight && minReps(sets) < reps
+}
+
+// suggestRPE estima el 1RM a partir de la última serie con RPE registrado
+// (Epley con repeticiones en reserva) y calcula el peso para las repeticiones
+// y el RPE objetivo
+func suggestRPE(sessions [][]setResult, p progressionParams) models.NextTarget {
+	var top *setResult
+	if len(sessions) > 0 {
+		for i := range sessions[0] {
+			set := sessions[0][i]
+			if set.RPE != nil && (top == nil || set.Weight >= top.Weight) {
+				top = &set
+			}
+		}
+	}
+
+	if top == nil {
+		target := suggestDouble(sessions, p)
+		target.Strategy = models.ProgressionRPE
+		target.Reason = "Sin series con RPE registrado: se usa doble progresión. " + target.Reason
+		return target
+	}
+
+	e1RM := top.Weight * (1 + (float64(top.Reps)+(10-*top.RPE))/30)
+	weight := roundToIncrement(e1RM/(1+(float64(p.Reps)+(10-p.TargetRPE))/30), p.Increment)
+	rpe := p.TargetRPE
+	lastWeight := top.Weight
+	lastReps := top.Reps
+
+	return models.NextTarget{
+		Strategy:   models.ProgressionRPE,
+		Weight:     &weight,
+		Reps:       p.Reps,
+		RPE:        &rpe,
+		Increment:  p.Increment,
+		LastWeight: &lastWeight,
+		LastReps:   &lastReps,
+		Reason:     fmt.Sprintf("1RM estimado %.1f kg a partir de %g kg x %d @ RPE %g", e1RM, top.Weight, top.Reps, *top.RPE),
+	}
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"testing"
+)
+
+func TestSuggestDouble(t *testing.T) {
+	params := progressionParams{RepMin: 8, RepMax: 12, Increment: 2.5}
+
+	tests := []struct {
+		name     string
+		sessions [][]setResult
+		weight   *float64
+		reps     int
+	}{
+		{"Sin historial", nil, nil, 8},
+		{"Dentro del rango", [][]setResult{{{40, 10, nil}, {40, 9, nil}}}, floatPtr(40), 10},
+		{"Rango completado", [][]setResult{{{40, 12, nil}, {40, 12, nil}, {40, 13, nil}}}, floatPtr(42.5), 8},
+		{"Por debajo del mínimo", [][]setResult{{{40, 5, nil}}}, floatPtr(40), 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := suggestDouble(tt.sessions, params)
+			assertTarget(t, target.Weight, target.Reps, tt.weight, tt.reps)
+		})
+	}
+}
+
+func TestSuggestLinear(t *testing.T) {
+	params := progressionParams{Reps: 5, Increment: 2.5}
+
+	tests := []struct {
+		name     string
+		sessions [][]setResult
+		weight   *float64
+	}{
+		{"Sin historial", nil, nil},
+		{"Sesión completada", [][]setResult{{{100, 5, nil}, {100, 5, nil}}}, floatPtr(102.5)},
+		{"Primera sesión fallida", [][]setResult{{{100, 5, nil}, {100, 3, nil}}, {{97.5, 5, nil}}}, floatPtr(100)},
+		{"Dos sesiones fallidas", [][]setResult{{{100, 4, nil}}, {{100, 3, nil}}}, floatPtr(90)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := suggestLinear(tt.sessions, params)
+			assertTarget(t, target.Weight, target.Reps, tt.weight, 5)
+		})
+	}
+}
+
+func TestSuggestRPE(t *testing.T) {
+	params := progressionParams{RepMin: 8, RepMax: 12, Reps: 5, TargetRPE: 8, Increment: 2.5}
+
+	// 100 kg x 5 @ RPE 8 equivale a 7 repeticiones al fallo: el mismo objetivo repite el peso
+	target := suggestRPE([][]setResult{{{100, 5, floatPtr(8)}}}, params)
+	assertTarget(t, target.Weight, target.Reps, floatPtr(100), 5)
+	if target.RPE == nil || *target.RPE != 8 {
+		t.Errorf("Expected RPE 8, got %v", target.RPE)
+	}
+
+	// Sin RPE registrado se usa doble progresión
+	target = suggestRPE([][]setResult{{{40, 12, nil}}}, params)
+	if target.Strategy != "rpe" || target.RPE != nil {
+		t.Errorf("Expected rpe strategy without RPE target, got %+v", target)
+	}
+	assertTarget(t, target.Weight, target.Reps, floatPtr(42.5), 8)
+}
+
+func TestRoundToIncrement(t *testing.T) {
+	if got := roundToIncrement(91.3, 2.5); got != 92.5 {
+		t.Errorf("Expected 92.5, got %v", got)
+	}
+	if got := roundToIncrement(91.333, 0); got != 91.33 {
+		t.Errorf("Expected 91.33, got %v", got)
+	}
+}
+
+func assertTarget(t *testing.T, weight *float64, reps int, wantWeight *float64, wantReps int) {
+	t.Helper()
+	if wantWeight == nil {
+		if weight != nil {
+			t.Errorf("Expected nil weight, got %v", *weight)
+		}
+	} else if weight == nil || *weight != *wantWeight {
+		t.Errorf("Expected weight %v, got %v", *wantWeight, weight)
+	}
+	if reps != wantReps {
+		t.Errorf("Expected reps %d, got %d", wantReps, reps)
+	}
+}
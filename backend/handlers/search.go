@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/goalritmo/gym/backend/database"
+	"github.com/goalritmo/gym/backend/middleware"
+	"github.com/goalritmo/gym/backend/models"
+)
+
+// Parámetros de la búsqueda en el catálogo
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 50
+	// searchMinScore descarta coincidencias demasiado lejanas: es el
+	// pg_trgm.word_similarity_threshold con el que filtra el operador <%
+	searchMinScore = 0.3
+)
+
+// searchSources describe las tablas del catálogo en las que se busca
+var searchSources = map[string]string{
+	models.SearchTypeExercise:  "exercises",
+	models.SearchTypeEquipment: "equipment",
+}
+
+// searchVisibility limita cada tabla a lo que el usuario puede ver: los ejercicios
+// privados solo aparecen para su dueño
+var searchVisibility = map[string]string{
+	models.SearchTypeExercise:  "AND (t.owner_id IS NULL OR t.owner_id = q.user_id)",
+	models.SearchTypeEquipment: "",
+}
+
+// searchParams representa los parámetros de GET /api/search
+type searchParams struct {
+	Query string
+	Types []string
+	Limit int
+}
+
+// SearchHandler busca ejercicios y equipos por nombre (en cualquier idioma) o
+// sinónimo, sin distinguir acentos y tolerando errores de tipeo, ordenando por
+// relevancia. Los nombres se devuelven en el idioma de Accept-Language
+func SearchHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	locale := requestLocale(w, r)
+
+	userID, ok := middleware.CurrentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	params, err := parseSearchParams(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// El umbral de <% se fija solo para esta transacción
+	tx, err := database.DB.Begin()
+	if err != nil {
+		http.Error(w, "Error buscando en el catálogo", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(fmt.Sprintf(`SET LOCAL pg_trgm.word_similarity_threshold = %g`, searchMinScore)); err != nil {
+		http.Error(w, "Error buscando en el catálogo", http.StatusInternalServerError)
+		return
+	}
+
+	rows, err := tx.Query(buildSearchQuery(params.Types), params.Query, params.Limit, userID)
+	if err != nil {
+		http.Error(w, "Error buscando en el catálogo", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	results := []models.SearchResult{}
+	for rows.Next() {
+		var result models.SearchResult
+		err := rows.Scan(&result.Type, &result.ID, &result.Name, &result.Score, &result.MatchedAlias)
+		if err != nil {
+			http.Error(w, "Error escaneando resultado", http.StatusInternalServerError)
+			return
+		}
+		results = append(results, result)
+	}
+
+	if err := localizeSearchResults(locale, results); err != nil {
+		http.Error(w, "Error traduciendo resultados", http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(results)
+}
+
+// localizeSearchResults traduce los nombres de los resultados. Si lo que coincidió
+// fue justamente el nombre traducido, no se informa como sinónimo
+func localizeSearchResults(locale string, results []models.SearchResult) error {
+	ids := make(map[string][]int)
+	for _, result := range results {
+		ids[result.Type] = append(ids[result.Type], result.ID)
+	}
+
+	names := make(map[string]map[int]string)
+	for searchType, typeIDs := range ids {
+		translated, err := translatedNames(locale, searchType, typeIDs)
+		if err != nil {
+			return err
+		}
+		names[searchType] = translated
+	}
+
+	for i := range results {
+		result := &results[i]
+		if name, ok := names[result.Type][result.ID]; ok {
+			result.Name = name
+		}
+		if result.MatchedAlias != nil && *result.MatchedAlias == result.Name {
+			result.MatchedAlias = nil
+		}
+	}
+	return nil
+}
+
+// parseSearchParams lee y valida q, type (exercise, equipment o vacío para ambos) y limit
+func parseSearchParams(q url.Values) (searchParams, error) {
+	params := searchParams{
+		Query: strings.TrimSpace(q.Get("q")),
+		Limit: defaultSearchLimit,
+	}
+
+	if params.Query == "" {
+		return params, fmt.Errorf("El parámetro q es requerido")
+	}
+
+	switch searchType := q.Get("type"); searchType {
+	case "", "all":
+		params.Types = []string{models.SearchTypeExercise, models.SearchTypeEquipment}
+	case models.SearchTypeExercise, models.SearchTypeEquipment:
+		params.Types = []string{searchType}
+	default:
+		return params, fmt.Errorf("type inválido (exercise o equipment)")
+	}
+
+	if err := parseIntParam(q.Get("limit"), &params.Limit); err != nil || params.Limit <= 0 {
+		return params, fmt.Errorf("limit inválido")
+	}
+	if params.Limit > maxSearchLimit {
+		params.Limit = maxSearchLimit
+	}
+
+	return params, nil
+}
+
+// buildSearchQuery arma la consulta de búsqueda para los tipos pedidos.
+// Argumentos: $1 término, $2 límite, $3 usuario.
+//
+// Los candidatos son los registros cuyo nombre, sinónimo o traducción (sin
+// acentos) contiene el término con operador <%, que usa los índices gin_trgm; el
+// umbral es pg_trgm.word_similarity_threshold (ver searchMinScore). El puntaje es
+// la mejor word_similarity entre el término y esos textos, más 1 si todas las
+// palabras del término aparecen en el nombre (búsqueda de texto completo), para
+// que "press banca" encuentre "Press de banca" por encima de coincidencias parciales
+func buildSearchQuery(types []string) string {
+	var parts []string
+	for _, searchType := range types {
+		parts = append(parts, fmt.Sprintf(`
+		SELECT '%[1]s' AS type, t.id, t.name,
+			GREATEST(word_similarity(q.term, f_unaccent(lower(t.name))), COALESCE(al.score, 0))
+			+ CASE WHEN to_tsvector('simple', f_unaccent(lower(t.name))) @@ plainto_tsquery('simple', q.term) THEN 1 ELSE 0 END AS score,
+			CASE WHEN COALESCE(al.score, 0) > word_similarity(q.term, f_unaccent(lower(t.name))) THEN al.alias END AS matched_alias
+		FROM %[2]s t
+		CROSS JOIN q
+		LEFT JOIN LATERAL (
+			SELECT a.alias, word_similarity(q.term, f_unaccent(lower(a.alias))) AS score
+			FROM (
+				SELECT alias, entity_type, entity_id FROM catalog_aliases
+				UNION
+				SELECT value, entity_type, entity_id FROM catalog_translations WHERE field = 'name'
+			) a
+			WHERE a.entity_type = '%[1]s' AND a.entity_id = t.id
+			ORDER BY score DESC
+			LIMIT 1
+		) al ON TRUE
+		WHERE t.id IN (
+			SELECT c.id FROM %[2]s c, q WHERE q.term <%% f_unaccent(lower(c.name))
+			UNION
+			SELECT a.entity_id FROM catalog_aliases a, q
+			WHERE a.entity_type = '%[1]s' AND q.term <%% f_unaccent(lower(a.alias))
+			UNION
+			SELECT tr.entity_id FROM catalog_translations tr, q
+			WHERE tr.entity_type = '%[1]s' AND tr.field = 'name' AND q.term <%% f_unaccent(lower(tr.value))
+		)
+		%[3]s`, searchType, searchSources[searchType], searchVisibility[searchType]))
+	}
+
+	return `
+		WITH q AS (SELECT f_unaccent(lower($1)) AS term, $3::uuid AS user_id)
+		SELECT type, id, name, score, matched_alias FROM (` +
+		strings.Join(parts, "\n\t\tUNION ALL") + `
+		) results
+		ORDER BY score DESC, name ASC
+		LIMIT $2`
+}
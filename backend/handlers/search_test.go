@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestParseSearchParams(t *testing.T) {
+	params, err := parseSearchParams(url.Values{"q": {"  jalon  "}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params.Query != "jalon" || len(params.Types) != 2 || params.Limit != defaultSearchLimit {
+		t.Errorf("Unexpected params: %+v", params)
+	}
+
+	params, err = parseSearchParams(url.Values{"q": {"banca"}, "type": {"equipment"}, "limit": {"500"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(params.Types) != 1 || params.Types[0] != "equipment" || params.Limit != maxSearchLimit {
+		t.Errorf("Unexpected params: %+v", params)
+	}
+
+	invalid := []url.Values{
+		{},
+		{"q": {"   "}},
+		{"q": {"press"}, "type": {"users"}},
+		{"q": {"press"}, "limit": {"0"}},
+	}
+	for _, q := range invalid {
+		if _, err := parseSearchParams(q); err == nil {
+			t.Errorf("Expected error for %v", q)
+		}
+	}
+}
+
+func TestBuildSearchQuery(t *testing.T) {
+	query := buildSearchQuery([]string{"exercise", "equipment"})
+	for _, expected := range []string{"FROM exercises t", "FROM equipment t", "UNION ALL", "a.entity_type = 'equipment'", "FROM catalog_translations WHERE field = 'name'", "t.owner_id = q.user_id", "q.term <% f_unaccent(lower(c.name))", "q.term <% f_unaccent(lower(a.alias))", "LIMIT $2"} {
+		if !strings.Contains(query, expected) {
+			t.Errorf("Expected query to contain %q:\n%s", expected, query)
+		}
+	}
+
+	query = buildSearchQuery([]string{"exercise"})
+	if strings.Contains(query, "UNION ALL") || strings.Contains(query, "FROM equipment t") {
+		t.Errorf("Expected exercises only:\n%s", query)
+	}
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/goalritmo/gym/backend/middleware"
+)
+
+// LogoutHandler revoca el JWT de la request (y, en Supabase, los demás tokens de
+// su sesión, incluidos los que emita al renovarse) para que la API lo rechace
+// aunque no haya vencido
+func LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middleware.CurrentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	if principal.TokenType != middleware.TokenTypeJWT {
+		http.Error(w, "Solo se puede cerrar la sesión de un JWT; los tokens de acceso se revocan en /api/me/tokens", http.StatusBadRequest)
+		return
+	}
+	if principal.TokenID == "" {
+		http.Error(w, "El token no tiene jti ni session_id; usar /api/me/logout-all", http.StatusBadRequest)
+		return
+	}
+
+	expiresAt := middleware.RevocationExpiry(principal, time.Now())
+	if err := middleware.Revocations().RevokeToken(principal.TokenID, principal.UserID, expiresAt); err != nil {
+		http.Error(w, "Error cerrando sesión", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// LogoutAllHandler revoca todos los JWT del usuario emitidos hasta ahora, en todos
+// sus dispositivos. Los tokens de acceso personales no se revocan
+func LogoutAllHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.CurrentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := middleware.Revocations().RevokeSessions(userID); err != nil {
+		http.Error(w, "Error cerrando sesiones", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
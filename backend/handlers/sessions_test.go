@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/goalritmo/gym/backend/middleware"
+)
+
+func TestLogoutHandlerRequiresRevocableJWT(t *testing.T) {
+	principals := []*middleware.Principal{
+		{UserID: "00000000-0000-0000-0000-000000000001", TokenType: middleware.TokenTypeAccessToken},
+		{UserID: "00000000-0000-0000-0000-000000000001", TokenType: middleware.TokenTypeDev},
+		{UserID: "00000000-0000-0000-0000-000000000001", TokenType: middleware.TokenTypeJWT},
+	}
+
+	for _, principal := range principals {
+		req := httptest.NewRequest("POST", "/api/me/logout", nil)
+		req = req.WithContext(middleware.WithPrincipal(req.Context(), principal))
+		rec := httptest.NewRecorder()
+		LogoutHandler(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %+v, got %d", principal, rec.Code)
+		}
+	}
+}
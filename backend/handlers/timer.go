@@ -0,0 +1,398 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/goalritmo/gym/backend/database"
+	"github.com/goalritmo/gym/backend/middleware"
+	"github.com/goalritmo/gym/backend/models"
+)
+
+// Acciones del cronómetro de descanso
+const (
+	timerStart  = "start"
+	timerPause  = "pause"
+	timerResume = "resume"
+	timerReset  = "reset"
+)
+
+// timerHeartbeat es el intervalo de los comentarios keep-alive del stream SSE
+const timerHeartbeat = 25 * time.Second
+
+// timerHub reparte los cambios de estado del cronómetro a los clientes conectados
+// por SSE. Vive en memoria del proceso: con varias instancias cada una notifica
+// solo a sus propios clientes
+type timerHub struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan models.RestTimer]struct{}
+}
+
+var timers = &timerHub{subscribers: make(map[string]map[chan models.RestTimer]struct{})}
+
+// subscribe registra un cliente y devuelve su canal y la función para darlo de baja
+func (h *timerHub) subscribe(userID string) (chan models.RestTimer, func()) {
+	ch := make(chan models.RestTimer, 1)
+
+	h.mu.Lock()
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[chan models.RestTimer]struct{})
+	}
+	h.subscribers[userID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		delete(h.subscribers[userID], ch)
+		if len(h.subscribers[userID]) == 0 {
+			delete(h.subscribers, userID)
+		}
+		h.mu.Unlock()
+	}
+}
+
+// publish envía el estado a todos los clientes del usuario. Si un cliente no
+// consumió el estado anterior se reemplaza, ya que solo importa el último
+func (h *timerHub) publish(timer models.RestTimer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subscribers[timer.UserID] {
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- timer
+	}
+}
+
+// GetTimerHandler obtiene el estado actual del cronómetro de descanso
+func GetTimerHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, ok := middleware.CurrentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	timer, err := loadTimer(userID)
+	if err != nil {
+		http.Error(w, "Error consultando cronómetro", http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(withTimerProgress(*timer, time.Now()))
+}
+
+// StartTimerHandler inicia el cronómetro, vinculado a la última serie registrada
+func StartTimerHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.CurrentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.StartTimerRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "JSON inválido", http.StatusBadRequest)
+			return
+		}
+	}
+	if req.TargetSeconds != nil && (*req.TargetSeconds <= 0 || *req.TargetSeconds > 600) {
+		http.Error(w, "El descanso objetivo debe estar entre 1 y 600 segundos", http.StatusBadRequest)
+		return
+	}
+
+	// Sin workout_id explícito se vincula a la última serie del usuario
+	workoutID := req.WorkoutID
+	if workoutID == nil {
+		var lastID int
+		err := database.DB.QueryRow(`
+			SELECT id FROM workouts WHERE user_id = $1 ORDER BY created_at DESC LIMIT 1
+		`, userID).Scan(&lastID)
+		if err != nil && err != sql.ErrNoRows {
+			http.Error(w, "Error consultando última serie", http.StatusInternalServerError)
+			return
+		}
+		if err == nil {
+			workoutID = &lastID
+		}
+	} else {
+		var exists bool
+		err := database.DB.QueryRow(`
+			SELECT EXISTS(SELECT 1 FROM workouts WHERE id = $1 AND user_id = $2)
+		`, *workoutID, userID).Scan(&exists)
+		if err != nil {
+			http.Error(w, "Error verificando serie", http.StatusInternalServerError)
+			return
+		}
+		if !exists {
+			http.Error(w, "Serie no encontrada", http.StatusBadRequest)
+			return
+		}
+	}
+
+	updateTimer(w, userID, func(timer *models.RestTimer, now time.Time) error {
+		if req.TargetSeconds != nil {
+			timer.TargetSeconds = *req.TargetSeconds
+		}
+		timer.LastWorkoutID = workoutID
+		return applyTimerAction(timer, timerStart, now)
+	})
+}
+
+// PauseTimerHandler pausa el cronómetro
+func PauseTimerHandler(w http.ResponseWriter, r *http.Request) {
+	timerActionHandler(w, r, timerPause)
+}
+
+// ResumeTimerHandler reanuda el cronómetro pausado
+func ResumeTimerHandler(w http.ResponseWriter, r *http.Request) {
+	timerActionHandler(w, r, timerResume)
+}
+
+// ResetTimerHandler vuelve el cronómetro a cero
+func ResetTimerHandler(w http.ResponseWriter, r *http.Request) {
+	timerActionHandler(w, r, timerReset)
+}
+
+// TimerEventsHandler envía el estado del cronómetro por Server-Sent Events
+// cada vez que cambia, para que todos los dispositivos del usuario lo vean igual
+func TimerEventsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.CurrentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming no soportado", http.StatusInternalServerError)
+		return
+	}
+
+	timer, err := loadTimer(userID)
+	if err != nil {
+		http.Error(w, "Error consultando cronómetro", http.StatusInternalServerError)
+		return
+	}
+
+	ch, unsubscribe := timers.subscribe(userID)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if err := writeTimerEvent(w, withTimerProgress(*timer, time.Now())); err != nil {
+		return
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(timerHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case timer := <-ch:
+			if err := writeTimerEvent(w, withTimerProgress(timer, time.Now())); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// timerActionHandler aplica una acción simple (sin cuerpo) al cronómetro
+func timerActionHandler(w http.ResponseWriter, r *http.Request, action string) {
+	userID, ok := middleware.CurrentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	updateTimer(w, userID, func(timer *models.RestTimer, now time.Time) error {
+		return applyTimerAction(timer, action, now)
+	})
+}
+
+// updateTimer carga el cronómetro, aplica el cambio, lo guarda y lo publica a los
+// clientes. Todo ocurre en una transacción con la fila bloqueada, para que dos
+// dispositivos que actúan a la vez no pisen uno el cambio del otro
+func updateTimer(w http.ResponseWriter, userID string, change func(*models.RestTimer, time.Time) error) {
+	w.Header().Set("Content-Type", "application/json")
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		http.Error(w, "Error consultando cronómetro", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	timer, err := lockTimer(tx, userID)
+	if err != nil {
+		http.Error(w, "Error consultando cronómetro", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	if err := change(timer, now); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	err = tx.QueryRow(`
+		UPDATE rest_timers SET
+			status = $2,
+			target_seconds = $3,
+			started_at = $4,
+			accumulated_ms = $5,
+			last_workout_id = $6,
+			updated_at = $7
+		WHERE user_id = $1
+		RETURNING updated_at
+	`, userID, timer.Status, timer.TargetSeconds, timer.StartedAt, timer.AccumulatedMs, timer.LastWorkoutID, now).Scan(&timer.UpdatedAt)
+	if err != nil {
+		http.Error(w, "Error guardando cronómetro", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error guardando cronómetro", http.StatusInternalServerError)
+		return
+	}
+
+	result := withTimerProgress(*timer, now)
+	timers.publish(result)
+
+	json.NewEncoder(w).Encode(result)
+}
+
+// timerColumns es la lista de columnas que lee scanTimer
+const timerColumns = `status, target_seconds, started_at, accumulated_ms, last_workout_id, updated_at`
+
+func scanTimer(row rowScanner, timer *models.RestTimer) error {
+	return row.Scan(
+		&timer.Status,
+		&timer.TargetSeconds,
+		&timer.StartedAt,
+		&timer.AccumulatedMs,
+		&timer.LastWorkoutID,
+		&timer.UpdatedAt,
+	)
+}
+
+// loadTimer obtiene el cronómetro del usuario o uno nuevo en reposo
+func loadTimer(userID string) (*models.RestTimer, error) {
+	timer := models.RestTimer{
+		UserID:        userID,
+		Status:        models.TimerIdle,
+		TargetSeconds: models.DefaultRestSeconds,
+	}
+
+	err := scanTimer(database.DB.QueryRow(`
+		SELECT `+timerColumns+`
+		FROM rest_timers
+		WHERE user_id = $1
+	`, userID), &timer)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	return &timer, nil
+}
+
+// lockTimer obtiene el cronómetro del usuario bloqueando su fila hasta el fin de
+// la transacción. Si el usuario no tenía cronómetro lo crea en reposo, para que
+// también haya una fila que bloquear
+func lockTimer(tx *sql.Tx, userID string) (*models.RestTimer, error) {
+	_, err := tx.Exec(`
+		INSERT INTO rest_timers (user_id, status, target_seconds)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO NOTHING
+	`, userID, models.TimerIdle, models.DefaultRestSeconds)
+	if err != nil {
+		return nil, err
+	}
+
+	timer := models.RestTimer{UserID: userID}
+	err = scanTimer(tx.QueryRow(`
+		SELECT `+timerColumns+`
+		FROM rest_timers
+		WHERE user_id = $1
+		FOR UPDATE
+	`, userID), &timer)
+	if err != nil {
+		return nil, err
+	}
+	return &timer, nil
+}
+
+// applyTimerAction aplica una transición de estado al cronómetro
+func applyTimerAction(timer *models.RestTimer, action string, now time.Time) error {
+	switch action {
+	case timerStart:
+		timer.Status = models.TimerRunning
+		timer.StartedAt = &now
+		timer.AccumulatedMs = 0
+
+	case timerPause:
+		if timer.Status != models.TimerRunning || timer.StartedAt == nil {
+			return fmt.Errorf("El cronómetro no está corriendo")
+		}
+		timer.AccumulatedMs += now.Sub(*timer.StartedAt).Milliseconds()
+		timer.StartedAt = nil
+		timer.Status = models.TimerPaused
+
+	case timerResume:
+		if timer.Status != models.TimerPaused {
+			return fmt.Errorf("El cronómetro no está pausado")
+		}
+		timer.StartedAt = &now
+		timer.Status = models.TimerRunning
+
+	case timerReset:
+		timer.Status = models.TimerIdle
+		timer.StartedAt = nil
+		timer.AccumulatedMs = 0
+
+	default:
+		return fmt.Errorf("Acción de cronómetro desconocida: %s", action)
+	}
+	return nil
+}
+
+// withTimerProgress completa el tiempo transcurrido y restante a la hora indicada
+func withTimerProgress(timer models.RestTimer, now time.Time) models.RestTimer {
+	elapsedMs := timer.AccumulatedMs
+	if timer.Status == models.TimerRunning && timer.StartedAt != nil {
+		elapsedMs += now.Sub(*timer.StartedAt).Milliseconds()
+	}
+
+	timer.ElapsedSeconds = float64(elapsedMs) / 1000
+	timer.RemainingSeconds = float64(timer.TargetSeconds) - timer.ElapsedSeconds
+	if timer.RemainingSeconds < 0 {
+		timer.RemainingSeconds = 0
+	}
+	timer.ServerTime = now
+	return timer
+}
+
+// writeTimerEvent escribe un evento SSE "timer" con el estado en JSON
+func writeTimerEvent(w http.ResponseWriter, timer models.RestTimer) error {
+	data, err := json.Marshal(timer)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: timer\ndata: %s\n\n", data)
+	return err
+}
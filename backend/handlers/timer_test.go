@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/goalritmo/gym/backend/models"
+)
+
+func TestApplyTimerAction(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	timer := &models.RestTimer{UserID: "user", Status: models.TimerIdle, TargetSeconds: 45}
+
+	if err := applyTimerAction(timer, timerPause, start); err == nil {
+		t.Error("Pausar un cronómetro en reposo debería fallar")
+	}
+
+	if err := applyTimerAction(timer, timerStart, start); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyTimerAction(timer, timerPause, start.Add(10*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if timer.Status != models.TimerPaused || timer.AccumulatedMs != 10000 {
+		t.Errorf("Expected paused with 10000ms, got %s with %dms", timer.Status, timer.AccumulatedMs)
+	}
+
+	// El tiempo en pausa no cuenta
+	if err := applyTimerAction(timer, timerResume, start.Add(60*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	progress := withTimerProgress(*timer, start.Add(65*time.Second))
+	if progress.ElapsedSeconds != 15 || progress.RemainingSeconds != 30 {
+		t.Errorf("Expected 15s elapsed and 30s remaining, got %v and %v",
+			progress.ElapsedSeconds, progress.RemainingSeconds)
+	}
+
+	// El restante nunca es negativo
+	progress = withTimerProgress(*timer, start.Add(10*time.Minute))
+	if progress.RemainingSeconds != 0 {
+		t.Errorf("Expected 0 remaining, got %v", progress.RemainingSeconds)
+	}
+
+	if err := applyTimerAction(timer, timerReset, start); err != nil {
+		t.Fatal(err)
+	}
+	if timer.Status != models.TimerIdle || timer.StartedAt != nil || timer.AccumulatedMs != 0 {
+		t.Errorf("Expected idle timer after reset, got %+v", timer)
+	}
+}
+
+func TestTimerHubPublish(t *testing.T) {
+	hub := &timerHub{subscribers: make(map[string]map[chan models.RestTimer]struct{})}
+	ch, unsubscribe := hub.subscribe("user")
+
+	// Solo se conserva el último estado si el cliente no consumió el anterior
+	hub.publish(models.RestTimer{UserID: "user", Status: models.TimerRunning})
+	hub.publish(models.RestTimer{UserID: "user", Status: models.TimerPaused})
+	hub.publish(models.RestTimer{UserID: "other", Status: models.TimerIdle})
+
+	if got := <-ch; got.Status != models.TimerPaused {
+		t.Errorf("Expected paused, got %s", got.Status)
+	}
+
+	unsubscribe()
+	if len(hub.subscribers) != 0 {
+		t.Errorf("Expected no subscribers after unsubscribe, got %d", len(hub.subscribers))
+	}
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/goalritmo/gym/backend/database"
+	"github.com/goalritmo/gym/backend/models"
+	"github.com/gorilla/mux"
+)
+
+// SetExerciseTranslationHandler guarda la traducción de un ejercicio (solo administradores)
+func SetExerciseTranslationHandler(w http.ResponseWriter, r *http.Request) {
+	setTranslation(w, r, models.TranslationExercise)
+}
+
+// SetEquipmentTranslationHandler guarda la traducción de un equipo (solo administradores)
+func SetEquipmentTranslationHandler(w http.ResponseWriter, r *http.Request) {
+	setTranslation(w, r, models.TranslationEquipment)
+}
+
+// SetMuscleGroupTranslationHandler guarda la traducción de un grupo muscular (solo administradores)
+func SetMuscleGroupTranslationHandler(w http.ResponseWriter, r *http.Request) {
+	setTranslation(w, r, models.TranslationMuscleGroup)
+}
+
+// setTranslation reemplaza la traducción de una entrada a {locale}: los campos
+// con texto se guardan y los vacíos o ausentes se eliminan
+func setTranslation(w http.ResponseWriter, r *http.Request, entityType string) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+	locale := vars["locale"]
+
+	var req models.TranslationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+	fields, err := validateTranslationRequest(entityType, locale, &req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var exists bool
+	err = database.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM `+translationTables[entityType]+` WHERE id = $1)`, id).Scan(&exists)
+	if err != nil {
+		http.Error(w, "Error consultando entrada del catálogo", http.StatusInternalServerError)
+		return
+	}
+	if !exists {
+		http.Error(w, "Entrada del catálogo no encontrada", http.StatusNotFound)
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		http.Error(w, "Error guardando traducción", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	for field, value := range fields {
+		if value == nil {
+			_, err = tx.Exec(`
+				DELETE FROM catalog_translations
+				WHERE entity_type = $1 AND entity_id = $2 AND locale = $3 AND field = $4
+			`, entityType, id, locale, field)
+		} else {
+			_, err = tx.Exec(`
+				INSERT INTO catalog_translations (entity_type, entity_id, locale, field, value)
+				VALUES ($1, $2, $3, $4, $5)
+				ON CONFLICT (entity_type, entity_id, locale, field)
+				DO UPDATE SET value = EXCLUDED.value, updated_at = NOW()
+			`, entityType, id, locale, field, *value)
+		}
+		if err != nil {
+			http.Error(w, "Error guardando traducción", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error guardando traducción", http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(models.Translation{
+		EntityType:   entityType,
+		EntityID:     id,
+		Locale:       locale,
+		Name:         fields[models.TranslationFieldName],
+		Observations: fields[models.TranslationFieldObservations],
+	})
+}
+
+// validateTranslationRequest valida el idioma y los campos de la traducción y
+// devuelve el valor de cada campo traducible (nil para eliminarlo). Solo los
+// equipos tienen observaciones
+func validateTranslationRequest(entityType, locale string, req *models.TranslationRequest) (map[string]*string, error) {
+	if !containsString(models.Locales, locale) {
+		return nil, fmt.Errorf("Idioma no soportado (%s)", strings.Join(models.Locales, ", "))
+	}
+	if locale == models.DefaultLocale {
+		return nil, fmt.Errorf("Los textos en el idioma por defecto (%s) se editan en el catálogo", models.DefaultLocale)
+	}
+	if req.Observations != nil && entityType != models.TranslationEquipment {
+		return nil, fmt.Errorf("Solo los equipos tienen observaciones")
+	}
+
+	fields := map[string]*string{models.TranslationFieldName: nil}
+	if entityType == models.TranslationEquipment {
+		fields[models.TranslationFieldObservations] = nil
+	}
+
+	for field, value := range map[string]*string{
+		models.TranslationFieldName:         req.Name,
+		models.TranslationFieldObservations: req.Observations,
+	} {
+		if value == nil {
+			continue
+		}
+		trimmed := strings.TrimSpace(*value)
+		if len(trimmed) > 1000 {
+			return nil, fmt.Errorf("%s no puede superar los 1000 caracteres", field)
+		}
+		if trimmed != "" {
+			fields[field] = &trimmed
+		}
+	}
+
+	return fields, nil
+}
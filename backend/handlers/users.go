@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/goalritmo/gym/backend/database"
+	"github.com/goalritmo/gym/backend/middleware"
 )
 
 // SupabaseUser representa información básica del usuario de Supabase Auth
@@ -12,16 +13,16 @@ type SupabaseUser struct {
 	ID       string                 `json:"id"`
 	Email    *string                `json:"email"`
 	Metadata map[string]interface{} `json:"user_metadata"`
-	Role     string                 `json:"role"`
+	// Role es el rol de la aplicación (user, coach o admin, ver middleware.Roles)
+	Role string `json:"role"`
 }
 
 // GetCurrentUserHandler obtiene el usuario actual desde Supabase Auth
 func GetCurrentUserHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	userID, ok := r.Context().Value("user_id").(string)
-	if !ok || userID == "" {
-		http.Error(w, "Unauthorized: user_id not found in context", http.StatusUnauthorized)
+	principal, ok := middleware.CurrentPrincipal(w, r)
+	if !ok {
 		return
 	}
 
@@ -30,8 +31,7 @@ func GetCurrentUserHandler(w http.ResponseWriter, r *http.Request) {
 		SELECT 
 			id,
 			email,
-			COALESCE(raw_user_meta_data, '{}')::jsonb as user_metadata,
-			role
+			COALESCE(raw_user_meta_data, '{}')::jsonb as user_metadata
 		FROM auth.users
 		WHERE id = $1
 	`
@@ -39,11 +39,10 @@ func GetCurrentUserHandler(w http.ResponseWriter, r *http.Request) {
 	var user SupabaseUser
 	var userMetadataJSON []byte
 
-	err := database.DB.QueryRow(query, userID).Scan(
+	err := database.DB.QueryRow(query, principal.UserID).Scan(
 		&user.ID,
 		&user.Email,
 		&userMetadataJSON,
-		&user.Role,
 	)
 
 	if err != nil {
@@ -51,6 +50,9 @@ func GetCurrentUserHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// El rol es el mismo con el que se autorizan las requests
+	user.Role = principal.Role
+
 	// Parsear metadata JSON
 	if len(userMetadataJSON) > 0 {
 		json.Unmarshal(userMetadataJSON, &user.Metadata)
@@ -63,9 +65,8 @@ func GetCurrentUserHandler(w http.ResponseWriter, r *http.Request) {
 func GetUserStatsHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	userID, ok := r.Context().Value("user_id").(string)
-	if !ok || userID == "" {
-		http.Error(w, "Unauthorized: user_id not found in context", http.StatusUnauthorized)
+	userID, ok := middleware.CurrentUserID(w, r)
+	if !ok {
 		return
 	}
 
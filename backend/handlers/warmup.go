@@ -0,0 +1,276 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/goalritmo/gym/backend/database"
+	"github.com/goalritmo/gym/backend/middleware"
+	"github.com/goalritmo/gym/backend/models"
+	"github.com/gorilla/mux"
+)
+
+// defaultWarmupSteps es el esquema de calentamiento usado si el usuario no configuró uno
+var defaultWarmupSteps = []models.WarmupStep{
+	{Percent: 0.4, Reps: 5},
+	{Percent: 0.6, Reps: 3},
+	{Percent: 0.8, Reps: 2},
+}
+
+// GetWarmupHandler genera la rampa de calentamiento para un peso de trabajo
+func GetWarmupHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	exerciseID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := middleware.CurrentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	workingWeight, err := strconv.ParseFloat(r.URL.Query().Get("working_weight"), 64)
+	if err != nil || workingWeight <= 0 {
+		http.Error(w, "El parámetro working_weight debe ser un peso mayor a 0", http.StatusBadRequest)
+		return
+	}
+
+	plan, status, err := warmupPlan(userID, exerciseID, workingWeight)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	json.NewEncoder(w).Encode(plan)
+}
+
+// CreateWarmupSetsHandler genera el calentamiento y lo registra en la sesión de hoy
+func CreateWarmupSetsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	exerciseID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := middleware.CurrentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.CreateWarmupSetsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+	if req.WorkingWeight <= 0 {
+		http.Error(w, "El peso de trabajo debe ser mayor a 0", http.StatusBadRequest)
+		return
+	}
+
+	plan, status, err := warmupPlan(userID, exerciseID, req.WorkingWeight)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	if len(plan.Sets) == 0 {
+		http.Error(w, "El peso de trabajo es demasiado bajo para generar calentamiento", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := getOrCreateTodaySession(userID); err != nil {
+		http.Error(w, "Error creando sesión de entrenamiento", http.StatusInternalServerError)
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		http.Error(w, "Error iniciando registro de calentamiento", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	// Todas las series de calentamiento comparten el mismo exercise_session_id
+	var sessionUUID string
+	if err := tx.QueryRow(`SELECT gen_random_uuid()`).Scan(&sessionUUID); err != nil {
+		http.Error(w, "Error generando identificador único", http.StatusInternalServerError)
+		return
+	}
+
+	workouts := []models.Workout{}
+	for i, set := range plan.Sets {
+		serie := i + 1
+		workout := models.Workout{
+			UserID:     userID,
+			ExerciseID: exerciseID,
+			Weight:     set.Weight,
+			Reps:       set.Reps,
+			Serie:      &serie,
+			IsWarmup:   true,
+		}
+		err := tx.QueryRow(`
+			INSERT INTO workouts (user_id, exercise_id, weight, reps, serie, is_warmup, exercise_session_id)
+			VALUES ($1, $2, $3, $4, $5, TRUE, $6)
+			RETURNING id, exercise_session_id, created_at
+		`, userID, exerciseID, set.Weight, set.Reps, serie, sessionUUID).Scan(
+			&workout.ID, &workout.ExerciseSessionID, &workout.CreatedAt,
+		)
+		if err != nil {
+			http.Error(w, "Error registrando serie de calentamiento", http.StatusInternalServerError)
+			return
+		}
+		workouts = append(workouts, workout)
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error confirmando calentamiento", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(workouts)
+}
+
+// GetWarmupSettingsHandler obtiene el esquema de calentamiento del usuario actual
+func GetWarmupSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, ok := middleware.CurrentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	settings, err := loadWarmupSettings(userID)
+	if err != nil {
+		http.Error(w, "Error consultando configuración de calentamiento", http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(settings)
+}
+
+// UpdateWarmupSettingsHandler reemplaza el esquema de calentamiento del usuario actual
+func UpdateWarmupSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, ok := middleware.CurrentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateWarmupSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+
+	if err := validateWarmupSteps(req.Steps); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	stepsJSON, err := json.Marshal(req.Steps)
+	if err != nil {
+		http.Error(w, "Error serializando calentamiento", http.StatusInternalServerError)
+		return
+	}
+
+	settings := models.WarmupSettings{UserID: userID, Steps: req.Steps}
+	err = database.DB.QueryRow(`
+		INSERT INTO user_warmup_settings (user_id, steps, updated_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (user_id) DO UPDATE SET steps = EXCLUDED.steps, updated_at = NOW()
+		RETURNING updated_at
+	`, userID, stepsJSON).Scan(&settings.UpdatedAt)
+	if err != nil {
+		http.Error(w, "Error guardando configuración de calentamiento", http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(settings)
+}
+
+// warmupPlan arma la rampa de calentamiento del usuario para un ejercicio.
+// En caso de error devuelve también el status HTTP correspondiente
+func warmupPlan(userID string, exerciseID int, workingWeight float64) (*models.WarmupPlan, int, error) {
+	equipment, err := exerciseEquipment(exerciseID, userID)
+	if err == sql.ErrNoRows {
+		return nil, http.StatusNotFound, fmt.Errorf("Ejercicio no encontrado")
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("Error consultando ejercicio")
+	}
+
+	settings, err := loadWarmupSettings(userID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("Error consultando configuración de calentamiento")
+	}
+
+	return &models.WarmupPlan{
+		ExerciseID:    exerciseID,
+		WorkingWeight: workingWeight,
+		Sets:          buildWarmupSets(settings.Steps, workingWeight, equipment),
+	}, http.StatusOK, nil
+}
+
+// loadWarmupSettings obtiene el esquema del usuario o el esquema por defecto
+func loadWarmupSettings(userID string) (*models.WarmupSettings, error) {
+	settings := models.WarmupSettings{UserID: userID}
+	var stepsJSON []byte
+	err := database.DB.QueryRow(`
+		SELECT steps, updated_at FROM user_warmup_settings WHERE user_id = $1
+	`, userID).Scan(&stepsJSON, &settings.UpdatedAt)
+	if err == sql.ErrNoRows {
+		settings.Steps = defaultWarmupSteps
+		return &settings, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(stepsJSON, &settings.Steps); err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// validateWarmupSteps verifica que el esquema tenga entre 1 y 10 escalones válidos
+func validateWarmupSteps(steps []models.WarmupStep) error {
+	if len(steps) == 0 || len(steps) > 10 {
+		return fmt.Errorf("El calentamiento debe tener entre 1 y 10 series")
+	}
+	for _, step := range steps {
+		if step.Percent <= 0 || step.Percent >= 1 {
+			return fmt.Errorf("Cada porcentaje debe estar entre 0 y 1 (exclusivo)")
+		}
+		if step.Reps <= 0 {
+			return fmt.Errorf("Las repeticiones deben ser mayores a 0")
+		}
+	}
+	return nil
+}
+
+// buildWarmupSets calcula las series de calentamiento redondeadas a lo que permite
+// el equipo, descartando las que no quedan por debajo del peso de trabajo o repiten peso
+func buildWarmupSets(steps []models.WarmupStep, workingWeight float64, equipment *models.Equipment) []models.WarmupSet {
+	sets := []models.WarmupSet{}
+	for _, step := range steps {
+		weight := roundLoad(equipment, workingWeight*step.Percent)
+		if weight <= 0 || weight >= workingWeight {
+			continue
+		}
+		if len(sets) > 0 && weight <= sets[len(sets)-1].Weight {
+			continue
+		}
+		sets = append(sets, models.WarmupSet{Percent: step.Percent, Weight: weight, Reps: step.Reps})
+	}
+	return sets
+}
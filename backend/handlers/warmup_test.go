@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/goalritmo/gym/backend/models"
+)
+
+func TestBuildWarmupSets(t *testing.T) {
+	plates := models.LoadTypePlates
+	bar := 20.0
+	barbell := &models.Equipment{LoadType: &plates, BarWeight: &bar, Plates: []float64{20, 10, 5, 2.5, 1.25}}
+
+	sets := buildWarmupSets(defaultWarmupSteps, 100, barbell)
+	want := []float64{40, 60, 80}
+	if len(sets) != len(want) {
+		t.Fatalf("Expected %d sets, got %+v", len(want), sets)
+	}
+	for i, set := range sets {
+		if set.Weight != want[i] {
+			t.Errorf("Set %d: expected %v, got %v", i, want[i], set.Weight)
+		}
+	}
+
+	// Con un peso de trabajo bajo, los escalones que quedan en la barra vacía se fusionan
+	sets = buildWarmupSets(defaultWarmupSteps, 30, barbell)
+	if len(sets) != 2 || sets[0].Weight != 20 || sets[1].Weight != 25 {
+		t.Errorf("Expected the empty bar and 25 kg, got %+v", sets)
+	}
+}
+
+func TestValidateWarmupSteps(t *testing.T) {
+	if err := validateWarmupSteps(defaultWarmupSteps); err != nil {
+		t.Errorf("Default steps should be valid: %v", err)
+	}
+	if err := validateWarmupSteps(nil); err == nil {
+		t.Error("Empty steps should be invalid")
+	}
+	if err := validateWarmupSteps([]models.WarmupStep{{Percent: 1.2, Reps: 3}}); err == nil {
+		t.Error("Percent above 1 should be invalid")
+	}
+}
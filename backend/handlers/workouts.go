@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"database/sql"
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
@@ -10,6 +12,7 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/goalritmo/gym/backend/database"
+	"github.com/goalritmo/gym/backend/middleware"
 	"github.com/goalritmo/gym/backend/models"
 )
 
@@ -17,9 +20,8 @@ import (
 func GetWorkoutsHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	userID, ok := r.Context().Value("user_id").(string)
-	if !ok || userID == "" {
-		http.Error(w, "Unauthorized: user_id not found in context", http.StatusUnauthorized)
+	userID, ok := middleware.CurrentUserID(w, r)
+	if !ok {
 		return
 	}
 
@@ -30,7 +32,7 @@ func GetWorkoutsHandler(w http.ResponseWriter, r *http.Request) {
 	query := `
 		SELECT w.id, w.user_id, w.exercise_id, e.name as exercise_name, 
 			   w.weight, w.reps, w.serie, w.seconds, w.observations, 
-			   w.exercise_session_id, w.created_at
+			   w.rpe, w.is_warmup, w.exercise_session_id, w.created_at
 		FROM workouts w
 		JOIN exercises e ON w.exercise_id = e.id
 		WHERE w.user_id = $1
@@ -72,6 +74,8 @@ func GetWorkoutsHandler(w http.ResponseWriter, r *http.Request) {
 			&workout.Serie,
 			&workout.Seconds,
 			&workout.Observations,
+			&workout.RPE,
+			&workout.IsWarmup,
 			&workout.ExerciseSessionID,
 			&workout.CreatedAt,
 		)
@@ -89,9 +93,8 @@ func GetWorkoutsHandler(w http.ResponseWriter, r *http.Request) {
 func CreateWorkoutHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	userID, ok := r.Context().Value("user_id").(string)
-	if !ok || userID == "" {
-		http.Error(w, "Unauthorized: user_id not found in context", http.StatusUnauthorized)
+	userID, ok := middleware.CurrentUserID(w, r)
+	if !ok {
 		return
 	}
 
@@ -101,57 +104,39 @@ func CreateWorkoutHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-
-
-	// Validaciones básicas
-	if req.Weight <= 0 {
-		http.Error(w, "El peso debe ser mayor a 0", http.StatusBadRequest)
+	// Validaciones básicas (qué valores son obligatorios depende del modo de medición)
+	if req.Weight < 0 {
+		http.Error(w, "El peso no puede ser negativo", http.StatusBadRequest)
+		return
+	}
+	if req.Reps < 0 {
+		http.Error(w, "Las repeticiones no pueden ser negativas", http.StatusBadRequest)
 		return
 	}
-	if req.Reps <= 0 {
-		http.Error(w, "Las repeticiones deben ser mayores a 0", http.StatusBadRequest)
+	if req.RPE != nil && (*req.RPE < 1 || *req.RPE > 10) {
+		http.Error(w, "El RPE debe estar entre 1 y 10", http.StatusBadRequest)
 		return
 	}
 
-	// Verificar que el ejercicio existe
-	var exerciseExists bool
-	var err error
-	err = database.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM exercises WHERE id = $1)", req.ExerciseID).Scan(&exerciseExists)
+	// Verificar que el ejercicio existe y es del catálogo o del usuario
+	mode, err := exerciseMeasurementMode(req.ExerciseID, userID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Ejercicio no encontrado", http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Error verificando ejercicio", http.StatusInternalServerError)
 		return
 	}
-	if !exerciseExists {
-		http.Error(w, "Ejercicio no encontrado", http.StatusBadRequest)
+	if err := validateWorkoutMeasurement(mode, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	// Buscar o crear workout_session para hoy
-	today := time.Now().Format("2006-01-02")
-	var sessionID int
-	
-	// Verificar si ya existe una sesión para hoy
-	sessionQuery := `SELECT id FROM workout_sessions WHERE user_id = $1 AND DATE(session_date) = $2 LIMIT 1`
-	err = database.DB.QueryRow(sessionQuery, userID, today).Scan(&sessionID)
-	
-	if err != nil {
-		// No existe sesión para hoy, crear una nueva
-		fmt.Printf("🔄 Creando nueva sesión para usuario %s, fecha %s\n", userID, today)
-		createSessionQuery := `
-			INSERT INTO workout_sessions (user_id, session_date, session_name, total_exercises, effort, mood) 
-			VALUES ($1, $2, $3, 0, 0, 0) 
-			RETURNING id
-		`
-		sessionName := "Entrenamiento del día"
-		err = database.DB.QueryRow(createSessionQuery, userID, today, sessionName).Scan(&sessionID)
-		if err != nil {
-			fmt.Printf("❌ Error creando sesión: %v\n", err)
-			http.Error(w, "Error creando sesión de entrenamiento", http.StatusInternalServerError)
-			return
-		}
-		fmt.Printf("✅ Sesión creada con ID: %d\n", sessionID)
-	} else {
-		fmt.Printf("✅ Sesión existente encontrada con ID: %d\n", sessionID)
+	if _, err = getOrCreateTodaySession(userID); err != nil {
+		http.Error(w, "Error creando sesión de entrenamiento", http.StatusInternalServerError)
+		return
 	}
 
 	// Generar un UUID único para este workout
@@ -168,8 +153,8 @@ func CreateWorkoutHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Insertar workout asociado a la sesión
 	query := `
-		INSERT INTO workouts (user_id, exercise_id, weight, reps, serie, seconds, observations, exercise_session_id)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO workouts (user_id, exercise_id, weight, reps, serie, seconds, observations, rpe, is_warmup, exercise_session_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		RETURNING id, exercise_session_id, created_at
 	`
 
@@ -181,6 +166,8 @@ func CreateWorkoutHandler(w http.ResponseWriter, r *http.Request) {
 	workout.Serie = req.Serie
 	workout.Seconds = req.Seconds
 	workout.Observations = req.Observations
+	workout.RPE = req.RPE
+	workout.IsWarmup = req.IsWarmup
 
 	// Obtener valores de los punteros de forma segura
 	var serieValue, secondsValue int
@@ -196,7 +183,7 @@ func CreateWorkoutHandler(w http.ResponseWriter, r *http.Request) {
 	err = database.DB.QueryRow(
 		query,
 		userID, req.ExerciseID, req.Weight, req.Reps,
-		serieValue, secondsValue, req.Observations, sessionUUID,
+		serieValue, secondsValue, req.Observations, req.RPE, req.IsWarmup, sessionUUID,
 	).Scan(&workout.ID, &workout.ExerciseSessionID, &workout.CreatedAt)
 
 	if err != nil {
@@ -223,9 +210,8 @@ func UpdateWorkoutHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID, ok := r.Context().Value("user_id").(string)
-	if !ok || userID == "" {
-		http.Error(w, "Unauthorized: user_id not found in context", http.StatusUnauthorized)
+	userID, ok := middleware.CurrentUserID(w, r)
+	if !ok {
 		return
 	}
 
@@ -235,28 +221,53 @@ func UpdateWorkoutHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Validaciones
-	if req.Weight <= 0 || req.Reps <= 0 {
-		http.Error(w, "Peso y repeticiones deben ser mayores a 0", http.StatusBadRequest)
+	// Validaciones (qué valores son obligatorios depende del modo de medición)
+	if req.Weight < 0 {
+		http.Error(w, "El peso no puede ser negativo", http.StatusBadRequest)
+		return
+	}
+	if req.Reps < 0 {
+		http.Error(w, "Las repeticiones no pueden ser negativas", http.StatusBadRequest)
+		return
+	}
+	if req.RPE != nil && (*req.RPE < 1 || *req.RPE > 10) {
+		http.Error(w, "El RPE debe estar entre 1 y 10", http.StatusBadRequest)
+		return
+	}
+
+	// El modo de medición depende del ejercicio de la serie
+	var mode string
+	err = database.DB.QueryRow(`
+		SELECT e.measurement_mode
+		FROM workouts w
+		JOIN exercises e ON w.exercise_id = e.id
+		WHERE w.id = $1 AND w.user_id = $2
+	`, id, userID).Scan(&mode)
+	if err != nil {
+		http.Error(w, "Workout no encontrado o error actualizando", http.StatusNotFound)
+		return
+	}
+	if err := validateWorkoutMeasurement(mode, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	query := `
 		UPDATE workouts 
-		SET weight = $1, reps = $2, serie = $3, seconds = $4, observations = $5
-		WHERE id = $6 AND user_id = $7
-		RETURNING id, exercise_id, weight, reps, serie, seconds, observations, exercise_session_id, created_at
+		SET weight = $1, reps = $2, serie = $3, seconds = $4, observations = $5, rpe = $6
+		WHERE id = $7 AND user_id = $8
+		RETURNING id, exercise_id, weight, reps, serie, seconds, observations, rpe, is_warmup, exercise_session_id, created_at
 	`
 
 	var workout models.Workout
 	err = database.DB.QueryRow(
 		query,
-		req.Weight, req.Reps, req.Serie, req.Seconds, req.Observations,
+		req.Weight, req.Reps, req.Serie, req.Seconds, req.Observations, req.RPE,
 		id, userID,
 	).Scan(
 		&workout.ID, &workout.ExerciseID, &workout.Weight, &workout.Reps,
-		&workout.Serie, &workout.Seconds, &workout.Observations,
-		&workout.ExerciseSessionID, &workout.CreatedAt,
+		&workout.Serie, &workout.Seconds, &workout.Observations, &workout.RPE,
+		&workout.IsWarmup, &workout.ExerciseSessionID, &workout.CreatedAt,
 	)
 
 	if err != nil {
@@ -277,9 +288,8 @@ func DeleteWorkoutHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID, ok := r.Context().Value("user_id").(string)
-	if !ok || userID == "" {
-		http.Error(w, "Unauthorized: user_id not found in context", http.StatusUnauthorized)
+	userID, ok := middleware.CurrentUserID(w, r)
+	if !ok {
 		return
 	}
 
@@ -302,9 +312,8 @@ func DeleteWorkoutHandler(w http.ResponseWriter, r *http.Request) {
 func GetWorkoutSessionsHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	userID, ok := r.Context().Value("user_id").(string)
-	if !ok || userID == "" {
-		http.Error(w, "Unauthorized: user_id not found in context", http.StatusUnauthorized)
+	userID, ok := middleware.CurrentUserID(w, r)
+	if !ok {
 		return
 	}
 
@@ -352,9 +361,8 @@ func GetWorkoutSessionsHandler(w http.ResponseWriter, r *http.Request) {
 func CreateWorkoutSessionHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	userID, ok := r.Context().Value("user_id").(string)
-	if !ok || userID == "" {
-		http.Error(w, "Unauthorized: user_id not found in context", http.StatusUnauthorized)
+	userID, ok := middleware.CurrentUserID(w, r)
+	if !ok {
 		return
 	}
 
@@ -406,9 +414,8 @@ func UpdateWorkoutSessionHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID, ok := r.Context().Value("user_id").(string)
-	if !ok || userID == "" {
-		http.Error(w, "Unauthorized: user_id not found in context", http.StatusUnauthorized)
+	userID, ok := middleware.CurrentUserID(w, r)
+	if !ok {
 		return
 	}
 
@@ -474,3 +481,35 @@ func UpdateWorkoutSessionHandler(w http.ResponseWriter, r *http.Request) {
 	session.UserID = userID
 	json.NewEncoder(w).Encode(session)
 }
+
+// getOrCreateTodaySession busca la sesión de entrenamiento de hoy del usuario o la crea
+func getOrCreateTodaySession(userID string) (int, error) {
+	today := time.Now().Format("2006-01-02")
+	var sessionID int
+
+	// Verificar si ya existe una sesión para hoy
+	sessionQuery := `SELECT id FROM workout_sessions WHERE user_id = $1 AND DATE(session_date) = $2 LIMIT 1`
+	err := database.DB.QueryRow(sessionQuery, userID, today).Scan(&sessionID)
+	if err == nil {
+		return sessionID, nil
+	}
+	if err != sql.ErrNoRows {
+		log.Printf("Error consultando la sesión de hoy de %s: %v", userID, err)
+		return 0, err
+	}
+
+	// No existe sesión para hoy, crear una nueva
+	createSessionQuery := `
+		INSERT INTO workout_sessions (user_id, session_date, session_name, total_exercises, effort, mood)
+		VALUES ($1, $2, $3, 0, 0, 0)
+		RETURNING id
+	`
+	sessionName := "Entrenamiento del día"
+	err = database.DB.QueryRow(createSessionQuery, userID, today, sessionName).Scan(&sessionID)
+	if err != nil {
+		log.Printf("Error creando la sesión de hoy de %s: %v", userID, err)
+		return 0, err
+	}
+
+	return sessionID, nil
+}
@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"bytes"
-	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -10,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/gorilla/mux"
+	"github.com/goalritmo/gym/backend/middleware"
 	"github.com/goalritmo/gym/backend/models"
 )
 
@@ -29,7 +29,7 @@ func mockRequest(method, url string, body interface{}) (*http.Request, error) {
 	}
 
 	// Simular autenticación agregando user_id al contexto
-	ctx := context.WithValue(req.Context(), "user_id", "test_user_id")
+	ctx := middleware.WithPrincipal(req.Context(), &middleware.Principal{UserID: "test_user_id", Role: middleware.RoleUser})
 	req = req.WithContext(ctx)
 	req.Header.Set("Content-Type", "application/json")
 
@@ -100,7 +100,7 @@ func TestCreateWorkoutHandler_InvalidReps(t *testing.T) {
 	workoutData := models.CreateWorkoutRequest{
 		ExerciseID: 1,
 		Weight:     80.5,
-		Reps:       0, // Reps inválidas
+		Reps:       -1, // Reps inválidas (0 es válido en los ejercicios por tiempo)
 	}
 
 	req, err := mockRequest("POST", "/api/workouts", workoutData)
@@ -129,7 +129,7 @@ func TestCreateWorkoutHandler_InvalidJSON(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	ctx := context.WithValue(req.Context(), "user_id", "test_user_id")
+	ctx := middleware.WithPrincipal(req.Context(), &middleware.Principal{UserID: "test_user_id", Role: middleware.RoleUser})
 	req = req.WithContext(ctx)
 	req.Header.Set("Content-Type", "application/json")
 
@@ -1,19 +1,78 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
 	"github.com/rs/cors"
 
+	"github.com/goalritmo/gym/backend/catalog"
 	"github.com/goalritmo/gym/backend/database"
 	"github.com/goalritmo/gym/backend/handlers"
 	"github.com/goalritmo/gym/backend/middleware"
+	"github.com/goalritmo/gym/backend/storage"
 )
 
+// routePermissions es el rol mínimo de cada ruta (ver middleware.RoutePermissions).
+// Las rutas que no figuran están abiertas a cualquier usuario autenticado
+var routePermissions = middleware.RoutePermissions{
+	// Administración del catálogo: ejercicios
+	"POST /api/exercises":                           middleware.RoleAdmin,
+	"PUT /api/exercises/{id}":                       middleware.RoleAdmin,
+	"DELETE /api/exercises/{id}":                    middleware.RoleAdmin,
+	"PUT /api/exercises/{id}/muscle-groups":         middleware.RoleAdmin,
+	"POST /api/exercises/{id}/promote":              middleware.RoleAdmin,
+	"PUT /api/exercises/{id}/translations/{locale}": middleware.RoleAdmin,
+	"POST /api/exercises/{id}/video":                middleware.RoleAdmin,
+	"PUT /api/exercises/{id}/instructions/{locale}": middleware.RoleAdmin,
+
+	// Equipos
+	"GET /api/equipment/reports":                    middleware.RoleAdmin,
+	"PUT /api/equipment/{id}/status":                middleware.RoleAdmin,
+	"POST /api/equipment":                           middleware.RoleAdmin,
+	"PUT /api/equipment/{id}":                       middleware.RoleAdmin,
+	"DELETE /api/equipment/{id}":                    middleware.RoleAdmin,
+	"PUT /api/equipment/{id}/translations/{locale}": middleware.RoleAdmin,
+	"POST /api/equipment/{id}/image":                middleware.RoleAdmin,
+
+	// Grupos musculares
+	"POST /api/muscle-groups":                           middleware.RoleAdmin,
+	"PUT /api/muscle-groups/{id}":                       middleware.RoleAdmin,
+	"DELETE /api/muscle-groups/{id}":                    middleware.RoleAdmin,
+	"PUT /api/muscle-groups/{id}/translations/{locale}": middleware.RoleAdmin,
+}
+
+// rateLimits es el presupuesto de requests por usuario (o por IP sin usuario) de
+// cada ruta (ver middleware.RateLimits). Las rutas que no figuran comparten el
+// presupuesto por defecto
+var rateLimits = middleware.RateLimits{
+	Default: middleware.RateLimit{Requests: 300, Per: time.Minute},
+	// Todas las requests de una IP, autenticadas o no: frena los intentos con
+	// credenciales inválidas. Es amplio porque un gimnasio comparte una IP
+	PerIP: middleware.RateLimit{Requests: 1200, Per: time.Minute},
+	Routes: map[string]middleware.RateLimit{
+		// Registrar series y sesiones hace varias consultas por llamada
+		"POST /api/workouts":         {Requests: 60, Per: time.Minute},
+		"POST /api/workout-sessions": {Requests: 30, Per: time.Minute},
+		"GET /api/search":            {Requests: 60, Per: time.Minute},
+
+		// Subida de archivos
+		"POST /api/exercises/{id}/video": {Requests: 10, Per: time.Minute},
+		"POST /api/equipment/{id}/image": {Requests: 10, Per: time.Minute},
+
+		// Credenciales
+		"POST /api/me/tokens":     {Requests: 10, Per: time.Hour},
+		"POST /api/me/logout-all": {Requests: 10, Per: time.Hour},
+	},
+}
+
 func main() {
 	// Cargar variables de entorno
 	if err := godotenv.Load(); err != nil {
@@ -28,15 +87,57 @@ func main() {
 
 	log.Println("Conexión con base de datos establecida")
 
+	// Subcomando de catálogo: go run main.go catalog import|export ...
+	if len(os.Args) > 1 && os.Args[1] == "catalog" {
+		if err := runCatalogCommand(os.Args[2:]); err != nil {
+			log.Fatalf("Error en catalog: %v", err)
+		}
+		return
+	}
+
+	// Inicializar almacenamiento de archivos subidos
+	if err := storage.Init(); err != nil {
+		log.Fatalf("Error inicializando almacenamiento: %v", err)
+	}
+
+	// Verificar la configuración de validación de JWT antes de aceptar requests
+	if _, err := middleware.LoadJWTConfig(); err != nil {
+		log.Fatalf("Configuración de JWT inválida: %v", err)
+	}
+
+	// Cargar las revocaciones de sesiones antes de aceptar requests: con la lista
+	// vacía se aceptarían tokens revocados hasta el primer refresco
+	if err := middleware.Revocations().Refresh(); err != nil {
+		log.Fatalf("Error cargando revocaciones de sesiones: %v", err)
+	}
+
+	// El modo de autenticación de desarrollo nunca se habilita en producción
+	devAuth, err := middleware.LoadDevAuth()
+	if err != nil {
+		log.Fatalf("Configuración de DEV_AUTH inválida: %v", err)
+	}
+	if devAuth != nil {
+		log.Printf("⚠️  DEV_AUTH habilitado: se aceptan tokens dev:<nombre> para %s", strings.Join(devAuth.Names(), ", "))
+	}
+
 	// Crear router
 	r := mux.NewRouter()
 
 	// Middleware
+	rateLimitStore := middleware.NewMemoryRateLimitStore()
 	r.Use(middleware.LoggingMiddleware)
+	r.Use(middleware.IPRateLimiter(rateLimits, rateLimitStore))
 	r.Use(middleware.SupabaseAuthMiddleware)
+	r.Use(middleware.RateLimiter(rateLimits, rateLimitStore))
+
+	// Archivos subidos con almacenamiento local (con S3 las URLs apuntan al bucket)
+	if local, ok := storage.Media.(*storage.Local); ok {
+		r.PathPrefix("/media/").Handler(http.StripPrefix("/media/", local)).Methods("GET", "HEAD")
+	}
 
 	// API routes
 	api := r.PathPrefix("/api").Subrouter()
+	api.Use(middleware.Authorize(routePermissions))
 
 	// Health check
 	api.HandleFunc("/health", handlers.HealthHandler).Methods("GET")
@@ -52,17 +153,86 @@ func main() {
 	api.HandleFunc("/workout-sessions", handlers.CreateWorkoutSessionHandler).Methods("POST")
 	api.HandleFunc("/workout-sessions/{id}", handlers.UpdateWorkoutSessionHandler).Methods("PUT")
 
+	// Rest timer endpoints (cronómetro sincronizado entre dispositivos)
+	api.HandleFunc("/timer", handlers.GetTimerHandler).Methods("GET")
+	api.HandleFunc("/timer/start", handlers.StartTimerHandler).Methods("POST")
+	api.HandleFunc("/timer/pause", handlers.PauseTimerHandler).Methods("POST")
+	api.HandleFunc("/timer/resume", handlers.ResumeTimerHandler).Methods("POST")
+	api.HandleFunc("/timer/reset", handlers.ResetTimerHandler).Methods("POST")
+	api.HandleFunc("/timer/events", handlers.TimerEventsHandler).Methods("GET")
+
+	// Catalog search (ejercicios y equipos)
+	api.HandleFunc("/search", handlers.SearchHandler).Methods("GET")
+
 	// Exercises endpoints
 	api.HandleFunc("/exercises", handlers.GetExercisesHandler).Methods("GET")
 	api.HandleFunc("/exercises/{id}", handlers.GetExerciseHandler).Methods("GET")
+	api.HandleFunc("/exercises/{id}/next-target", handlers.GetNextTargetHandler).Methods("GET")
+	api.HandleFunc("/exercises/{id}/warmup", handlers.GetWarmupHandler).Methods("GET")
+	api.HandleFunc("/exercises/{id}/warmup", handlers.CreateWarmupSetsHandler).Methods("POST")
+	api.HandleFunc("/exercises/{id}/alternatives", handlers.GetAlternativesHandler).Methods("GET")
+	api.HandleFunc("/exercises", handlers.CreateExerciseHandler).Methods("POST")
+	api.HandleFunc("/exercises/{id}", handlers.UpdateExerciseHandler).Methods("PUT")
+	api.HandleFunc("/exercises/{id}", handlers.DeleteExerciseHandler).Methods("DELETE")
+	api.HandleFunc("/exercises/{id}/muscle-groups", handlers.SetExerciseMuscleGroupsHandler).Methods("PUT")
+	api.HandleFunc("/exercises/{id}/promote", handlers.PromoteExerciseHandler).Methods("POST")
+	api.HandleFunc("/exercises/{id}/translations/{locale}", handlers.SetExerciseTranslationHandler).Methods("PUT")
+	api.HandleFunc("/exercises/{id}/video", handlers.UploadExerciseVideoHandler).Methods("POST")
+	api.HandleFunc("/exercises/{id}/instructions/{locale}", handlers.SetExerciseInstructionsHandler).Methods("PUT")
 
 	// Equipment endpoints
 	api.HandleFunc("/equipment", handlers.GetEquipmentHandler).Methods("GET")
+	// /equipment/reports va antes de /equipment/{id} para que no lo capture la variable
+	api.HandleFunc("/equipment/reports", handlers.GetEquipmentReportsHandler).Methods("GET")
 	api.HandleFunc("/equipment/{id}", handlers.GetEquipmentByIdHandler).Methods("GET")
+	api.HandleFunc("/equipment/{id}/load", handlers.GetEquipmentLoadHandler).Methods("GET")
+	api.HandleFunc("/equipment/{id}/exercises", handlers.GetEquipmentExercisesHandler).Methods("GET")
+	api.HandleFunc("/equipment/{id}/status-history", handlers.GetEquipmentStatusHistoryHandler).Methods("GET")
+	api.HandleFunc("/equipment/{id}/reports", handlers.CreateEquipmentReportHandler).Methods("POST")
+	api.HandleFunc("/equipment/{id}/status", handlers.UpdateEquipmentStatusHandler).Methods("PUT")
+	api.HandleFunc("/equipment", handlers.CreateEquipmentHandler).Methods("POST")
+	api.HandleFunc("/equipment/{id}", handlers.UpdateEquipmentHandler).Methods("PUT")
+	api.HandleFunc("/equipment/{id}", handlers.DeleteEquipmentHandler).Methods("DELETE")
+	api.HandleFunc("/equipment/{id}/translations/{locale}", handlers.SetEquipmentTranslationHandler).Methods("PUT")
+	api.HandleFunc("/equipment/{id}/image", handlers.UploadEquipmentImageHandler).Methods("POST")
+
+	// Muscle groups endpoints
+	api.HandleFunc("/muscle-groups", handlers.GetMuscleGroupsHandler).Methods("GET")
+	api.HandleFunc("/muscle-groups/{id}/exercises", handlers.GetMuscleGroupExercisesHandler).Methods("GET")
+	api.HandleFunc("/muscle-groups", handlers.CreateMuscleGroupHandler).Methods("POST")
+	api.HandleFunc("/muscle-groups/{id}", handlers.UpdateMuscleGroupHandler).Methods("PUT")
+	api.HandleFunc("/muscle-groups/{id}", handlers.DeleteMuscleGroupHandler).Methods("DELETE")
+	api.HandleFunc("/muscle-groups/{id}/translations/{locale}", handlers.SetMuscleGroupTranslationHandler).Methods("PUT")
+
+	// Programs endpoints
+	api.HandleFunc("/programs", handlers.GetProgramsHandler).Methods("GET")
+	api.HandleFunc("/programs/{id}", handlers.GetProgramHandler).Methods("GET")
+	api.HandleFunc("/programs/{id}/enroll", handlers.EnrollProgramHandler).Methods("POST")
 
 	// Users endpoints (usando Supabase Auth)
 	api.HandleFunc("/me", handlers.GetCurrentUserHandler).Methods("GET")
 	api.HandleFunc("/me/stats", handlers.GetUserStatsHandler).Methods("GET")
+	api.HandleFunc("/me/today", handlers.GetTodayHandler).Methods("GET")
+	api.HandleFunc("/me/warmup-settings", handlers.GetWarmupSettingsHandler).Methods("GET")
+	api.HandleFunc("/me/warmup-settings", handlers.UpdateWarmupSettingsHandler).Methods("PUT")
+	api.HandleFunc("/me/exercises", handlers.GetMyExercisesHandler).Methods("GET")
+	api.HandleFunc("/me/exercises", handlers.CreateCustomExerciseHandler).Methods("POST")
+	api.HandleFunc("/me/exercises/{id}", handlers.UpdateCustomExerciseHandler).Methods("PUT")
+	api.HandleFunc("/me/exercises/{id}", handlers.DeleteCustomExerciseHandler).Methods("DELETE")
+	api.HandleFunc("/me/tokens", handlers.GetAccessTokensHandler).Methods("GET")
+	api.HandleFunc("/me/tokens", handlers.CreateAccessTokenHandler).Methods("POST")
+	api.HandleFunc("/me/tokens/{id}", handlers.RevokeAccessTokenHandler).Methods("DELETE")
+	api.HandleFunc("/me/logout", handlers.LogoutHandler).Methods("POST")
+	api.HandleFunc("/me/logout-all", handlers.LogoutAllHandler).Methods("POST")
+
+	// Una entrada de routePermissions que no coincide con ninguna ruta dejaría la
+	// ruta real sin proteger
+	if err := routePermissions.Validate(r); err != nil {
+		log.Fatalf("Permisos de rutas inválidos: %v", err)
+	}
+	if err := rateLimits.Validate(r); err != nil {
+		log.Fatalf("Límites de requests inválidos: %v", err)
+	}
 
 	// Configurar CORS
 	c := cors.New(cors.Options{
@@ -73,6 +243,7 @@ func main() {
 		},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"*"},
+		ExposedHeaders:   []string{"X-Total-Count", "Content-Language", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy", "Retry-After"},
 		AllowCredentials: true,
 	})
 
@@ -86,3 +257,60 @@ func main() {
 	log.Printf("Servidor iniciado en puerto %s", port)
 	log.Fatal(http.ListenAndServe(":"+port, handler))
 }
+
+// runCatalogCommand ejecuta los subcomandos de catálogo:
+//
+//	catalog import [-dry-run] [-v] <archivo.yaml|archivo.json>
+//	catalog export <archivo.yaml|archivo.json|->
+func runCatalogCommand(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("uso: catalog import [-dry-run] [-v] <archivo> | catalog export <archivo|->")
+	}
+
+	switch args[0] {
+	case "import":
+		flags := flag.NewFlagSet("catalog import", flag.ContinueOnError)
+		dryRun := flags.Bool("dry-run", false, "mostrar los cambios sin aplicarlos")
+		verbose := flags.Bool("v", false, "listar también las entradas sin cambios")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		if flags.NArg() != 1 {
+			return fmt.Errorf("uso: catalog import [-dry-run] [-v] <archivo>")
+		}
+
+		file, err := catalog.Load(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		report, err := catalog.Import(file, *dryRun)
+		if err != nil {
+			return err
+		}
+		report.Write(os.Stdout, *verbose)
+		if *dryRun {
+			fmt.Println("dry-run: no se aplicaron cambios")
+		}
+		return nil
+
+	case "export":
+		if len(args) != 2 {
+			return fmt.Errorf("uso: catalog export <archivo|->")
+		}
+		file, err := catalog.Export()
+		if err != nil {
+			return err
+		}
+		if args[1] == "-" {
+			data, err := file.Marshal(".yaml")
+			if err != nil {
+				return err
+			}
+			_, err = os.Stdout.Write(data)
+			return err
+		}
+		return file.Save(args[1])
+	}
+
+	return fmt.Errorf("subcomando desconocido: %s", args[0])
+}
@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/goalritmo/gym/backend/database"
+	"github.com/goalritmo/gym/backend/models"
+	"github.com/lib/pq"
+)
+
+// AccessTokenPrefix distingue los tokens de acceso personales de los JWT
+const AccessTokenPrefix = "gym_pat_"
+
+// accessTokenDisplayLength es cuántos caracteres del token se guardan para mostrarlo
+const accessTokenDisplayLength = len(AccessTokenPrefix) + 4
+
+// accessTokenManagementPath no se puede usar con tokens de acceso: crear o revocar
+// tokens requiere una sesión real
+const accessTokenManagementPath = "/api/me/tokens"
+
+// workoutsWritePaths son las rutas que se pueden modificar con workouts:write
+var workoutsWritePaths = []string{"/api/workouts", "/api/workout-sessions", "/api/timer"}
+
+// GenerateAccessToken crea un token nuevo y devuelve el token, lo que se guarda
+// para mostrarlo y su hash
+func GenerateAccessToken() (token, prefix, hash string, err error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", "", fmt.Errorf("error generando token: %v", err)
+	}
+	token = AccessTokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	return token, token[:accessTokenDisplayLength], HashAccessToken(token), nil
+}
+
+// HashAccessToken devuelve el SHA-256 del token. Alcanza con un hash rápido porque
+// el token tiene 256 bits aleatorios
+func HashAccessToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// authenticateAccessToken valida un token de acceso personal, registra su uso y
+// verifica que sus alcances permitan la request. Devuelve la identidad del dueño
+func authenticateAccessToken(tokenString string, r *http.Request) (*Principal, error) {
+	if database.DB == nil {
+		return nil, authError(AuthErrLookupFailed, "base de datos no disponible")
+	}
+
+	principal := &Principal{TokenType: TokenTypeAccessToken}
+	var scopes pq.StringArray
+	var role string
+	err := database.DB.QueryRow(`
+		UPDATE personal_access_tokens t SET last_used_at = NOW()
+		FROM auth.users u
+		WHERE u.id = t.user_id AND t.token_hash = $1
+			AND t.revoked_at IS NULL AND (t.expires_at IS NULL OR t.expires_at > NOW())
+		RETURNING t.user_id, t.scopes, COALESCE(u.email, ''), COALESCE(u.raw_app_meta_data->>'role', '')
+	`, HashAccessToken(tokenString)).Scan(&principal.UserID, &scopes, &principal.Email, &role)
+	if err == sql.ErrNoRows {
+		return nil, authError(AuthErrInvalidAccessToken, "token de acceso inexistente, vencido o revocado")
+	}
+	if err != nil {
+		return nil, authError(AuthErrLookupFailed, "error consultando token de acceso: %v", err)
+	}
+	principal.Scopes = []string(scopes)
+	principal.Role = normalizeRole(role)
+
+	if !accessTokenAllows(principal.Scopes, r.Method, r.URL.Path) {
+		return nil, authError(AuthErrInsufficientScope, "el token no tiene alcance para %s %s", r.Method, r.URL.Path)
+	}
+	return principal, nil
+}
+
+// accessTokenAllows indica si los alcances permiten la request. La gestión de
+// tokens nunca se permite
+func accessTokenAllows(scopes []string, method, path string) bool {
+	if path == accessTokenManagementPath || strings.HasPrefix(path, accessTokenManagementPath+"/") {
+		return false
+	}
+
+	if method == http.MethodGet || method == http.MethodHead {
+		return containsValue(scopes, models.ScopeRead)
+	}
+
+	if containsValue(scopes, models.ScopeWorkoutsWrite) {
+		for _, prefix := range workoutsWritePaths {
+			if path == prefix || strings.HasPrefix(path, prefix+"/") {
+				return true
+			}
+		}
+	}
+	return false
+}
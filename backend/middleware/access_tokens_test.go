@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateAccessToken(t *testing.T) {
+	token, prefix, hash, err := GenerateAccessToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(token, AccessTokenPrefix) || !strings.HasPrefix(token, prefix) || len(prefix) != accessTokenDisplayLength {
+		t.Errorf("token = %q, prefix = %q", token, prefix)
+	}
+	if hash != HashAccessToken(token) || len(hash) != 64 {
+		t.Errorf("hash = %q", hash)
+	}
+
+	other, _, _, _ := GenerateAccessToken()
+	if other == token {
+		t.Error("dos tokens generados no deberían coincidir")
+	}
+}
+
+func TestAccessTokenAllows(t *testing.T) {
+	cases := []struct {
+		scopes []string
+		method string
+		path   string
+		allow  bool
+	}{
+		{[]string{"read"}, "GET", "/api/exercises", true},
+		{[]string{"read"}, "POST", "/api/workouts", false},
+		{[]string{"workouts:write"}, "POST", "/api/workouts", true},
+		{[]string{"workouts:write"}, "DELETE", "/api/workouts/12", true},
+		{[]string{"workouts:write"}, "POST", "/api/timer/start", true},
+		{[]string{"workouts:write"}, "GET", "/api/workouts", false},
+		{[]string{"workouts:write"}, "POST", "/api/workoutsx", false},
+		{[]string{"workouts:write"}, "POST", "/api/exercises", false},
+		{[]string{"read", "workouts:write"}, "GET", "/api/me/tokens", false},
+		{[]string{"read", "workouts:write"}, "DELETE", "/api/me/tokens/3", false},
+	}
+	for _, c := range cases {
+		if got := accessTokenAllows(c.scopes, c.method, c.path); got != c.allow {
+			t.Errorf("%v %s %s = %v, se esperaba %v", c.scopes, c.method, c.path, got, c.allow)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+package middleware
+
+import (
+	"net/http"
+)
+
+// RequireAdmin permite el acceso solo a usuarios administradores. En main.go las
+// rutas de administración se declaran en RoutePermissions; esto queda para
+// handlers montados fuera del router principal
+func RequireAdmin(next http.Handler) http.Handler {
+	return RequireRole(RoleAdmin)(next)
+}
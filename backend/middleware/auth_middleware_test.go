@@ -0,0 +1,75 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/goalritmo/gym/backend/middleware"
+	"github.com/goalritmo/gym/backend/testutils"
+)
+
+func TestAuthMiddlewareWithTestIssuer(t *testing.T) {
+	issuer := testutils.NewTestIssuer(t)
+	handler := issuer.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, _ := middleware.PrincipalFrom(r.Context())
+		w.Write([]byte(principal.UserID + " " + principal.Email + " " + principal.Role))
+	}))
+
+	request := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/workouts", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	userID := "00000000-0000-0000-0000-000000000002"
+	rec := request(issuer.Token(userID))
+	if rec.Code != http.StatusOK || rec.Body.String() != userID+"  user" {
+		t.Fatalf("token válido = %d %q", rec.Code, rec.Body.String())
+	}
+
+	claims := issuer.Claims(userID)
+	claims["email"] = "ana@example.com"
+	claims["app_metadata"] = map[string]interface{}{"provider": "google", "role": "admin"}
+	rec = request(issuer.Sign(claims))
+	if rec.Code != http.StatusOK || rec.Body.String() != userID+" ana@example.com admin" {
+		t.Fatalf("token válido = %d %q", rec.Code, rec.Body.String())
+	}
+
+	claims = issuer.Claims(userID)
+	claims["exp"] = time.Now().Add(-time.Hour).Unix()
+	rec = request(issuer.Sign(claims))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("token vencido = %d", rec.Code)
+	}
+	if got := rec.Header().Get("WWW-Authenticate"); got != `Bearer error="invalid_token", error_description="token_expired"` {
+		t.Errorf("WWW-Authenticate = %q", got)
+	}
+}
+
+func TestAuthMiddlewareQueryTokenOnlyForTimerEvents(t *testing.T) {
+	issuer := testutils.NewTestIssuer(t)
+	handler := issuer.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	token := issuer.Token("00000000-0000-0000-0000-000000000002")
+
+	request := func(method, path string) int {
+		req := httptest.NewRequest(method, path+"?access_token="+token, nil)
+		req.Header.Set("Accept", "text/event-stream")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := request("GET", "/api/timer/events"); code != http.StatusOK {
+		t.Errorf("stream SSE = %d", code)
+	}
+	if code := request("GET", "/api/workouts"); code != http.StatusUnauthorized {
+		t.Errorf("otra ruta = %d", code)
+	}
+	if code := request("POST", "/api/timer/events"); code != http.StatusUnauthorized {
+		t.Errorf("otro método = %d", code)
+	}
+}
@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// devTokenPrefix identifica los tokens del modo desarrollo: "Bearer dev:<nombre>"
+const devTokenPrefix = "dev:"
+
+// defaultDevUsers es el usuario de desarrollo si DEV_AUTH_USERS está vacío
+const defaultDevUsers = "dev=00000000-0000-0000-0000-000000000001"
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// DevAuth es el modo de autenticación de desarrollo: acepta tokens
+// "dev:<nombre>" para un conjunto fijo de usuarios ficticios, sin firma
+type DevAuth struct {
+	// Users mapea el nombre del usuario ficticio a su identidad
+	Users map[string]DevUser
+}
+
+// DevUser es un usuario ficticio del modo desarrollo
+type DevUser struct {
+	UserID string
+	Role   string
+}
+
+// LoadDevAuth lee el modo desarrollo del entorno. Devuelve nil si DEV_AUTH no es
+// "true", y error si está habilitado con ENVIRONMENT=production o si
+// DEV_AUTH_USERS ("nombre=uuid[:rol],nombre=uuid[:rol]") es inválido
+func LoadDevAuth() (*DevAuth, error) {
+	if os.Getenv("DEV_AUTH") != "true" {
+		return nil, nil
+	}
+	if IsProduction() {
+		return nil, fmt.Errorf("DEV_AUTH no puede habilitarse con ENVIRONMENT=production")
+	}
+
+	users, err := parseDevUsers(envOrDefault("DEV_AUTH_USERS", defaultDevUsers))
+	if err != nil {
+		return nil, err
+	}
+	return &DevAuth{Users: users}, nil
+}
+
+// IsProduction indica si el servidor corre en producción (ENVIRONMENT=production)
+func IsProduction() bool {
+	switch strings.ToLower(os.Getenv("ENVIRONMENT")) {
+	case "production", "prod":
+		return true
+	default:
+		return false
+	}
+}
+
+// parseDevUsers parsea la lista "nombre=uuid[:rol],nombre=uuid[:rol]". Sin rol
+// el usuario es RoleUser
+func parseDevUsers(value string) (map[string]DevUser, error) {
+	users := make(map[string]DevUser)
+	for _, entry := range splitList(value) {
+		name, identity, ok := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("DEV_AUTH_USERS inválido: %q (se espera nombre=uuid[:rol])", entry)
+		}
+
+		userID, role, hasRole := strings.Cut(strings.TrimSpace(identity), ":")
+		if !uuidPattern.MatchString(userID) {
+			return nil, fmt.Errorf("DEV_AUTH_USERS: el user_id de %s no es un UUID", name)
+		}
+		if !hasRole {
+			role = RoleUser
+		} else if roleRank(role) < 0 {
+			return nil, fmt.Errorf("DEV_AUTH_USERS: rol inválido para %s: %s", name, role)
+		}
+
+		if _, exists := users[name]; exists {
+			return nil, fmt.Errorf("DEV_AUTH_USERS: usuario %s repetido", name)
+		}
+		users[name] = DevUser{UserID: userID, Role: role}
+	}
+	if len(users) == 0 {
+		return nil, fmt.Errorf("DEV_AUTH_USERS no tiene usuarios")
+	}
+	return users, nil
+}
+
+// Names devuelve los nombres de los usuarios ficticios, ordenados
+func (d *DevAuth) Names() []string {
+	names := make([]string, 0, len(d.Users))
+	for name := range d.Users {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// principal resuelve un token "dev:<nombre>"
+func (d *DevAuth) principal(tokenString string) (*Principal, error) {
+	name := strings.TrimPrefix(tokenString, devTokenPrefix)
+	user, ok := d.Users[name]
+	if !ok {
+		return nil, authError(AuthErrUnknownDevUser, "usuario de desarrollo desconocido: %s", name)
+	}
+	return &Principal{UserID: user.UserID, Role: user.Role, TokenType: TokenTypeDev}, nil
+}
+
+// devAuth es el modo desarrollo del entorno, leído una sola vez
+var devAuth = sync.OnceValues(LoadDevAuth)
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLoadDevAuth(t *testing.T) {
+	t.Setenv("ENVIRONMENT", "development")
+	t.Setenv("DEV_AUTH_USERS", "")
+
+	t.Setenv("DEV_AUTH", "")
+	if dev, err := LoadDevAuth(); dev != nil || err != nil {
+		t.Fatalf("sin DEV_AUTH debería estar deshabilitado: %v, %v", dev, err)
+	}
+
+	t.Setenv("DEV_AUTH", "true")
+	dev, err := LoadDevAuth()
+	if err != nil {
+		t.Fatalf("LoadDevAuth: %v", err)
+	}
+	if dev.Users["dev"] != (DevUser{UserID: "00000000-0000-0000-0000-000000000001", Role: RoleUser}) {
+		t.Errorf("Users = %v", dev.Users)
+	}
+
+	t.Setenv("DEV_AUTH_USERS", "ana=00000000-0000-0000-0000-00000000000a:admin, beto=00000000-0000-0000-0000-00000000000b")
+	dev, err = LoadDevAuth()
+	if err != nil {
+		t.Fatalf("LoadDevAuth: %v", err)
+	}
+	if names := dev.Names(); len(names) != 2 || names[0] != "ana" || names[1] != "beto" {
+		t.Errorf("Names = %v", names)
+	}
+	if dev.Users["ana"].Role != RoleAdmin || dev.Users["beto"].Role != RoleUser {
+		t.Errorf("Users = %v", dev.Users)
+	}
+
+	for _, users := range []string{"ana", "ana=no-es-uuid", "ana=00000000-0000-0000-0000-00000000000a,ana=00000000-0000-0000-0000-00000000000b", "ana=00000000-0000-0000-0000-00000000000a:root"} {
+		t.Setenv("DEV_AUTH_USERS", users)
+		if _, err := LoadDevAuth(); err == nil {
+			t.Errorf("DEV_AUTH_USERS=%q debería rechazarse", users)
+		}
+	}
+
+	t.Setenv("DEV_AUTH_USERS", "")
+	t.Setenv("ENVIRONMENT", "production")
+	if _, err := LoadDevAuth(); err == nil {
+		t.Error("DEV_AUTH no debería permitirse en producción")
+	}
+}
+
+func TestAuthMiddlewareDevTokens(t *testing.T) {
+	dev := &DevAuth{Users: map[string]DevUser{"ana": {UserID: "00000000-0000-0000-0000-00000000000a", Role: RoleCoach}}}
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, _ := PrincipalFrom(r.Context())
+		w.Write([]byte(principal.UserID + " " + principal.Role + " " + principal.TokenType))
+	})
+
+	request := func(handler http.Handler, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/workouts", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	handler := NewAuthMiddleware(testJWTConfig(), nil, nil, dev)(echo)
+
+	rec := request(handler, "dev:ana")
+	if rec.Code != http.StatusOK || rec.Body.String() != "00000000-0000-0000-0000-00000000000a coach dev" {
+		t.Errorf("dev:ana = %d %q", rec.Code, rec.Body.String())
+	}
+
+	rec = request(handler, "dev:carla")
+	if rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") == "" {
+		t.Errorf("dev:carla = %d", rec.Code)
+	}
+
+	// Sin modo desarrollo los tokens dev: son JWT inválidos, y "salud" ya no se acepta
+	handler = NewAuthMiddleware(testJWTConfig(), nil, nil, nil)(echo)
+	for _, token := range []string{"dev:ana", "salud"} {
+		if rec := request(handler, token); rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s sin DEV_AUTH = %d", token, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,282 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Parámetros del caché de JWKS
+const (
+	// jwksDefaultTTL se usa si la respuesta no trae Cache-Control max-age
+	jwksDefaultTTL = 5 * time.Minute
+	jwksMinTTL     = time.Minute
+	jwksMaxTTL     = 24 * time.Hour
+	// jwksMinRefreshInterval limita los refetch por kid desconocido, para que
+	// tokens con kid inventados no generen una request a Supabase cada uno
+	jwksMinRefreshInterval = 30 * time.Second
+	// jwksDefaultStaleIfError es cuánto tiempo se siguen usando las claves vencidas
+	// si Supabase no responde (salvo que Cache-Control indique stale-if-error)
+	jwksDefaultStaleIfError = time.Hour
+)
+
+// Errores de Key, para distinguir un kid desconocido de una falla de Supabase
+var (
+	ErrUnknownKid      = errors.New("kid desconocido")
+	ErrJWKSUnavailable = errors.New("JWKS no disponible")
+)
+
+// JWKSCache mantiene en memoria las claves públicas de un JWKS. Es seguro para uso
+// concurrente: las lecturas no bloquean y solo una goroutine a la vez hace fetch
+type JWKSCache struct {
+	url    string
+	client *http.Client
+	now    func() time.Time
+
+	mu           sync.RWMutex
+	keys         map[string]interface{} // kid -> *rsa.PublicKey o *ecdsa.PublicKey
+	expiresAt    time.Time
+	staleUntil   time.Time
+	lastAttempt  time.Time
+	lastFetchErr error
+	fetching     bool // hay un fetch en curso
+
+	// refreshMu serializa los fetch
+	refreshMu sync.Mutex
+}
+
+// NewJWKSCache crea el caché para la URL dada. Las claves se obtienen en el primer uso
+func NewJWKSCache(url string) *JWKSCache {
+	return &JWKSCache{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+		now:    time.Now,
+	}
+}
+
+// Key devuelve la clave pública con el kid dado. Si el caché venció o el kid no
+// está (rotación de claves) se vuelve a pedir el JWKS, como máximo una vez cada
+// jwksMinRefreshInterval; mientras tanto se responde con las claves que haya. Si
+// Supabase falla se siguen usando las claves vencidas durante la ventana de
+// stale-if-error, sin esperar a que otro fetch termine
+func (c *JWKSCache) Key(kid string) (interface{}, error) {
+	key, found, fresh := c.lookup(kid)
+	if found && fresh {
+		return key, nil
+	}
+
+	if !c.canRefetch() {
+		return c.cachedKey(kid)
+	}
+	// Si otra goroutine ya está pidiendo el JWKS y hay claves vencidas utilizables,
+	// se responde con ellas en lugar de esperar
+	if !fresh && c.refreshing() {
+		if _, _, usable := c.lookupStale(kid); usable {
+			return c.cachedKey(kid)
+		}
+	}
+
+	if err := c.refresh(); err != nil {
+		// Claves vencidas pero dentro de la ventana stale-if-error
+		if _, _, usable := c.lookupStale(kid); usable {
+			log.Printf("JWKS: usando claves vencidas por error de refresco: %v", err)
+			return c.cachedKey(kid)
+		}
+		return nil, fmt.Errorf("%w: %v", ErrJWKSUnavailable, err)
+	}
+	return c.cachedKey(kid)
+}
+
+// cachedKey busca el kid en las claves cargadas, vigentes o dentro de la ventana
+// stale-if-error, sin pedir el JWKS
+func (c *JWKSCache) cachedKey(kid string) (interface{}, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.keys == nil || !c.now().Before(c.staleUntil) {
+		if c.lastFetchErr != nil {
+			return nil, fmt.Errorf("%w: %v", ErrJWKSUnavailable, c.lastFetchErr)
+		}
+		return nil, fmt.Errorf("%w: claves vencidas", ErrJWKSUnavailable)
+	}
+	key, found := c.keys[kid]
+	if !found {
+		return nil, fmt.Errorf("%w: clave con kid %s no encontrada en JWKS", ErrUnknownKid, kid)
+	}
+	return key, nil
+}
+
+// RefreshInBackground renueva las claves poco antes de que venzan, para que las
+// requests no esperen el fetch. Si falla reintenta cada jwksMinRefreshInterval.
+// Termina cuando se cancela ctx
+func (c *JWKSCache) RefreshInBackground(ctx context.Context) {
+	for {
+		wait := jwksMinRefreshInterval
+		if err := c.refresh(); err == nil {
+			c.mu.RLock()
+			// Se renueva al 80% de la vida útil
+			wait = time.Duration(float64(c.expiresAt.Sub(c.now())) * 0.8)
+			c.mu.RUnlock()
+			if wait < jwksMinRefreshInterval {
+				wait = jwksMinRefreshInterval
+			}
+		} else {
+			log.Printf("JWKS: error en refresco en segundo plano: %v", err)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// lookup busca el kid e indica si las claves están vigentes
+func (c *JWKSCache) lookup(kid string) (interface{}, bool, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	key, found := c.keys[kid]
+	return key, found, c.keys != nil && c.now().Before(c.expiresAt)
+}
+
+// lookupStale busca el kid en claves vencidas e indica si todavía se pueden usar
+func (c *JWKSCache) lookupStale(kid string) (interface{}, bool, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	key, found := c.keys[kid]
+	return key, found, c.keys != nil && c.now().Before(c.staleUntil)
+}
+
+// canRefetch indica si pasó suficiente tiempo desde el último fetch para volver
+// a pedir el JWKS por un kid desconocido
+func (c *JWKSCache) canRefetch() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.now().Sub(c.lastAttempt) >= jwksMinRefreshInterval
+}
+
+// refreshing indica si hay un fetch en curso
+func (c *JWKSCache) refreshing() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.fetching
+}
+
+// refresh pide el JWKS y reemplaza las claves. Si otra goroutine lo pidió
+// mientras se esperaba el lock, se usa ese resultado
+func (c *JWKSCache) refresh() error {
+	requested := c.now()
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	c.mu.RLock()
+	if !c.lastAttempt.Before(requested) {
+		err := c.lastFetchErr
+		c.mu.RUnlock()
+		return err
+	}
+	c.mu.RUnlock()
+
+	c.mu.Lock()
+	c.fetching = true
+	c.mu.Unlock()
+
+	keys, ttl, staleIfError, err := c.fetch()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fetching = false
+	c.lastAttempt = c.now()
+	c.lastFetchErr = err
+	if err != nil {
+		return err
+	}
+	c.keys = keys
+	c.expiresAt = c.lastAttempt.Add(ttl)
+	c.staleUntil = c.expiresAt.Add(staleIfError)
+	return nil
+}
+
+// fetch descarga el JWKS, convierte las claves y calcula su vigencia según Cache-Control
+func (c *JWKSCache) fetch() (map[string]interface{}, time.Duration, time.Duration, error) {
+	resp, err := c.client.Get(c.url)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("error haciendo request a JWKS: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, 0, fmt.Errorf("JWKS endpoint retornó status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("error leyendo respuesta JWKS: %v", err)
+	}
+
+	var jwks JWKS
+	if err := json.Unmarshal(body, &jwks); err != nil {
+		return nil, 0, 0, fmt.Errorf("error parseando JWKS JSON: %v", err)
+	}
+
+	keys := make(map[string]interface{})
+	for _, jwk := range jwks.Keys {
+		key, err := JWKToPublicKey(jwk)
+		if err != nil {
+			log.Printf("JWKS: se ignora la clave %s: %v", jwk.Kid, err)
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, 0, 0, fmt.Errorf("JWKS sin claves válidas")
+	}
+
+	ttl, staleIfError := parseCacheControl(resp.Header.Get("Cache-Control"))
+	return keys, ttl, staleIfError, nil
+}
+
+// parseCacheControl obtiene la vigencia (max-age, acotada entre jwksMinTTL y
+// jwksMaxTTL) y la ventana stale-if-error de un header Cache-Control. Con no-store
+// o no-cache se usa la vigencia mínima
+func parseCacheControl(header string) (time.Duration, time.Duration) {
+	ttl, staleIfError := jwksDefaultTTL, jwksDefaultStaleIfError
+	noCache := false
+
+	for _, directive := range strings.Split(header, ",") {
+		name, value, _ := strings.Cut(strings.ToLower(strings.TrimSpace(directive)), "=")
+		seconds, err := strconv.Atoi(strings.Trim(value, `"`))
+
+		switch name {
+		case "no-store", "no-cache":
+			noCache = true
+		case "max-age":
+			if err == nil && seconds >= 0 {
+				ttl = time.Duration(seconds) * time.Second
+			}
+		case "stale-if-error":
+			if err == nil && seconds >= 0 {
+				staleIfError = time.Duration(seconds) * time.Second
+			}
+		}
+	}
+
+	if noCache || ttl < jwksMinTTL {
+		ttl = jwksMinTTL
+	}
+	if ttl > jwksMaxTTL {
+		ttl = jwksMaxTTL
+	}
+	return ttl, staleIfError
+}
@@ -0,0 +1,240 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// jwksServer sirve un JWKS con las claves indicadas y cuenta las requests
+type jwksServer struct {
+	*httptest.Server
+	key          *rsa.PrivateKey
+	requests     atomic.Int32
+	mu           sync.Mutex
+	kids         []string
+	cacheControl string
+	fail         bool
+}
+
+func newJWKSServer(t *testing.T, kids ...string) *jwksServer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey: %v", err)
+	}
+
+	s := &jwksServer{key: key, kids: kids, cacheControl: "max-age=600"}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests.Add(1)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if s.fail {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		jwks := JWKS{}
+		for _, kid := range s.kids {
+			jwks.Keys = append(jwks.Keys, JWK{
+				Kty: "RSA", Kid: kid, Alg: "RS256", Use: "sig",
+				N: base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				E: base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			})
+		}
+		w.Header().Set("Cache-Control", s.cacheControl)
+		json.NewEncoder(w).Encode(jwks)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) set(update func(s *jwksServer)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	update(s)
+}
+
+// newTestJWKSCache crea un caché con un reloj controlado por el test
+func newTestJWKSCache(url string) (*JWKSCache, *time.Time) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := NewJWKSCache(url)
+	cache.now = func() time.Time { return now }
+	return cache, &now
+}
+
+func TestJWKSCacheReusesKeys(t *testing.T) {
+	server := newJWKSServer(t, "key-1")
+	cache, _ := newTestJWKSCache(server.URL)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := cache.Key("key-1"); err != nil {
+				t.Errorf("Key: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := server.requests.Load(); n != 1 {
+		t.Errorf("Expected 1 fetch for concurrent requests, got %d", n)
+	}
+	if _, ok := mustKey(t, cache, "key-1").(*rsa.PublicKey); !ok {
+		t.Error("Expected RSA public key")
+	}
+}
+
+func TestJWKSCacheRespectsMaxAge(t *testing.T) {
+	server := newJWKSServer(t, "key-1")
+	cache, now := newTestJWKSCache(server.URL)
+
+	mustKey(t, cache, "key-1")
+	*now = now.Add(9 * time.Minute)
+	mustKey(t, cache, "key-1")
+	if n := server.requests.Load(); n != 1 {
+		t.Errorf("Expected cached keys before max-age, got %d fetches", n)
+	}
+
+	*now = now.Add(2 * time.Minute)
+	mustKey(t, cache, "key-1")
+	if n := server.requests.Load(); n != 2 {
+		t.Errorf("Expected refetch after max-age, got %d fetches", n)
+	}
+}
+
+func TestJWKSCacheUnknownKidIsRateLimited(t *testing.T) {
+	server := newJWKSServer(t, "key-1")
+	cache, now := newTestJWKSCache(server.URL)
+	mustKey(t, cache, "key-1")
+
+	// Rotación: la clave nueva aparece en el JWKS
+	server.set(func(s *jwksServer) { s.kids = []string{"key-1", "key-2"} })
+
+	// Antes del intervalo mínimo no se vuelve a pedir
+	if _, err := cache.Key("key-2"); err == nil {
+		t.Error("Expected unknown kid before refetch interval")
+	}
+	*now = now.Add(jwksMinRefreshInterval)
+	mustKey(t, cache, "key-2")
+
+	// Un kid inventado no genera un fetch por request
+	for i := 0; i < 5; i++ {
+		cache.Key("inventado")
+	}
+	if n := server.requests.Load(); n != 2 {
+		t.Errorf("Expected 2 fetches, got %d", n)
+	}
+}
+
+func TestJWKSCacheStaleIfError(t *testing.T) {
+	server := newJWKSServer(t, "key-1")
+	server.set(func(s *jwksServer) { s.cacheControl = "max-age=300, stale-if-error=600" })
+	cache, now := newTestJWKSCache(server.URL)
+	mustKey(t, cache, "key-1")
+
+	server.set(func(s *jwksServer) { s.fail = true })
+
+	// Vencidas pero dentro de stale-if-error: se siguen usando
+	*now = now.Add(10 * time.Minute)
+	mustKey(t, cache, "key-1")
+
+	// Fuera de la ventana: error
+	*now = now.Add(10 * time.Minute)
+	if _, err := cache.Key("key-1"); err == nil {
+		t.Error("Expected error after stale-if-error window")
+	}
+
+	// Cuando Supabase vuelve, se recuperan las claves
+	server.set(func(s *jwksServer) { s.fail = false })
+	*now = now.Add(jwksMinRefreshInterval)
+	mustKey(t, cache, "key-1")
+}
+
+func TestJWKSCacheStaleKeysAreRateLimited(t *testing.T) {
+	server := newJWKSServer(t, "key-1")
+	server.set(func(s *jwksServer) { s.cacheControl = "max-age=300, stale-if-error=600" })
+	cache, now := newTestJWKSCache(server.URL)
+	mustKey(t, cache, "key-1")
+
+	server.set(func(s *jwksServer) { s.fail = true })
+
+	// Con las claves vencidas y Supabase caído, solo una request por intervalo
+	// intenta el fetch; las demás usan las claves vencidas sin pedir el JWKS
+	*now = now.Add(6 * time.Minute)
+	for i := 0; i < 5; i++ {
+		mustKey(t, cache, "key-1")
+		if _, err := cache.Key("inventado"); err == nil {
+			t.Error("Expected unknown kid")
+		}
+	}
+	if n := server.requests.Load(); n != 2 {
+		t.Errorf("Expected 2 fetches, got %d", n)
+	}
+
+	*now = now.Add(jwksMinRefreshInterval)
+	mustKey(t, cache, "key-1")
+	if n := server.requests.Load(); n != 3 {
+		t.Errorf("Expected a new fetch after the interval, got %d", n)
+	}
+}
+
+func TestJWKSCacheServesStaleKeysDuringFetch(t *testing.T) {
+	server := newJWKSServer(t, "key-1")
+	server.set(func(s *jwksServer) { s.cacheControl = "max-age=300, stale-if-error=600" })
+	cache, now := newTestJWKSCache(server.URL)
+	mustKey(t, cache, "key-1")
+	*now = now.Add(6 * time.Minute)
+
+	// Mientras otro fetch está en curso, las claves vencidas se sirven sin esperar
+	cache.mu.Lock()
+	cache.fetching = true
+	cache.mu.Unlock()
+	mustKey(t, cache, "key-1")
+	if n := server.requests.Load(); n != 1 {
+		t.Errorf("Expected no fetch while another is in progress, got %d", n)
+	}
+}
+
+func TestParseCacheControl(t *testing.T) {
+	tests := []struct {
+		header       string
+		ttl          time.Duration
+		staleIfError time.Duration
+	}{
+		{"", jwksDefaultTTL, jwksDefaultStaleIfError},
+		{"public, max-age=3600", time.Hour, jwksDefaultStaleIfError},
+		{"max-age=10", jwksMinTTL, jwksDefaultStaleIfError},
+		{"max-age=604800", jwksMaxTTL, jwksDefaultStaleIfError},
+		{"max-age=600, stale-if-error=86400", 10 * time.Minute, 24 * time.Hour},
+		{"no-cache, max-age=600", jwksMinTTL, jwksDefaultStaleIfError},
+		{"max-age=abc", jwksDefaultTTL, jwksDefaultStaleIfError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			ttl, staleIfError := parseCacheControl(tt.header)
+			if ttl != tt.ttl || staleIfError != tt.staleIfError {
+				t.Errorf("Expected %v/%v, got %v/%v", tt.ttl, tt.staleIfError, ttl, staleIfError)
+			}
+		})
+	}
+}
+
+func mustKey(t *testing.T, cache *JWKSCache, kid string) interface{} {
+	t.Helper()
+	key, err := cache.Key(kid)
+	if err != nil {
+		t.Fatalf("Key(%s): %v", kid, err)
+	}
+	return key
+}
@@ -0,0 +1,413 @@
+package middleware
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Códigos de error de autenticación. SupabaseAuthMiddleware los devuelve al
+// principio del cuerpo de la respuesta y en el header WWW-Authenticate
+const (
+	AuthErrMissingToken     = "missing_token"
+	AuthErrInvalidHeader    = "invalid_authorization_header"
+	AuthErrMalformed        = "token_malformed"
+	AuthErrExpired          = "token_expired"
+	AuthErrNotYetValid      = "token_not_yet_valid"
+	AuthErrInvalidSignature = "invalid_signature"
+	AuthErrInvalidIssuer    = "invalid_issuer"
+	AuthErrInvalidAudience  = "invalid_audience"
+	AuthErrAlgorithm        = "algorithm_not_allowed"
+	AuthErrWeakKey          = "weak_key"
+	AuthErrMissingKid       = "missing_kid"
+	AuthErrUnknownKid       = "unknown_kid"
+	AuthErrMissingClaim     = "missing_claim"
+	AuthErrMissingSubject   = "missing_subject"
+	AuthErrInvalidSubject   = "invalid_subject"
+	AuthErrMissingRole      = "missing_role"
+	AuthErrInvalidRole      = "invalid_role"
+	AuthErrTokenRevoked     = "token_revoked"
+	AuthErrUnknownDevUser   = "unknown_dev_user"
+	// Tokens de acceso personales (ver access_tokens.go)
+	AuthErrInvalidAccessToken = "invalid_access_token"
+	AuthErrInsufficientScope  = "insufficient_scope"
+	AuthErrLookupFailed       = "token_lookup_failed"
+	AuthErrKeysUnavailable    = "keys_unavailable"
+	AuthErrNotConfigured      = "auth_not_configured"
+)
+
+// Valores por defecto de la validación de JWT
+const (
+	defaultJWTAudience = "authenticated"
+	defaultJWTLeeway   = 30 * time.Second
+	// minRSABits es el tamaño mínimo aceptado para claves RSA
+	minRSABits = 2048
+	// minHMACSecretBytes es el largo mínimo del secreto para HS256 (256 bits)
+	minHMACSecretBytes = 32
+)
+
+// supportedJWTAlgorithms son los algoritmos que se pueden habilitar con JWT_ALGORITHMS
+var supportedJWTAlgorithms = []string{
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+	"HS256", "HS384", "HS512",
+}
+
+// AuthError es un error de autenticación con su código (ver AuthErr*)
+type AuthError struct {
+	Code string
+	Err  error
+}
+
+func (e *AuthError) Error() string {
+	return e.Code + ": " + e.Err.Error()
+}
+
+func (e *AuthError) Unwrap() error {
+	return e.Err
+}
+
+// Status devuelve el código HTTP que corresponde al error: los problemas del lado
+// del servidor no se informan como 401 para que el cliente no descarte su sesión
+func (e *AuthError) Status() int {
+	switch e.Code {
+	case AuthErrInsufficientScope:
+		return http.StatusForbidden
+	case AuthErrKeysUnavailable, AuthErrLookupFailed:
+		return http.StatusServiceUnavailable
+	case AuthErrNotConfigured:
+		return http.StatusInternalServerError
+	default:
+		return http.StatusUnauthorized
+	}
+}
+
+// authError crea un AuthError con un mensaje formateado
+func authError(code, format string, args ...interface{}) *AuthError {
+	return &AuthError{Code: code, Err: fmt.Errorf(format, args...)}
+}
+
+// JWTConfig es lo que se exige a un JWT para aceptarlo
+type JWTConfig struct {
+	// Issuers son los emisores confiables: Supabase y los de OIDC_ISSUERS
+	Issuers    []IssuerConfig
+	Algorithms []string
+	Leeway     time.Duration
+	// Secret es SUPABASE_JWT_SECRET, solo necesario para tokens HS*
+	Secret string
+}
+
+// LoadJWTConfig lee la configuración del entorno. El emisor de Supabase se arma con:
+//   - JWT_ISSUER (por defecto SUPABASE_URL/auth/v1)
+//   - JWT_AUDIENCE (por defecto "authenticated")
+//   - JWT_ROLES, separados por coma (por defecto "authenticated")
+//
+// OIDC_ISSUERS agrega otros emisores (ver IssuerConfig) y puede reemplazar a
+// Supabase si SUPABASE_URL y JWT_ISSUER están vacíos. Para todos los emisores:
+//   - JWT_ALGORITHMS, separados por coma (por defecto RS256 y ES256, más HS256 si
+//     hay SUPABASE_JWT_SECRET)
+//   - JWT_LEEWAY, tolerancia de reloj para exp, nbf e iat (por defecto 30s)
+func LoadJWTConfig() (JWTConfig, error) {
+	config := JWTConfig{
+		Leeway: defaultJWTLeeway,
+		Secret: os.Getenv("SUPABASE_JWT_SECRET"),
+	}
+
+	supabaseURL := strings.TrimSuffix(os.Getenv("SUPABASE_URL"), "/")
+	if supabaseURL != "" || os.Getenv("JWT_ISSUER") != "" {
+		supabase := IssuerConfig{
+			Issuer:      envOrDefault("JWT_ISSUER", supabaseURL+"/auth/v1"),
+			Audience:    envOrDefault("JWT_AUDIENCE", defaultJWTAudience),
+			RoleClaim:   supabaseRoleClaim,
+			TokenRoles:  splitList(envOrDefault("JWT_ROLES", defaultJWTAudience)),
+			AllowSecret: true,
+		}
+		if supabaseURL != "" {
+			supabase.JWKSURL = supabaseURL + "/auth/v1/.well-known/jwks.json"
+		}
+		config.Issuers = append(config.Issuers, supabase.withDefaults())
+	}
+
+	if value := os.Getenv("OIDC_ISSUERS"); value != "" {
+		issuers, err := parseIssuers(value)
+		if err != nil {
+			return config, err
+		}
+		config.Issuers = append(config.Issuers, issuers...)
+	}
+
+	if len(config.Issuers) == 0 {
+		return config, fmt.Errorf("SUPABASE_URL, JWT_ISSUER u OIDC_ISSUERS no configurado")
+	}
+
+	if algorithms := os.Getenv("JWT_ALGORITHMS"); algorithms != "" {
+		config.Algorithms = splitList(algorithms)
+	} else {
+		config.Algorithms = []string{"RS256", "ES256"}
+		if config.Secret != "" {
+			config.Algorithms = append(config.Algorithms, "HS256")
+		}
+	}
+
+	if leeway := os.Getenv("JWT_LEEWAY"); leeway != "" {
+		d, err := time.ParseDuration(leeway)
+		if err != nil || d < 0 {
+			return config, fmt.Errorf("JWT_LEEWAY inválido: %q", leeway)
+		}
+		config.Leeway = d
+	}
+
+	return config, config.Validate()
+}
+
+// Validate verifica que la configuración sea utilizable
+func (c JWTConfig) Validate() error {
+	if len(c.Issuers) == 0 {
+		return fmt.Errorf("se requiere al menos un emisor")
+	}
+	seen := make(map[string]bool)
+	for _, issuer := range c.Issuers {
+		if err := issuer.validate(); err != nil {
+			return err
+		}
+		if seen[issuer.Issuer] {
+			return fmt.Errorf("emisor repetido: %s", issuer.Issuer)
+		}
+		seen[issuer.Issuer] = true
+	}
+	if len(c.Algorithms) == 0 {
+		return fmt.Errorf("se requiere al menos un algoritmo")
+	}
+
+	for _, alg := range c.Algorithms {
+		if !containsValue(supportedJWTAlgorithms, alg) {
+			return fmt.Errorf("algoritmo no soportado: %s", alg)
+		}
+		if strings.HasPrefix(alg, "HS") {
+			if c.Secret == "" {
+				return fmt.Errorf("%s requiere SUPABASE_JWT_SECRET", alg)
+			}
+			if len(c.Secret) < minHMACSecretBytes {
+				return fmt.Errorf("SUPABASE_JWT_SECRET debe tener al menos %d bytes", minHMACSecretBytes)
+			}
+		}
+	}
+	return nil
+}
+
+// issuer devuelve la configuración del emisor iss, con los claims por defecto
+func (c JWTConfig) issuer(iss string) (IssuerConfig, bool) {
+	for _, issuer := range c.Issuers {
+		if issuer.Issuer == iss {
+			return issuer.withDefaults(), true
+		}
+	}
+	return IssuerConfig{}, false
+}
+
+// jwtConfig es la configuración del entorno, leída una sola vez
+var jwtConfig = sync.OnceValues(LoadJWTConfig)
+
+// parseJWT valida firma y claims del token y devuelve sus claims junto con la
+// configuración de su emisor. El emisor se elige por el claim iss; las claves
+// públicas se buscan en keys por kid y los tokens HS* se validan con el secreto.
+// Todos los errores son *AuthError
+func parseJWT(tokenString string, config JWTConfig, keys *Keyring) (jwt.MapClaims, IssuerConfig, error) {
+	unverified := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(tokenString, unverified); err != nil {
+		return nil, IssuerConfig{}, classifyJWTError(err)
+	}
+	iss, _ := unverified["iss"].(string)
+	if iss == "" {
+		return nil, IssuerConfig{}, authError(AuthErrMissingClaim, "el token no tiene claim iss")
+	}
+	issuer, ok := config.issuer(iss)
+	if !ok {
+		return nil, IssuerConfig{}, authError(AuthErrInvalidIssuer, "emisor no confiable: %s", iss)
+	}
+
+	parser := jwt.NewParser(
+		jwt.WithIssuer(issuer.Issuer),
+		jwt.WithAudience(issuer.Audience),
+		jwt.WithLeeway(config.Leeway),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+
+	claims := jwt.MapClaims{}
+	_, err := parser.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return config.verificationKey(token, issuer, keys)
+	})
+	if err != nil {
+		return nil, issuer, classifyJWTError(err)
+	}
+
+	userID := claimString(claims, issuer.UserIDClaim)
+	if userID == "" {
+		return nil, issuer, authError(AuthErrMissingSubject, "el token no tiene claim %s", issuer.UserIDClaim)
+	}
+	if !uuidPattern.MatchString(userID) {
+		return nil, issuer, authError(AuthErrInvalidSubject, "el claim %s no es un UUID", issuer.UserIDClaim)
+	}
+
+	if len(issuer.TokenRoles) > 0 {
+		role, ok := claims["role"].(string)
+		if !ok || role == "" {
+			return nil, issuer, authError(AuthErrMissingRole, "el token no tiene claim role")
+		}
+		if !containsValue(issuer.TokenRoles, role) {
+			return nil, issuer, authError(AuthErrInvalidRole, "rol no permitido: %s", role)
+		}
+	}
+
+	return claims, issuer, nil
+}
+
+// verificationKey elige la clave con la que se verifica la firma, rechazando
+// algoritmos no habilitados y claves demasiado débiles para el algoritmo
+func (c JWTConfig) verificationKey(token *jwt.Token, issuer IssuerConfig, keys *Keyring) (interface{}, error) {
+	alg := token.Method.Alg()
+	if !containsValue(c.Algorithms, alg) {
+		return nil, authError(AuthErrAlgorithm, "algoritmo no permitido: %s", alg)
+	}
+
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		// El secreto es de Supabase: otro emisor no puede firmar con él
+		if !issuer.AllowSecret {
+			return nil, authError(AuthErrAlgorithm, "%s no permitido para %s", alg, issuer.Issuer)
+		}
+		return []byte(c.Secret), nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, authError(AuthErrMissingKid, "el token no tiene kid")
+	}
+
+	jwks, err := keys.keys(issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := jwks.Key(kid)
+	if errors.Is(err, ErrUnknownKid) {
+		return nil, &AuthError{Code: AuthErrUnknownKid, Err: err}
+	}
+	if err != nil {
+		return nil, &AuthError{Code: AuthErrKeysUnavailable, Err: err}
+	}
+
+	if err := checkKeyStrength(token.Method, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// checkKeyStrength verifica que la clave corresponda al algoritmo: RSA de al menos
+// minRSABits y, para ECDSA, la curva que indica el algoritmo (P-256 para ES256)
+func checkKeyStrength(method jwt.SigningMethod, key interface{}) error {
+	switch m := method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return authError(AuthErrWeakKey, "la clave no es RSA")
+		}
+		if bits := rsaKey.N.BitLen(); bits < minRSABits {
+			return authError(AuthErrWeakKey, "clave RSA de %d bits (mínimo %d)", bits, minRSABits)
+		}
+	case *jwt.SigningMethodECDSA:
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return authError(AuthErrWeakKey, "la clave no es ECDSA")
+		}
+		if bits := ecKey.Curve.Params().BitSize; bits != m.CurveBits {
+			return authError(AuthErrWeakKey, "curva de %d bits para %s", bits, m.Alg())
+		}
+	default:
+		return authError(AuthErrAlgorithm, "algoritmo no permitido: %s", method.Alg())
+	}
+	return nil
+}
+
+// classifyJWTError traduce los errores de jwt a AuthError. Los que devuelve
+// verificationKey ya vienen clasificados
+func classifyJWTError(err error) *AuthError {
+	var authErr *AuthError
+	if errors.As(err, &authErr) {
+		return authErr
+	}
+
+	switch {
+	case errors.Is(err, jwt.ErrTokenMalformed):
+		return &AuthError{Code: AuthErrMalformed, Err: err}
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid):
+		return &AuthError{Code: AuthErrInvalidSignature, Err: err}
+	case errors.Is(err, jwt.ErrTokenRequiredClaimMissing):
+		return &AuthError{Code: AuthErrMissingClaim, Err: err}
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return &AuthError{Code: AuthErrExpired, Err: err}
+	case errors.Is(err, jwt.ErrTokenNotValidYet), errors.Is(err, jwt.ErrTokenUsedBeforeIssued):
+		return &AuthError{Code: AuthErrNotYetValid, Err: err}
+	case errors.Is(err, jwt.ErrTokenInvalidIssuer):
+		return &AuthError{Code: AuthErrInvalidIssuer, Err: err}
+	case errors.Is(err, jwt.ErrTokenInvalidAudience):
+		return &AuthError{Code: AuthErrInvalidAudience, Err: err}
+	default:
+		return &AuthError{Code: AuthErrMalformed, Err: err}
+	}
+}
+
+// writeAuthError responde con el código del error al principio del cuerpo y en
+// WWW-Authenticate (RFC 6750), para que el cliente pueda distinguir un token
+// vencido de uno inválido
+func writeAuthError(w http.ResponseWriter, err error) {
+	authErr := classifyJWTError(err)
+
+	oauthError := "invalid_token"
+	switch authErr.Code {
+	case AuthErrMissingToken, AuthErrInvalidHeader:
+		oauthError = "invalid_request"
+	case AuthErrInsufficientScope:
+		oauthError = "insufficient_scope"
+	}
+	w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="%s", error_description="%s"`, oauthError, authErr.Code))
+
+	http.Error(w, authErr.Error(), authErr.Status())
+}
+
+// envOrDefault devuelve la variable de entorno o el valor por defecto si está vacía
+func envOrDefault(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// splitList separa una lista separada por comas, descartando elementos vacíos
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func containsValue(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
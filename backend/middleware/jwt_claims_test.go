@@ -0,0 +1,254 @@
+package middleware
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testJWTSecret = "un-secreto-de-prueba-de-al-menos-32-bytes"
+	testIssuer    = "https://proyecto.supabase.co/auth/v1"
+)
+
+func testJWTConfig() JWTConfig {
+	return JWTConfig{
+		Issuers: []IssuerConfig{{
+			Issuer:      testIssuer,
+			Audience:    "authenticated",
+			RoleClaim:   supabaseRoleClaim,
+			TokenRoles:  []string{"authenticated"},
+			AllowSecret: true,
+		}},
+		Algorithms: []string{"RS256", "ES256", "HS256"},
+		Leeway:     30 * time.Second,
+		Secret:     testJWTSecret,
+	}
+}
+
+// validClaims devuelve claims que pasan la validación de testJWTConfig
+func validClaims() jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":  testIssuer,
+		"aud":  "authenticated",
+		"sub":  "00000000-0000-0000-0000-000000000001",
+		"role": "authenticated",
+		"iat":  now.Unix(),
+		"exp":  now.Add(time.Hour).Unix(),
+	}
+}
+
+func signHS256(t *testing.T, claims jwt.MapClaims) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testJWTSecret))
+	if err != nil {
+		t.Fatalf("SignedString: %v", err)
+	}
+	return token
+}
+
+func TestParseJWTClaims(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(claims jwt.MapClaims)
+		code   string
+	}{
+		{"válido", func(c jwt.MapClaims) {}, ""},
+		{"vencido", func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() }, AuthErrExpired},
+		{"vencido dentro del leeway", func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-10 * time.Second).Unix() }, ""},
+		{"sin exp", func(c jwt.MapClaims) { delete(c, "exp") }, AuthErrMissingClaim},
+		{"todavía no válido", func(c jwt.MapClaims) { c["nbf"] = time.Now().Add(time.Hour).Unix() }, AuthErrNotYetValid},
+		{"emitido en el futuro", func(c jwt.MapClaims) { c["iat"] = time.Now().Add(time.Hour).Unix() }, AuthErrNotYetValid},
+		{"otro issuer", func(c jwt.MapClaims) { c["iss"] = "https://otro.supabase.co/auth/v1" }, AuthErrInvalidIssuer},
+		{"sin issuer", func(c jwt.MapClaims) { delete(c, "iss") }, AuthErrMissingClaim},
+		{"otra audience", func(c jwt.MapClaims) { c["aud"] = "anon" }, AuthErrInvalidAudience},
+		{"audience en lista", func(c jwt.MapClaims) { c["aud"] = []string{"otra", "authenticated"} }, ""},
+		{"sin sub", func(c jwt.MapClaims) { delete(c, "sub") }, AuthErrMissingSubject},
+		{"sub no es UUID", func(c jwt.MapClaims) { c["sub"] = "ana" }, AuthErrInvalidSubject},
+		{"sin role", func(c jwt.MapClaims) { delete(c, "role") }, AuthErrMissingRole},
+		{"role no permitido", func(c jwt.MapClaims) { c["role"] = "service_role" }, AuthErrInvalidRole},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := validClaims()
+			tt.modify(claims)
+
+			_, _, err := parseJWT(signHS256(t, claims), testJWTConfig(), nil)
+			assertAuthErrorCode(t, err, tt.code)
+		})
+	}
+}
+
+func TestParseJWTSignatureAndAlgorithm(t *testing.T) {
+	config := testJWTConfig()
+
+	// Firma con otro secreto
+	forged, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, validClaims()).SignedString([]byte("otro-secreto-de-prueba-de-32-bytes!!"))
+	_, _, err := parseJWT(forged, config, nil)
+	assertAuthErrorCode(t, err, AuthErrInvalidSignature)
+
+	// HS512 no está habilitado
+	hs512, _ := jwt.NewWithClaims(jwt.SigningMethodHS512, validClaims()).SignedString([]byte(testJWTSecret))
+	_, _, err = parseJWT(hs512, config, nil)
+	assertAuthErrorCode(t, err, AuthErrAlgorithm)
+
+	// alg none nunca se acepta
+	none, _ := jwt.NewWithClaims(jwt.SigningMethodNone, validClaims()).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	_, _, err = parseJWT(none, config, nil)
+	assertAuthErrorCode(t, err, AuthErrAlgorithm)
+
+	_, _, err = parseJWT("no-es-un-jwt", config, nil)
+	assertAuthErrorCode(t, err, AuthErrMalformed)
+}
+
+func TestParseJWTWithJWKS(t *testing.T) {
+	server := newJWKSServer(t, "key-1")
+	keys := NewKeyring(false)
+	keys.Set(testIssuer, NewJWKSCache(server.URL))
+	config := testJWTConfig()
+
+	sign := func(kid string) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, validClaims())
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		signed, err := token.SignedString(server.key)
+		if err != nil {
+			t.Fatalf("SignedString: %v", err)
+		}
+		return signed
+	}
+
+	claims, _, err := parseJWT(sign("key-1"), config, keys)
+	assertAuthErrorCode(t, err, "")
+	if claims["sub"] != "00000000-0000-0000-0000-000000000001" {
+		t.Errorf("sub = %v", claims["sub"])
+	}
+
+	_, _, err = parseJWT(sign(""), config, keys)
+	assertAuthErrorCode(t, err, AuthErrMissingKid)
+
+	_, _, err = parseJWT(sign("inventado"), config, keys)
+	assertAuthErrorCode(t, err, AuthErrUnknownKid)
+
+	server.set(func(s *jwksServer) { s.fail = true })
+	failing := NewKeyring(false)
+	failing.Set(testIssuer, NewJWKSCache(server.URL))
+	_, _, err = parseJWT(sign("key-1"), config, failing)
+	assertAuthErrorCode(t, err, AuthErrKeysUnavailable)
+}
+
+func TestCheckKeyStrength(t *testing.T) {
+	p256, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	p384, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	server := newJWKSServer(t)
+
+	if err := checkKeyStrength(jwt.SigningMethodES256, &p256.PublicKey); err != nil {
+		t.Errorf("ES256 con P-256: %v", err)
+	}
+	if err := checkKeyStrength(jwt.SigningMethodES256, &p384.PublicKey); err == nil {
+		t.Error("ES256 con P-384 debería rechazarse")
+	}
+	if err := checkKeyStrength(jwt.SigningMethodRS256, &server.key.PublicKey); err != nil {
+		t.Errorf("RS256 con 2048 bits: %v", err)
+	}
+	if err := checkKeyStrength(jwt.SigningMethodRS256, &p256.PublicKey); err == nil {
+		t.Error("RS256 con clave ECDSA debería rechazarse")
+	}
+}
+
+func TestLoadJWTConfig(t *testing.T) {
+	t.Setenv("SUPABASE_URL", "https://proyecto.supabase.co/")
+	t.Setenv("SUPABASE_JWT_SECRET", "")
+	t.Setenv("JWT_ISSUER", "")
+	t.Setenv("JWT_AUDIENCE", "")
+	t.Setenv("JWT_ALGORITHMS", "")
+	t.Setenv("JWT_LEEWAY", "")
+	t.Setenv("JWT_ROLES", "")
+	t.Setenv("OIDC_ISSUERS", "")
+
+	config, err := LoadJWTConfig()
+	if err != nil {
+		t.Fatalf("LoadJWTConfig: %v", err)
+	}
+	if len(config.Issuers) != 1 || config.Issuers[0].Issuer != testIssuer {
+		t.Fatalf("Issuers = %+v", config.Issuers)
+	}
+	supabase := config.Issuers[0]
+	if supabase.Audience != "authenticated" || supabase.JWKSURL != testIssuer+"/.well-known/jwks.json" || !supabase.AllowSecret {
+		t.Errorf("emisor de Supabase = %+v", supabase)
+	}
+	if config.Leeway != defaultJWTLeeway {
+		t.Errorf("Leeway = %v", config.Leeway)
+	}
+	if strings.Join(config.Algorithms, ",") != "RS256,ES256" {
+		t.Errorf("Algorithms = %v", config.Algorithms)
+	}
+
+	t.Setenv("SUPABASE_JWT_SECRET", testJWTSecret)
+	t.Setenv("JWT_LEEWAY", "5s")
+	config, err = LoadJWTConfig()
+	if err != nil {
+		t.Fatalf("LoadJWTConfig: %v", err)
+	}
+	if !containsValue(config.Algorithms, "HS256") || config.Leeway != 5*time.Second {
+		t.Errorf("Algorithms = %v, Leeway = %v", config.Algorithms, config.Leeway)
+	}
+
+	t.Setenv("SUPABASE_JWT_SECRET", "corto")
+	if _, err := LoadJWTConfig(); err == nil {
+		t.Error("un secreto corto debería rechazarse")
+	}
+
+	t.Setenv("SUPABASE_JWT_SECRET", "")
+	t.Setenv("JWT_ALGORITHMS", "RS256,none")
+	if _, err := LoadJWTConfig(); err == nil {
+		t.Error("none debería rechazarse")
+	}
+}
+
+func TestWriteAuthError(t *testing.T) {
+	rec := httptest.NewRecorder()
+	writeAuthError(rec, authError(AuthErrExpired, "token is expired"))
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d", rec.Code)
+	}
+	if !strings.HasPrefix(rec.Body.String(), AuthErrExpired+":") {
+		t.Errorf("body = %q", rec.Body.String())
+	}
+	if got := rec.Header().Get("WWW-Authenticate"); got != `Bearer error="invalid_token", error_description="token_expired"` {
+		t.Errorf("WWW-Authenticate = %q", got)
+	}
+
+	rec = httptest.NewRecorder()
+	writeAuthError(rec, authError(AuthErrKeysUnavailable, "JWKS no disponible"))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d", rec.Code)
+	}
+}
+
+func assertAuthErrorCode(t *testing.T, err error, code string) {
+	t.Helper()
+	if code == "" {
+		if err != nil {
+			t.Fatalf("error inesperado: %v", err)
+		}
+		return
+	}
+	authErr, ok := err.(*AuthError)
+	if !ok {
+		t.Fatalf("se esperaba *AuthError con código %s, se obtuvo %v", code, err)
+	}
+	if authErr.Code != code {
+		t.Errorf("código = %s, se esperaba %s (%v)", authErr.Code, code, authErr.Err)
+	}
+}
@@ -1,12 +1,13 @@
 package middleware
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"time"
 )
 
-// LoggingMiddleware registra todas las requests HTTP
+// LoggingMiddleware registra todas las requests HTTP con el usuario que las hizo
 func LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
@@ -17,16 +18,26 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 			statusCode:     http.StatusOK,
 		}
 
-		next.ServeHTTP(wrapper, r)
+		// La autenticación corre adentro: deja acá el Principal (ver WithPrincipal)
+		var principal *Principal
+		ctx := context.WithValue(r.Context(), principalSlotKey{}, &principal)
+
+		next.ServeHTTP(wrapper, r.WithContext(ctx))
+
+		user := "-"
+		if principal != nil {
+			user = principal.UserID + "/" + principal.TokenType
+		}
 
 		duration := time.Since(start)
 		log.Printf(
-			"%s %s %d %v %s",
+			"%s %s %d %v %s %s",
 			r.Method,
 			r.URL.Path,
 			wrapper.statusCode,
 			duration,
 			r.RemoteAddr,
+			user,
 		)
 	})
 }
@@ -41,3 +52,10 @@ func (rw *responseWrapper) WriteHeader(code int) {
 	rw.statusCode = code
 	rw.ResponseWriter.WriteHeader(code)
 }
+
+// Flush permite que los handlers de streaming (SSE) sigan funcionando a través del wrapper
+func (rw *responseWrapper) Flush() {
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,275 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Claims por defecto de los emisores OIDC
+const (
+	defaultUserIDClaim = "sub"
+	defaultEmailClaim  = "email"
+	// supabaseRoleClaim es donde Supabase guarda el rol de la aplicación
+	supabaseRoleClaim = "app_metadata.role"
+)
+
+// IssuerConfig describe un emisor de tokens confiable (Supabase, Keycloak u otro
+// proveedor OIDC) y cómo se traducen sus claims a nuestra identidad
+type IssuerConfig struct {
+	// Issuer es el valor exacto del claim iss
+	Issuer   string `json:"issuer"`
+	Audience string `json:"audience"`
+	// JWKSURL es la URL de las claves públicas. Si está vacía se obtiene de
+	// Issuer/.well-known/openid-configuration (OIDC discovery)
+	JWKSURL string `json:"jwks_uri,omitempty"`
+	// UserIDClaim es el claim con nuestro user_id (un UUID de auth.users). Por
+	// defecto "sub"; con otro IdP suele ser un atributo propio, como "gym_user_id"
+	UserIDClaim string `json:"user_id_claim,omitempty"`
+	EmailClaim  string `json:"email_claim,omitempty"`
+	// RoleClaim es el claim con el rol de la aplicación, con "." para claims
+	// anidados (por ejemplo "realm_access.roles" en Keycloak). Si es una lista se
+	// usa el mayor rol conocido. Vacío: todos los usuarios son RoleUser
+	RoleClaim string `json:"role_claim,omitempty"`
+	// TokenRoles son los valores aceptados en el claim role (en Supabase,
+	// "authenticated"). Vacío: el claim no se exige
+	TokenRoles []string `json:"token_roles,omitempty"`
+	// AllowSecret permite tokens HS* firmados con SUPABASE_JWT_SECRET
+	AllowSecret bool `json:"-"`
+}
+
+// withDefaults completa los claims por defecto
+func (c IssuerConfig) withDefaults() IssuerConfig {
+	if c.UserIDClaim == "" {
+		c.UserIDClaim = defaultUserIDClaim
+	}
+	if c.EmailClaim == "" {
+		c.EmailClaim = defaultEmailClaim
+	}
+	return c
+}
+
+// validate verifica que el emisor sea utilizable
+func (c IssuerConfig) validate() error {
+	if c.Issuer == "" || c.Audience == "" {
+		return fmt.Errorf("issuer y audience son requeridos")
+	}
+	for _, raw := range []string{c.Issuer, c.JWKSURL} {
+		if raw == "" {
+			continue
+		}
+		u, err := url.Parse(raw)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("URL inválida: %q", raw)
+		}
+	}
+	return nil
+}
+
+// parseIssuers lee OIDC_ISSUERS: una lista JSON de IssuerConfig
+func parseIssuers(value string) ([]IssuerConfig, error) {
+	var issuers []IssuerConfig
+	if err := json.Unmarshal([]byte(value), &issuers); err != nil {
+		return nil, fmt.Errorf("OIDC_ISSUERS no es una lista JSON válida: %v", err)
+	}
+	for i := range issuers {
+		issuers[i] = issuers[i].withDefaults()
+		if err := issuers[i].validate(); err != nil {
+			return nil, fmt.Errorf("OIDC_ISSUERS[%d]: %v", i, err)
+		}
+	}
+	return issuers, nil
+}
+
+// openIDConfiguration es la parte del documento de discovery que se usa
+type openIDConfiguration struct {
+	Issuer  string `json:"issuer"`
+	JWKSURI string `json:"jwks_uri"`
+}
+
+// Keyring mantiene un JWKSCache por emisor, resolviendo la URL de las claves por
+// OIDC discovery la primera vez que se necesita
+type Keyring struct {
+	client *http.Client
+	// background indica si los cachés nuevos se refrescan en segundo plano
+	background bool
+
+	mu     sync.Mutex
+	caches map[string]*JWKSCache
+	// lastDiscovery limita los reintentos de discovery fallidos por emisor
+	lastDiscovery map[string]time.Time
+	// pending son los discovery en curso por emisor, que comparten las requests
+	// concurrentes del mismo emisor
+	pending map[string]*pendingKeys
+}
+
+// pendingKeys es el resultado de un discovery en curso; done se cierra al terminar
+type pendingKeys struct {
+	done  chan struct{}
+	cache *JWKSCache
+	err   error
+}
+
+// NewKeyring crea un Keyring vacío. Con background, cada caché creado refresca
+// sus claves en segundo plano (ver JWKSCache.RefreshInBackground)
+func NewKeyring(background bool) *Keyring {
+	return &Keyring{
+		client:        &http.Client{Timeout: 10 * time.Second},
+		background:    background,
+		caches:        make(map[string]*JWKSCache),
+		lastDiscovery: make(map[string]time.Time),
+		pending:       make(map[string]*pendingKeys),
+	}
+}
+
+// Set asigna el caché de claves de un emisor, sin discovery
+func (k *Keyring) Set(issuer string, cache *JWKSCache) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.caches[issuer] = cache
+}
+
+// keys devuelve el caché de claves del emisor. El discovery se hace sin tomar el
+// lock, así un emisor lento no demora a los demás; las requests concurrentes del
+// mismo emisor esperan ese único discovery. Los errores son *AuthError
+func (k *Keyring) keys(issuer IssuerConfig) (*JWKSCache, error) {
+	if k == nil {
+		return nil, authError(AuthErrKeysUnavailable, "no hay claves configuradas para %s", issuer.Issuer)
+	}
+
+	k.mu.Lock()
+	if cache, ok := k.caches[issuer.Issuer]; ok {
+		k.mu.Unlock()
+		return cache, nil
+	}
+	if pending, ok := k.pending[issuer.Issuer]; ok {
+		k.mu.Unlock()
+		<-pending.done
+		return pending.cache, pending.err
+	}
+	if issuer.JWKSURL == "" {
+		if last, ok := k.lastDiscovery[issuer.Issuer]; ok && time.Since(last) < jwksMinRefreshInterval {
+			k.mu.Unlock()
+			return nil, authError(AuthErrKeysUnavailable, "discovery de %s falló recientemente", issuer.Issuer)
+		}
+		k.lastDiscovery[issuer.Issuer] = time.Now()
+	}
+	pending := &pendingKeys{done: make(chan struct{})}
+	k.pending[issuer.Issuer] = pending
+	k.mu.Unlock()
+
+	jwksURL := issuer.JWKSURL
+	if jwksURL == "" {
+		var err error
+		if jwksURL, err = k.discover(issuer.Issuer); err != nil {
+			pending.err = &AuthError{Code: AuthErrKeysUnavailable, Err: err}
+		}
+	}
+	if pending.err == nil {
+		pending.cache = NewJWKSCache(jwksURL)
+		pending.cache.client = k.client
+		if k.background {
+			go pending.cache.RefreshInBackground(context.Background())
+		}
+	}
+
+	k.mu.Lock()
+	delete(k.pending, issuer.Issuer)
+	if pending.err == nil {
+		k.caches[issuer.Issuer] = pending.cache
+	}
+	k.mu.Unlock()
+	close(pending.done)
+	return pending.cache, pending.err
+}
+
+// discover obtiene jwks_uri del documento de discovery del emisor, que debe
+// declarar exactamente el mismo issuer
+func (k *Keyring) discover(issuer string) (string, error) {
+	resp, err := k.client.Get(strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration")
+	if err != nil {
+		return "", fmt.Errorf("error haciendo discovery de %s: %v", issuer, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("discovery de %s retornó status %d", issuer, resp.StatusCode)
+	}
+
+	var doc openIDConfiguration
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return "", fmt.Errorf("error parseando discovery de %s: %v", issuer, err)
+	}
+	if doc.Issuer != issuer {
+		return "", fmt.Errorf("discovery de %s declara otro issuer: %s", issuer, doc.Issuer)
+	}
+	if doc.JWKSURI == "" {
+		return "", fmt.Errorf("discovery de %s sin jwks_uri", issuer)
+	}
+	return doc.JWKSURI, nil
+}
+
+var (
+	defaultKeyringOnce sync.Once
+	defaultKeyring     *Keyring
+)
+
+// sharedKeyring devuelve el Keyring del servidor, que refresca las claves en
+// segundo plano
+func sharedKeyring() *Keyring {
+	defaultKeyringOnce.Do(func() {
+		defaultKeyring = NewKeyring(true)
+	})
+	return defaultKeyring
+}
+
+// claimValue busca un claim, siguiendo los "." como claims anidados
+func claimValue(claims map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = claims
+	for _, part := range strings.Split(path, ".") {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = object[part]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// claimString devuelve un claim de texto, o "" si no existe o no es texto
+func claimString(claims map[string]interface{}, path string) string {
+	value, _ := claimValue(claims, path)
+	s, _ := value.(string)
+	return s
+}
+
+// claimRole devuelve el rol de la aplicación según RoleClaim. Si el claim es una
+// lista se usa el mayor rol conocido; sin rol válido el usuario es RoleUser
+func claimRole(claims map[string]interface{}, path string) string {
+	if path == "" {
+		return RoleUser
+	}
+
+	value, _ := claimValue(claims, path)
+	switch v := value.(type) {
+	case string:
+		return normalizeRole(v)
+	case []interface{}:
+		role := RoleUser
+		for _, item := range v {
+			if s, ok := item.(string); ok && roleRank(s) > roleRank(role) {
+				role = s
+			}
+		}
+		return role
+	default:
+		return RoleUser
+	}
+}
@@ -0,0 +1,250 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// newDiscoveryServer sirve el documento de discovery de un emisor cuyas claves
+// están en jwksURL. declared es el issuer que declara el documento (vacío: el propio)
+func newDiscoveryServer(t *testing.T, jwksURL, declared string) (*httptest.Server, *atomic.Int32) {
+	var requests atomic.Int32
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path != "/.well-known/openid-configuration" {
+			http.NotFound(w, r)
+			return
+		}
+		issuer := declared
+		if issuer == "" {
+			issuer = server.URL
+		}
+		json.NewEncoder(w).Encode(map[string]string{"issuer": issuer, "jwks_uri": jwksURL})
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestKeyringDiscovery(t *testing.T) {
+	jwks := newJWKSServer(t, "kc-1")
+	discovery, requests := newDiscoveryServer(t, jwks.URL, "")
+	issuer := IssuerConfig{Issuer: discovery.URL, Audience: "gym"}
+
+	keys := NewKeyring(false)
+	cache, err := keys.keys(issuer)
+	if err != nil {
+		t.Fatalf("keys: %v", err)
+	}
+	if _, err := cache.Key("kc-1"); err != nil {
+		t.Errorf("Key: %v", err)
+	}
+	if again, _ := keys.keys(issuer); again != cache || requests.Load() != 1 {
+		t.Errorf("el discovery debería hacerse una sola vez (requests = %d)", requests.Load())
+	}
+
+	// Un documento que declara otro issuer se rechaza, y no se reintenta enseguida
+	spoofed, requests := newDiscoveryServer(t, jwks.URL, "https://otro.example.com")
+	_, err = keys.keys(IssuerConfig{Issuer: spoofed.URL, Audience: "gym"})
+	assertAuthErrorCode(t, err, AuthErrKeysUnavailable)
+	_, err = keys.keys(IssuerConfig{Issuer: spoofed.URL, Audience: "gym"})
+	assertAuthErrorCode(t, err, AuthErrKeysUnavailable)
+	if requests.Load() != 1 {
+		t.Errorf("requests = %d, se esperaba 1", requests.Load())
+	}
+}
+
+func TestKeyringDiscoveryDoesNotBlockOtherIssuers(t *testing.T) {
+	jwks := newJWKSServer(t, "kc-1")
+	release := make(chan struct{})
+	var requests atomic.Int32
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		<-release
+		json.NewEncoder(w).Encode(map[string]string{"issuer": "http://" + r.Host, "jwks_uri": jwks.URL})
+	}))
+	t.Cleanup(slow.Close)
+	t.Cleanup(func() {
+		select {
+		case <-release:
+		default:
+			close(release)
+		}
+	})
+
+	keys := NewKeyring(false)
+	keys.Set("https://rapido.example.com", NewJWKSCache(jwks.URL))
+
+	// Dos requests concurrentes del emisor lento comparten el mismo discovery
+	results := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := keys.keys(IssuerConfig{Issuer: slow.URL})
+			results <- err
+		}()
+	}
+	for requests.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// Mientras tanto, otro emisor responde sin esperar
+	done := make(chan struct{})
+	go func() {
+		keys.keys(IssuerConfig{Issuer: "https://rapido.example.com"})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("el discovery de un emisor bloqueó a otro")
+	}
+
+	close(release)
+	for i := 0; i < 2; i++ {
+		if err := <-results; err != nil {
+			t.Errorf("keys: %v", err)
+		}
+	}
+	if requests.Load() != 1 {
+		t.Errorf("requests = %d, se esperaba 1", requests.Load())
+	}
+}
+
+func TestParseJWTMultipleIssuers(t *testing.T) {
+	jwks := newJWKSServer(t, "kc-1")
+	discovery, _ := newDiscoveryServer(t, jwks.URL, "")
+
+	config := testJWTConfig()
+	config.Issuers = append(config.Issuers, IssuerConfig{
+		Issuer:      discovery.URL,
+		Audience:    "gym",
+		UserIDClaim: "gym_user_id",
+		RoleClaim:   "realm_access.roles",
+	})
+	keys := NewKeyring(false)
+
+	keycloakClaims := func() jwt.MapClaims {
+		now := time.Now()
+		return jwt.MapClaims{
+			"iss":          discovery.URL,
+			"aud":          "gym",
+			"sub":          "f1a2b3c4-keycloak-id",
+			"gym_user_id":  "00000000-0000-0000-0000-000000000002",
+			"email":        "ana@example.com",
+			"realm_access": map[string]interface{}{"roles": []string{"offline_access", "coach"}},
+			"iat":          now.Unix(),
+			"exp":          now.Add(time.Hour).Unix(),
+		}
+	}
+	sign := func(claims jwt.MapClaims) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "kc-1"
+		signed, err := token.SignedString(jwks.key)
+		if err != nil {
+			t.Fatalf("SignedString: %v", err)
+		}
+		return signed
+	}
+
+	principal, err := validateJWT(sign(keycloakClaims()), config, keys)
+	assertAuthErrorCode(t, err, "")
+	if principal.UserID != "00000000-0000-0000-0000-000000000002" || principal.Email != "ana@example.com" || principal.Role != RoleCoach {
+		t.Errorf("principal = %+v", principal)
+	}
+
+	// Los tokens de Supabase se siguen aceptando
+	claims := validClaims()
+	claims["app_metadata"] = map[string]interface{}{"role": "admin"}
+	principal, err = validateJWT(signHS256(t, claims), config, keys)
+	assertAuthErrorCode(t, err, "")
+	if principal.UserID != "00000000-0000-0000-0000-000000000001" || principal.Role != RoleAdmin {
+		t.Errorf("principal = %+v", principal)
+	}
+
+	// La audience es la de cada emisor
+	claims = keycloakClaims()
+	claims["aud"] = "authenticated"
+	_, err = validateJWT(sign(claims), config, keys)
+	assertAuthErrorCode(t, err, AuthErrInvalidAudience)
+
+	// Sin el claim configurado no se cae al sub
+	claims = keycloakClaims()
+	delete(claims, "gym_user_id")
+	_, err = validateJWT(sign(claims), config, keys)
+	assertAuthErrorCode(t, err, AuthErrMissingSubject)
+
+	// El secreto de Supabase no sirve para otro emisor
+	hs, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, keycloakClaims()).SignedString([]byte(testJWTSecret))
+	_, err = validateJWT(hs, config, keys)
+	assertAuthErrorCode(t, err, AuthErrAlgorithm)
+}
+
+func TestClaimRole(t *testing.T) {
+	claims := map[string]interface{}{
+		"app_metadata": map[string]interface{}{"role": "coach"},
+		"realm_access": map[string]interface{}{"roles": []interface{}{"admin", "offline_access"}},
+		"groups":       []interface{}{"offline_access"},
+		"plain":        "superusuario",
+	}
+
+	tests := map[string]string{
+		"":                   RoleUser,
+		"app_metadata.role":  RoleCoach,
+		"realm_access.roles": RoleAdmin,
+		"groups":             RoleUser,
+		"plain":              RoleUser,
+		"inexistente.role":   RoleUser,
+	}
+	for path, want := range tests {
+		if got := claimRole(claims, path); got != want {
+			t.Errorf("claimRole(%q) = %s, se esperaba %s", path, got, want)
+		}
+	}
+}
+
+func TestLoadJWTConfigOIDCIssuers(t *testing.T) {
+	t.Setenv("SUPABASE_URL", "")
+	t.Setenv("SUPABASE_JWT_SECRET", "")
+	t.Setenv("JWT_ISSUER", "")
+	t.Setenv("JWT_ALGORITHMS", "")
+	t.Setenv("JWT_LEEWAY", "")
+
+	t.Setenv("OIDC_ISSUERS", "")
+	if _, err := LoadJWTConfig(); err == nil {
+		t.Error("sin emisores debería fallar")
+	}
+
+	t.Setenv("OIDC_ISSUERS", `[{"issuer": "https://sso.example.com/realms/gym", "audience": "gym", "role_claim": "realm_access.roles"}]`)
+	config, err := LoadJWTConfig()
+	if err != nil {
+		t.Fatalf("LoadJWTConfig: %v", err)
+	}
+	issuer := config.Issuers[0]
+	if len(config.Issuers) != 1 || issuer.UserIDClaim != "sub" || issuer.JWKSURL != "" || issuer.AllowSecret {
+		t.Errorf("Issuers = %+v", config.Issuers)
+	}
+
+	t.Setenv("SUPABASE_URL", "https://proyecto.supabase.co")
+	if config, err = LoadJWTConfig(); err != nil || len(config.Issuers) != 2 {
+		t.Errorf("Supabase y OIDC: %+v, %v", config.Issuers, err)
+	}
+
+	invalid := []string{
+		`{"issuer": "https://sso.example.com"}`,
+		`[{"issuer": "https://sso.example.com"}]`,
+		`[{"issuer": "sso.example.com", "audience": "gym"}]`,
+		`[{"issuer": "https://proyecto.supabase.co/auth/v1", "audience": "gym"}]`,
+	}
+	for _, value := range invalid {
+		t.Setenv("OIDC_ISSUERS", value)
+		if _, err := LoadJWTConfig(); err == nil {
+			t.Errorf("OIDC_ISSUERS = %s debería rechazarse", value)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Tipos de credencial con los que se autenticó la request
+const (
+	TokenTypeJWT         = "jwt"
+	TokenTypeAccessToken = "access_token"
+	TokenTypeDev         = "dev"
+)
+
+// Principal es la identidad autenticada de la request. Lo crea
+// SupabaseAuthMiddleware y lo leen los handlers, Authorize y LoggingMiddleware
+type Principal struct {
+	UserID string
+	// Email está vacío si la credencial no lo informa
+	Email string
+	// Role es el rol de la aplicación (ver Roles)
+	Role      string
+	TokenType string
+	// Scopes son los alcances de un token de acceso personal; nil para las demás credenciales
+	Scopes []string
+	// TokenID, SessionID, IssuedAt y ExpiresAt describen el JWT de la request, para
+	// revocarlo (ver RevocationList). TokenID es el jti, o session_id si el token no
+	// trae jti
+	TokenID   string
+	SessionID string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// principalKey es la clave del Principal en el contexto. Al no ser exportada,
+// ningún otro paquete puede escribir ni pisar la identidad
+type principalKey struct{}
+
+// principalSlotKey guarda el lugar donde LoggingMiddleware espera el Principal,
+// porque el contexto que crea la autenticación no vuelve hacia afuera
+type principalSlotKey struct{}
+
+// WithPrincipal devuelve un contexto con la identidad dada
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	if slot, ok := ctx.Value(principalSlotKey{}).(**Principal); ok {
+		*slot = principal
+	}
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFrom devuelve la identidad del contexto, si la request está autenticada
+func PrincipalFrom(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok && principal != nil && principal.UserID != ""
+}
+
+// CurrentPrincipal devuelve la identidad de la request o responde 401
+func CurrentPrincipal(w http.ResponseWriter, r *http.Request) (*Principal, bool) {
+	principal, ok := PrincipalFrom(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized: no hay usuario autenticado", http.StatusUnauthorized)
+		return nil, false
+	}
+	return principal, true
+}
+
+// CurrentUserID devuelve el user_id de la request o responde 401
+func CurrentUserID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	principal, ok := CurrentPrincipal(w, r)
+	if !ok {
+		return "", false
+	}
+	return principal.UserID, true
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCurrentUserID(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/api/workouts", nil)
+	if _, ok := CurrentUserID(rec, req); ok || rec.Code != http.StatusUnauthorized {
+		t.Errorf("sin principal: ok=%v status=%d", ok, rec.Code)
+	}
+
+	// Una clave string no puede hacerse pasar por la identidad
+	rec = httptest.NewRecorder()
+	req = req.WithContext(context.WithValue(req.Context(), "user_id", "00000000-0000-0000-0000-000000000001"))
+	if _, ok := CurrentUserID(rec, req); ok {
+		t.Error("la clave \"user_id\" no debería reconocerse")
+	}
+
+	rec = httptest.NewRecorder()
+	req = req.WithContext(WithPrincipal(req.Context(), &Principal{UserID: "00000000-0000-0000-0000-000000000001"}))
+	if userID, ok := CurrentUserID(rec, req); !ok || userID != "00000000-0000-0000-0000-000000000001" {
+		t.Errorf("CurrentUserID = %q, %v", userID, ok)
+	}
+}
+
+func TestLoggingMiddlewareLogsPrincipal(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	dev := &DevAuth{Users: map[string]DevUser{"ana": {UserID: "00000000-0000-0000-0000-00000000000a", Role: RoleUser}}}
+	handler := LoggingMiddleware(NewAuthMiddleware(testJWTConfig(), nil, nil, dev)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+	req := httptest.NewRequest("GET", "/api/workouts", nil)
+	req.Header.Set("Authorization", "Bearer dev:ana")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if !strings.Contains(buf.String(), "00000000-0000-0000-0000-00000000000a/dev") {
+		t.Errorf("log = %q", buf.String())
+	}
+}
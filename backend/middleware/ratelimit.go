@@ -0,0 +1,275 @@
+package middleware
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// defaultRateLimitBucket agrupa las rutas sin presupuesto propio
+const defaultRateLimitBucket = "*"
+
+// rateLimitSweepInterval es cada cuánto MemoryRateLimitStore descarta los buckets
+// que ya se recargaron por completo
+const rateLimitSweepInterval = time.Minute
+
+// RateLimit es un presupuesto de token bucket: se permiten hasta Requests seguidas
+// y el bucket se recarga a razón de Requests cada Per
+type RateLimit struct {
+	Requests int
+	Per      time.Duration
+}
+
+func (l RateLimit) validate() error {
+	if l.Requests <= 0 || l.Per <= 0 {
+		return fmt.Errorf("presupuesto inválido: %d cada %v", l.Requests, l.Per)
+	}
+	return nil
+}
+
+// rate devuelve cuántas requests se recuperan por segundo
+func (l RateLimit) rate() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+// RateLimits son los presupuestos por ruta, con clave "MÉTODO plantilla" como en
+// RoutePermissions. Cada ruta listada tiene su propio bucket; las demás comparten
+// el bucket de Default. PerIP es el presupuesto total de cada IP, que se aplica
+// antes de la autenticación (ver IPRateLimiter)
+type RateLimits struct {
+	Default RateLimit
+	Routes  map[string]RateLimit
+	PerIP   RateLimit
+}
+
+// Validate verifica que los presupuestos sean positivos y que cada ruta exista
+// en el router
+func (l RateLimits) Validate(router *mux.Router) error {
+	if err := l.Default.validate(); err != nil {
+		return fmt.Errorf("límite por defecto: %v", err)
+	}
+	if err := l.PerIP.validate(); err != nil {
+		return fmt.Errorf("límite por IP: %v", err)
+	}
+
+	registered, err := registeredRoutes(router)
+	if err != nil {
+		return err
+	}
+	for key, limit := range l.Routes {
+		if err := limit.validate(); err != nil {
+			return fmt.Errorf("límite de %s: %v", key, err)
+		}
+		if !registered[key] {
+			return fmt.Errorf("la ruta %s no existe", key)
+		}
+	}
+	return nil
+}
+
+// limitFor devuelve el bucket y el presupuesto de la ruta que atiende la request
+func (l RateLimits) limitFor(r *http.Request) (string, RateLimit) {
+	if key, ok := routeKey(r); ok {
+		if limit, ok := l.Routes[key]; ok {
+			return key, limit
+		}
+	}
+	return defaultRateLimitBucket, l.Default
+}
+
+// RateLimitResult es el estado de un bucket después de una request
+type RateLimitResult struct {
+	Allowed bool
+	// Remaining son las requests que quedan disponibles ahora
+	Remaining int
+	// Reset es cuánto falta para que el bucket se recargue por completo
+	Reset time.Duration
+	// RetryAfter es cuánto falta para la próxima request permitida; cero si Allowed
+	RetryAfter time.Duration
+}
+
+// RateLimitStore guarda los buckets. MemoryRateLimitStore sirve para una sola
+// instancia; con varias, una implementación compartida (por ejemplo en Redis)
+// aplica el mismo presupuesto en todas
+type RateLimitStore interface {
+	// Take consume una request del bucket key con el presupuesto limit
+	Take(key string, limit RateLimit) (RateLimitResult, error)
+}
+
+// MemoryRateLimitStore es un RateLimitStore en memoria, seguro para uso concurrente
+type MemoryRateLimitStore struct {
+	now func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+	per    time.Duration
+}
+
+// NewMemoryRateLimitStore crea un store vacío
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		now:     time.Now,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// Take recarga el bucket por el tiempo transcurrido y, si queda al menos una
+// request, la consume
+func (s *MemoryRateLimitStore) Take(key string, limit RateLimit) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	capacity := float64(limit.Requests)
+	rate := limit.rate()
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: capacity, last: now}
+		s.buckets[key] = bucket
+	}
+	bucket.per = limit.Per
+	bucket.tokens = math.Min(capacity, bucket.tokens+now.Sub(bucket.last).Seconds()*rate)
+	bucket.last = now
+
+	result := RateLimitResult{}
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = secondsDuration((1 - bucket.tokens) / rate)
+	}
+	result.Remaining = int(bucket.tokens)
+	result.Reset = secondsDuration((capacity - bucket.tokens) / rate)
+	return result, nil
+}
+
+// sweep descarta los buckets que ya se recargaron por completo, que equivalen a
+// uno nuevo
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < rateLimitSweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, bucket := range s.buckets {
+		if now.Sub(bucket.last) >= bucket.per {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+func secondsDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// RateLimiter limita las requests por usuario autenticado o, sin usuario, por IP
+// del cliente, con el presupuesto de cada ruta. Va después de
+// SupabaseAuthMiddleware para conocer al usuario; las requests rechazadas por la
+// autenticación las limita IPRateLimiter. Responde 429 con Retry-After cuando se
+// agota y agrega los headers RateLimit-* a todas las respuestas. Si el store
+// falla, la request se deja pasar
+func RateLimiter(limits RateLimits, store RateLimitStore) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "OPTIONS" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			identity := "ip:" + clientIP(r)
+			if principal, ok := PrincipalFrom(r.Context()); ok {
+				identity = "user:" + principal.UserID
+			}
+			bucket, limit := limits.limitFor(r)
+
+			if takeRateLimit(w, store, bucket+" "+identity, limit) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// IPRateLimiter limita el total de requests de cada IP con limits.PerIP. Va antes
+// de SupabaseAuthMiddleware, así también cuentan las requests con credenciales
+// inválidas (JWT inventados, intentos de adivinar tokens de acceso), que nunca
+// llegan a RateLimiter
+func IPRateLimiter(limits RateLimits, store RateLimitStore) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "OPTIONS" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if takeRateLimit(w, store, "ip "+clientIP(r), limits.PerIP) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// takeRateLimit consume una request del bucket y agrega los headers RateLimit-*.
+// Si el presupuesto se agotó responde 429 y devuelve false
+func takeRateLimit(w http.ResponseWriter, store RateLimitStore, key string, limit RateLimit) bool {
+	result, err := store.Take(key, limit)
+	if err != nil {
+		log.Printf("Rate limit: error consultando el store: %v", err)
+		return true
+	}
+
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(limit.Requests))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+	w.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limit.Requests, ceilSeconds(limit.Per)))
+
+	if !result.Allowed {
+		retryAfter := ceilSeconds(result.RetryAfter)
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		http.Error(w, fmt.Sprintf("Demasiadas requests: reintentar en %d s", retryAfter), http.StatusTooManyRequests)
+		return false
+	}
+	return true
+}
+
+// ceilSeconds redondea hacia arriba a segundos enteros, como esperan los headers
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// clientIP devuelve la IP del cliente. Detrás de un proxy (TRUST_PROXY=true) es
+// la última de X-Forwarded-For, la que agregó el proxy; las anteriores las puede
+// inventar el cliente. El header puede llegar repetido (el cliente manda uno y el
+// proxy agrega otro), así que se toma el último elemento del último valor
+func clientIP(r *http.Request) string {
+	if os.Getenv("TRUST_PROXY") == "true" {
+		if values := r.Header.Values("X-Forwarded-For"); len(values) > 0 {
+			parts := strings.Split(values[len(values)-1], ",")
+			if ip := strings.TrimSpace(parts[len(parts)-1]); ip != "" {
+				return ip
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,196 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// newTestRateLimitStore crea un store con un reloj controlado por el test
+func newTestRateLimitStore() (*MemoryRateLimitStore, *time.Time) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryRateLimitStore()
+	store.now = func() time.Time { return now }
+	return store, &now
+}
+
+func TestMemoryRateLimitStoreTokenBucket(t *testing.T) {
+	store, now := newTestRateLimitStore()
+	limit := RateLimit{Requests: 3, Per: 3 * time.Second}
+
+	for i := 2; i >= 0; i-- {
+		result, _ := store.Take("k", limit)
+		if !result.Allowed || result.Remaining != i {
+			t.Fatalf("request %d: %+v", 3-i, result)
+		}
+	}
+
+	result, _ := store.Take("k", limit)
+	if result.Allowed || result.RetryAfter != time.Second || result.Reset != 3*time.Second {
+		t.Errorf("bucket vacío: %+v", result)
+	}
+
+	// Otro bucket no se ve afectado
+	if result, _ := store.Take("otro", limit); !result.Allowed {
+		t.Errorf("otro bucket: %+v", result)
+	}
+
+	// Se recupera una request por segundo, sin pasar de la capacidad
+	*now = now.Add(time.Second)
+	if result, _ := store.Take("k", limit); !result.Allowed || result.Remaining != 0 {
+		t.Errorf("después de 1 s: %+v", result)
+	}
+	*now = now.Add(time.Hour)
+	if result, _ := store.Take("k", limit); !result.Allowed || result.Remaining != 2 {
+		t.Errorf("después de 1 h: %+v", result)
+	}
+}
+
+func TestMemoryRateLimitStoreSweep(t *testing.T) {
+	store, now := newTestRateLimitStore()
+	store.Take("viejo", RateLimit{Requests: 10, Per: time.Minute})
+
+	*now = now.Add(2 * time.Minute)
+	store.Take("nuevo", RateLimit{Requests: 10, Per: time.Minute})
+	if _, ok := store.buckets["viejo"]; ok || len(store.buckets) != 1 {
+		t.Errorf("buckets = %v", store.buckets)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	limits := RateLimits{
+		Default: RateLimit{Requests: 2, Per: time.Minute},
+		Routes:  map[string]RateLimit{"POST /api/workouts": {Requests: 1, Per: time.Minute}},
+		PerIP:   RateLimit{Requests: 100, Per: time.Minute},
+	}
+	store, _ := newTestRateLimitStore()
+
+	router := mux.NewRouter()
+	router.Use(RateLimiter(limits, store))
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	router.HandleFunc("/api/workouts", ok).Methods("GET", "POST")
+	router.HandleFunc("/api/health", ok).Methods("GET")
+
+	if err := limits.Validate(router); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	request := func(method, path, userID, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.RemoteAddr = remoteAddr
+		if userID != "" {
+			req = req.WithContext(WithPrincipal(req.Context(), &Principal{UserID: userID}))
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	ana := "00000000-0000-0000-0000-000000000001"
+	rec := request("POST", "/api/workouts", ana, "10.0.0.1:1234")
+	if rec.Code != http.StatusOK || rec.Header().Get("RateLimit-Limit") != "1" || rec.Header().Get("RateLimit-Remaining") != "0" || rec.Header().Get("RateLimit-Policy") != "1;w=60" {
+		t.Fatalf("primera request = %d %v", rec.Code, rec.Header())
+	}
+	rec = request("POST", "/api/workouts", ana, "10.0.0.1:1234")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "60" {
+		t.Errorf("sin presupuesto = %d, Retry-After %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+
+	// Las rutas sin presupuesto propio usan el bucket por defecto
+	if rec := request("GET", "/api/workouts", ana, "10.0.0.1:1234"); rec.Code != http.StatusOK || rec.Header().Get("RateLimit-Limit") != "2" {
+		t.Errorf("GET = %d %v", rec.Code, rec.Header())
+	}
+
+	// Otro usuario desde la misma IP tiene su propio presupuesto
+	if rec := request("POST", "/api/workouts", "00000000-0000-0000-0000-000000000002", "10.0.0.1:1234"); rec.Code != http.StatusOK {
+		t.Errorf("otro usuario = %d", rec.Code)
+	}
+
+	// Sin usuario se limita por IP
+	request("GET", "/api/health", "", "10.0.0.2:1234")
+	request("GET", "/api/health", "", "10.0.0.2:5678")
+	if rec := request("GET", "/api/health", "", "10.0.0.2:1234"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("misma IP = %d", rec.Code)
+	}
+	if rec := request("GET", "/api/health", "", "10.0.0.3:1234"); rec.Code != http.StatusOK {
+		t.Errorf("otra IP = %d", rec.Code)
+	}
+}
+
+func TestIPRateLimiterBeforeAuth(t *testing.T) {
+	limits := RateLimits{PerIP: RateLimit{Requests: 2, Per: time.Minute}}
+	store, _ := newTestRateLimitStore()
+
+	// Un "auth" que rechaza todo: las requests igual consumen el bucket de la IP
+	rejectAll := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "Token inválido", http.StatusUnauthorized)
+		})
+	}
+	router := mux.NewRouter()
+	router.Use(IPRateLimiter(limits, store))
+	router.Use(rejectAll)
+	router.HandleFunc("/api/workouts", func(w http.ResponseWriter, r *http.Request) {}).Methods("GET")
+
+	request := func(remoteAddr string) int {
+		req := httptest.NewRequest("GET", "/api/workouts", nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	for i := 0; i < 2; i++ {
+		if code := request("10.0.0.1:1234"); code != http.StatusUnauthorized {
+			t.Fatalf("request %d = %d", i+1, code)
+		}
+	}
+	if code := request("10.0.0.1:1234"); code != http.StatusTooManyRequests {
+		t.Errorf("sin presupuesto = %d", code)
+	}
+	if code := request("10.0.0.2:1234"); code != http.StatusUnauthorized {
+		t.Errorf("otra IP = %d", code)
+	}
+}
+
+func TestRateLimitsValidate(t *testing.T) {
+	router := mux.NewRouter()
+	router.HandleFunc("/api/workouts", func(w http.ResponseWriter, r *http.Request) {}).Methods("POST")
+
+	budget := RateLimit{Requests: 10, Per: time.Minute}
+	invalid := []RateLimits{
+		{Default: RateLimit{}, PerIP: budget},
+		{Default: budget},
+		{Default: budget, PerIP: budget, Routes: map[string]RateLimit{"POST /api/workouts": {Requests: 0, Per: time.Minute}}},
+		{Default: budget, PerIP: budget, Routes: map[string]RateLimit{"POST /api/workout": {Requests: 1, Per: time.Minute}}},
+	}
+	for _, limits := range invalid {
+		if err := limits.Validate(router); err == nil {
+			t.Errorf("Validate(%+v) debería fallar", limits)
+		}
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/health", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Forwarded-For", "1.1.1.1, 2.2.2.2")
+
+	t.Setenv("TRUST_PROXY", "")
+	if ip := clientIP(req); ip != "10.0.0.1" {
+		t.Errorf("sin proxy = %s", ip)
+	}
+	t.Setenv("TRUST_PROXY", "true")
+	if ip := clientIP(req); ip != "2.2.2.2" {
+		t.Errorf("con proxy = %s", ip)
+	}
+
+	// Con el header repetido, el del proxy es el último
+	req.Header.Add("X-Forwarded-For", "3.3.3.3")
+	if ip := clientIP(req); ip != "3.3.3.3" {
+		t.Errorf("header repetido = %s", ip)
+	}
+}
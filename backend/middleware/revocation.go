@@ -0,0 +1,221 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/goalritmo/gym/backend/database"
+)
+
+// revocationRefreshInterval es cada cuánto se relee la lista de revocaciones. Una
+// revocación hecha en otra instancia del servidor se aplica como máximo con este
+// retraso; en la instancia que la hizo se aplica de inmediato
+const revocationRefreshInterval = 30 * time.Second
+
+// defaultSessionMaxLifetime es la duración máxima de una sesión si no se configura
+// SESSION_MAX_LIFETIME
+const defaultSessionMaxLifetime = 30 * 24 * time.Hour
+
+// RevocationList es la lista de JWT revocados antes de vencer: por token (jti, o
+// session_id en los tokens de Supabase, que no traen jti) y por usuario (todos
+// los tokens emitidos hasta un momento). La fuente es la base de datos y las
+// consultas se hacen sobre una copia en memoria, sin ir a la base en cada request
+type RevocationList struct {
+	mu sync.RWMutex
+	// tokens mapea el id del token revocado a su vencimiento
+	tokens map[string]time.Time
+	// users mapea el user id al momento hasta el que se revocaron sus tokens
+	users map[string]time.Time
+}
+
+// NewRevocationList crea una lista vacía. Las revocaciones se cargan con Refresh
+func NewRevocationList() *RevocationList {
+	return &RevocationList{
+		tokens: make(map[string]time.Time),
+		users:  make(map[string]time.Time),
+	}
+}
+
+// Revoked indica si la credencial fue revocada. Un token emitido en el mismo
+// segundo que la revocación de sus sesiones también se considera revocado, porque
+// iat no tiene más precisión
+func (l *RevocationList) Revoked(principal *Principal) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if principal.TokenID != "" {
+		if _, ok := l.tokens[principal.TokenID]; ok {
+			return true
+		}
+	}
+	if before, ok := l.users[principal.UserID]; ok {
+		return principal.IssuedAt.Unix() <= before.Unix()
+	}
+	return false
+}
+
+// Refresh reemplaza la copia en memoria con las revocaciones de la base y borra
+// las de tokens que ya vencieron, que no hace falta seguir guardando
+func (l *RevocationList) Refresh() error {
+	if database.DB == nil {
+		return fmt.Errorf("base de datos no disponible")
+	}
+
+	if _, err := database.DB.Exec(`DELETE FROM revoked_tokens WHERE expires_at <= NOW()`); err != nil {
+		return fmt.Errorf("error borrando tokens vencidos: %v", err)
+	}
+
+	tokens := make(map[string]time.Time)
+	rows, err := database.DB.Query(`SELECT token_id, expires_at FROM revoked_tokens`)
+	if err != nil {
+		return fmt.Errorf("error consultando tokens revocados: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var tokenID string
+		var expiresAt time.Time
+		if err := rows.Scan(&tokenID, &expiresAt); err != nil {
+			return fmt.Errorf("error escaneando token revocado: %v", err)
+		}
+		tokens[tokenID] = expiresAt
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error consultando tokens revocados: %v", err)
+	}
+
+	users := make(map[string]time.Time)
+	rows, err = database.DB.Query(`SELECT user_id, revoked_before FROM session_revocations`)
+	if err != nil {
+		return fmt.Errorf("error consultando sesiones revocadas: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var userID string
+		var before time.Time
+		if err := rows.Scan(&userID, &before); err != nil {
+			return fmt.Errorf("error escaneando sesión revocada: %v", err)
+		}
+		users[userID] = before
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error consultando sesiones revocadas: %v", err)
+	}
+
+	l.mu.Lock()
+	l.tokens = tokens
+	l.users = users
+	l.mu.Unlock()
+	return nil
+}
+
+// RefreshInBackground relee la lista cada revocationRefreshInterval. La primera
+// carga la hace quien crea la lista, con Refresh. Si falla se siguen usando las
+// revocaciones cargadas. Termina cuando se cancela ctx
+func (l *RevocationList) RefreshInBackground(ctx context.Context) {
+	ticker := time.NewTicker(revocationRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := l.Refresh(); err != nil {
+			log.Printf("Revocaciones: error en refresco en segundo plano: %v", err)
+		}
+	}
+}
+
+// RevocationExpiry devuelve hasta cuándo hay que guardar la revocación del token
+// de principal. Un jti se guarda hasta que el token vence. Un session_id, en
+// cambio, identifica también a los tokens que la sesión emita al renovarse, así
+// que se guarda por la duración máxima de una sesión (SESSION_MAX_LIFETIME)
+func RevocationExpiry(principal *Principal, now time.Time) time.Time {
+	if principal.TokenID != principal.SessionID {
+		return principal.ExpiresAt
+	}
+	return now.Add(sessionMaxLifetime())
+}
+
+// sessionMaxLifetime lee SESSION_MAX_LIFETIME, que debe ser al menos la duración
+// máxima de las sesiones configurada en Supabase ("Time-box user sessions")
+func sessionMaxLifetime() time.Duration {
+	value := os.Getenv("SESSION_MAX_LIFETIME")
+	if value == "" {
+		return defaultSessionMaxLifetime
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Printf("Revocaciones: SESSION_MAX_LIFETIME inválido (%q), se usa %v", value, defaultSessionMaxLifetime)
+		return defaultSessionMaxLifetime
+	}
+	return d
+}
+
+// RevokeToken revoca un token hasta expiresAt (ver RevocationExpiry)
+func (l *RevocationList) RevokeToken(tokenID, userID string, expiresAt time.Time) error {
+	if database.DB == nil {
+		return fmt.Errorf("base de datos no disponible")
+	}
+
+	_, err := database.DB.Exec(`
+		INSERT INTO revoked_tokens (token_id, user_id, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (token_id) DO NOTHING
+	`, tokenID, userID, expiresAt)
+	if err != nil {
+		return fmt.Errorf("error revocando token: %v", err)
+	}
+
+	l.mu.Lock()
+	l.tokens[tokenID] = expiresAt
+	l.mu.Unlock()
+	return nil
+}
+
+// RevokeSessions revoca todos los JWT del usuario emitidos hasta ahora y devuelve
+// el momento de la revocación
+func (l *RevocationList) RevokeSessions(userID string) (time.Time, error) {
+	if database.DB == nil {
+		return time.Time{}, fmt.Errorf("base de datos no disponible")
+	}
+
+	var before time.Time
+	err := database.DB.QueryRow(`
+		INSERT INTO session_revocations (user_id, revoked_before)
+		VALUES ($1, NOW())
+		ON CONFLICT (user_id) DO UPDATE SET revoked_before = EXCLUDED.revoked_before
+		RETURNING revoked_before
+	`, userID).Scan(&before)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error revocando sesiones: %v", err)
+	}
+
+	l.mu.Lock()
+	l.users[userID] = before
+	l.mu.Unlock()
+	return before, nil
+}
+
+var (
+	revocationsOnce   sync.Once
+	sharedRevocations *RevocationList
+)
+
+// Revocations devuelve la lista de revocaciones del servidor, que se relee de la
+// base en segundo plano. Empieza vacía: main la carga con Refresh antes de
+// aceptar requests. Los handlers revocan a través de ella para que la revocación
+// se aplique de inmediato en esta instancia
+func Revocations() *RevocationList {
+	revocationsOnce.Do(func() {
+		sharedRevocations = NewRevocationList()
+		go sharedRevocations.RefreshInBackground(context.Background())
+	})
+	return sharedRevocations
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRevocationListRevoked(t *testing.T) {
+	revokedAt := time.Date(2024, 5, 1, 12, 0, 0, 500_000_000, time.UTC)
+	list := NewRevocationList()
+	list.tokens["jti-robado"] = revokedAt.Add(time.Hour)
+	list.users["00000000-0000-0000-0000-000000000002"] = revokedAt
+
+	tests := []struct {
+		name      string
+		principal Principal
+		want      bool
+	}{
+		{"token revocado", Principal{UserID: "00000000-0000-0000-0000-000000000001", TokenID: "jti-robado"}, true},
+		{"otro token", Principal{UserID: "00000000-0000-0000-0000-000000000001", TokenID: "jti-nuevo"}, false},
+		{"emitido antes de cerrar sesiones", Principal{UserID: "00000000-0000-0000-0000-000000000002", IssuedAt: revokedAt.Add(-time.Minute)}, true},
+		{"emitido en el mismo segundo", Principal{UserID: "00000000-0000-0000-0000-000000000002", IssuedAt: revokedAt.Truncate(time.Second)}, true},
+		{"emitido después", Principal{UserID: "00000000-0000-0000-0000-000000000002", IssuedAt: revokedAt.Add(time.Second)}, false},
+		{"sin iat", Principal{UserID: "00000000-0000-0000-0000-000000000002"}, true},
+	}
+	for _, tt := range tests {
+		if got := list.Revoked(&tt.principal); got != tt.want {
+			t.Errorf("%s: Revoked = %v, se esperaba %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRevocationExpiry(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	exp := now.Add(time.Hour)
+
+	// Por jti, hasta que el token vence
+	byJTI := &Principal{TokenID: "jti-1", SessionID: "sesion-1", ExpiresAt: exp}
+	if got := RevocationExpiry(byJTI, now); !got.Equal(exp) {
+		t.Errorf("jti: %v, se esperaba %v", got, exp)
+	}
+
+	// Por session_id, por la duración máxima de la sesión: los tokens renovados
+	// de la misma sesión siguen revocados
+	bySession := &Principal{TokenID: "sesion-1", SessionID: "sesion-1", ExpiresAt: exp}
+	t.Setenv("SESSION_MAX_LIFETIME", "")
+	if got := RevocationExpiry(bySession, now); !got.Equal(now.Add(defaultSessionMaxLifetime)) {
+		t.Errorf("session_id: %v", got)
+	}
+	t.Setenv("SESSION_MAX_LIFETIME", "168h")
+	if got := RevocationExpiry(bySession, now); !got.Equal(now.Add(7 * 24 * time.Hour)) {
+		t.Errorf("SESSION_MAX_LIFETIME=168h: %v", got)
+	}
+}
+
+func TestAuthMiddlewareRejectsRevokedTokens(t *testing.T) {
+	list := NewRevocationList()
+	handler := NewAuthMiddleware(testJWTConfig(), nil, list, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	claims := validClaims()
+	claims["session_id"] = "sesion-1"
+	token := signHS256(t, claims)
+
+	request := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/workouts", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := request(); rec.Code != http.StatusOK {
+		t.Fatalf("token válido = %d %s", rec.Code, rec.Body.String())
+	}
+
+	// Los tokens de Supabase se revocan por session_id
+	list.tokens["sesion-1"] = time.Now().Add(time.Hour)
+	rec := request()
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("token revocado = %d", rec.Code)
+	}
+	if got := rec.Header().Get("WWW-Authenticate"); got != `Bearer error="invalid_token", error_description="token_revoked"` {
+		t.Errorf("WWW-Authenticate = %q", got)
+	}
+}
@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// Roles de la aplicación, de menor a mayor: cada rol incluye los permisos de los
+// anteriores. Se guardan en app_metadata.role de auth.users, que solo puede
+// modificarse desde Supabase y viaja en el JWT; sin rol el usuario es RoleUser
+const (
+	RoleUser  = "user"
+	RoleCoach = "coach"
+	RoleAdmin = "admin"
+)
+
+// Roles son los roles válidos, de menor a mayor
+var Roles = []string{RoleUser, RoleCoach, RoleAdmin}
+
+// RoutePermissions asigna el rol mínimo a cada ruta, con clave "MÉTODO plantilla"
+// (la plantilla de gorilla/mux, por ejemplo "PUT /api/exercises/{id}"). Las rutas
+// que no figuran solo requieren autenticación
+type RoutePermissions map[string]string
+
+// roleRank devuelve la posición del rol en Roles, o -1 si no existe
+func roleRank(role string) int {
+	for i, r := range Roles {
+		if r == role {
+			return i
+		}
+	}
+	return -1
+}
+
+// RoleAllows indica si role alcanza el rol requerido
+func RoleAllows(role, required string) bool {
+	return roleRank(role) >= 0 && roleRank(role) >= roleRank(required)
+}
+
+// normalizeRole trata un rol vacío o desconocido como RoleUser
+func normalizeRole(role string) string {
+	if roleRank(role) < 0 {
+		return RoleUser
+	}
+	return role
+}
+
+// Validate verifica que cada entrada use un rol válido y corresponda a una ruta
+// registrada en el router, para que un error de tipeo no deje una ruta sin proteger
+func (p RoutePermissions) Validate(router *mux.Router) error {
+	registered, err := registeredRoutes(router)
+	if err != nil {
+		return err
+	}
+
+	for key, role := range p {
+		if roleRank(role) < 0 {
+			return fmt.Errorf("rol inválido para %s: %s", key, role)
+		}
+		if !registered[key] {
+			return fmt.Errorf("la ruta %s no existe", key)
+		}
+	}
+	return nil
+}
+
+// required devuelve el rol mínimo para la ruta que atiende la request
+func (p RoutePermissions) required(r *http.Request) string {
+	if key, ok := routeKey(r); ok {
+		if role, ok := p[key]; ok {
+			return role
+		}
+	}
+	return RoleUser
+}
+
+// registeredRoutes devuelve las claves "MÉTODO plantilla" de las rutas del router
+func registeredRoutes(router *mux.Router) (map[string]bool, error) {
+	registered := make(map[string]bool)
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, _ := route.GetMethods()
+		for _, method := range methods {
+			registered[method+" "+template] = true
+		}
+		return nil
+	})
+	return registered, err
+}
+
+// routeKey devuelve la clave "MÉTODO plantilla" de la ruta que atiende la request
+func routeKey(r *http.Request) (string, bool) {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return "", false
+	}
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return "", false
+	}
+	return r.Method + " " + template, true
+}
+
+// Authorize aplica los permisos por ruta con el rol del Principal. Va después de
+// SupabaseAuthMiddleware: sin usuario responde 401, y si el rol no alcanza, 403
+func Authorize(permissions RoutePermissions) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			required := permissions.required(r)
+			if required == RoleUser || r.Method == "OPTIONS" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			requireRole(w, r, required, next)
+		})
+	}
+}
+
+// RequireRole permite el acceso solo a usuarios con el rol indicado o superior
+func RequireRole(role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requireRole(w, r, role, next)
+		})
+	}
+}
+
+func requireRole(w http.ResponseWriter, r *http.Request, required string, next http.Handler) {
+	principal, ok := CurrentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	if !RoleAllows(principal.Role, required) {
+		http.Error(w, fmt.Sprintf("Forbidden: se requiere rol %s (rol actual: %s)", strings.Join(rolesFrom(required), " o "), principal.Role), http.StatusForbidden)
+		return
+	}
+
+	next.ServeHTTP(w, r)
+}
+
+// rolesFrom devuelve los roles que alcanzan el requerido, para el mensaje de error
+func rolesFrom(required string) []string {
+	return Roles[max(roleRank(required), 0):]
+}
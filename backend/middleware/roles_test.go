@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRoleAllows(t *testing.T) {
+	cases := []struct {
+		role, required string
+		allow          bool
+	}{
+		{RoleUser, RoleUser, true},
+		{RoleUser, RoleCoach, false},
+		{RoleCoach, RoleCoach, true},
+		{RoleAdmin, RoleCoach, true},
+		{RoleCoach, RoleAdmin, false},
+		{"superadmin", RoleUser, false},
+	}
+	for _, c := range cases {
+		if got := RoleAllows(c.role, c.required); got != c.allow {
+			t.Errorf("RoleAllows(%s, %s) = %v", c.role, c.required, got)
+		}
+	}
+}
+
+func testPermissionsRouter(permissions RoutePermissions) *mux.Router {
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	router := mux.NewRouter()
+	api := router.PathPrefix("/api").Subrouter()
+	api.Use(Authorize(permissions))
+	api.HandleFunc("/exercises", ok).Methods("GET")
+	api.HandleFunc("/exercises/{id}", ok).Methods("PUT")
+	return router
+}
+
+func TestRoutePermissionsValidate(t *testing.T) {
+	permissions := RoutePermissions{"PUT /api/exercises/{id}": RoleAdmin}
+	if err := permissions.Validate(testPermissionsRouter(permissions)); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+
+	for _, invalid := range []RoutePermissions{
+		{"PUT /api/exercise/{id}": RoleAdmin},
+		{"POST /api/exercises/{id}": RoleAdmin},
+		{"PUT /api/exercises/{id}": "root"},
+	} {
+		if err := invalid.Validate(testPermissionsRouter(invalid)); err == nil {
+			t.Errorf("%v debería rechazarse", invalid)
+		}
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	router := testPermissionsRouter(RoutePermissions{"PUT /api/exercises/{id}": RoleAdmin})
+
+	request := func(method, path, role string) int {
+		req := httptest.NewRequest(method, path, nil)
+		if role != "" {
+			principal := &Principal{UserID: "00000000-0000-0000-0000-000000000001", Role: role}
+			req = req.WithContext(WithPrincipal(req.Context(), principal))
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := request("GET", "/api/exercises", RoleUser); code != http.StatusOK {
+		t.Errorf("GET sin permisos declarados = %d", code)
+	}
+	if code := request("PUT", "/api/exercises/3", RoleCoach); code != http.StatusForbidden {
+		t.Errorf("PUT como coach = %d", code)
+	}
+	if code := request("PUT", "/api/exercises/3", RoleAdmin); code != http.StatusOK {
+		t.Errorf("PUT como admin = %d", code)
+	}
+	// Sin usuario es 401, no 403
+	if code := request("PUT", "/api/exercises/3", ""); code != http.StatusUnauthorized {
+		t.Errorf("PUT sin usuario = %d", code)
+	}
+}
@@ -1,196 +1,136 @@
 package middleware
 
 import (
-	"context"
 	"encoding/json"
-	"fmt"
 	"net/http"
-	"os"
 	"strings"
-	"time"
-
-	"github.com/golang-jwt/jwt/v5"
 )
 
-// SupabaseAuthMiddleware valida JWT tokens de Supabase
-func SupabaseAuthMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Permitir preflight requests
-		if r.Method == "OPTIONS" {
-			next.ServeHTTP(w, r)
-			return
-		}
-
-		// Permitir health check sin autenticación
-		if strings.HasSuffix(r.URL.Path, "/health") {
-			next.ServeHTTP(w, r)
-			return
-		}
-
-		// Obtener token JWT del header
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, "Authorization header requerido", http.StatusUnauthorized)
-			return
-		}
-
-		// Verificar formato "Bearer <token>"
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			http.Error(w, "Formato de autorización inválido", http.StatusUnauthorized)
-			return
-		}
-
-		tokenString := parts[1]
+// timerEventsPath es el stream SSE del timer, la única ruta que acepta el token en
+// la query (?access_token=)
+const timerEventsPath = "/api/timer/events"
 
-		// Para desarrollo/testing, permitir token "salud"
-		if tokenString == "salud" {
-			ctx := context.WithValue(r.Context(), "user_id", "00000000-0000-0000-0000-000000000001")
-			next.ServeHTTP(w, r.WithContext(ctx))
-			return
-		}
-
-		// Validar JWT de Supabase
-		userID, err := validateSupabaseJWT(tokenString)
-		if err != nil {
-			http.Error(w, fmt.Sprintf("Token inválido: %v", err), http.StatusUnauthorized)
-			return
-		}
-
-		// Agregar user_id al contexto
-		ctx := context.WithValue(r.Context(), "user_id", userID)
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
-}
-
-// validateSupabaseJWT valida un JWT token de Supabase usando JWT Signing Keys
-func validateSupabaseJWT(tokenString string) (string, error) {
-	// Usar JWKS (JSON Web Key Set) validation como método principal
-	supabaseURL := os.Getenv("SUPABASE_URL")
-	if supabaseURL == "" {
-		return "", fmt.Errorf("SUPABASE_URL no configurado")
-	}
-
-	// Intentar validación con JWKS primero
-	userID, err := validateWithJWKS(tokenString, supabaseURL)
+// SupabaseAuthMiddleware valida JWT tokens de Supabase con la configuración del
+// entorno (ver LoadJWTConfig y LoadDevAuth)
+func SupabaseAuthMiddleware(next http.Handler) http.Handler {
+	config, err := jwtConfig()
 	if err == nil {
-		return userID, nil
-	}
-
-	// Solo como fallback para desarrollo local, intentar con JWT secret
-	jwtSecret := os.Getenv("SUPABASE_JWT_SECRET")
-	if jwtSecret != "" {
-		userID, err := validateWithSecret(tokenString, jwtSecret)
-		if err == nil {
-			return userID, nil
+		var dev *DevAuth
+		if dev, err = devAuth(); err == nil {
+			return NewAuthMiddleware(config, sharedKeyring(), Revocations(), dev)(next)
 		}
 	}
 
-	return "", fmt.Errorf("JWT validation failed: %v", err)
+	// main no arranca con una configuración inválida; esto solo protege otros usos
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeAuthError(w, &AuthError{Code: AuthErrNotConfigured, Err: err})
+	})
 }
 
-// validateWithJWKS valida JWT usando JWKS de Supabase
-func validateWithJWKS(tokenString, supabaseURL string) (string, error) {
-	// Obtener las claves JWKS de Supabase
-	jwksURL := supabaseURL + "/auth/v1/.well-known/jwks.json"
-	jwks, err := FetchJWKS(jwksURL)
-	if err != nil {
-		return "", fmt.Errorf("error fetching JWKS: %v", err)
-	}
+// NewAuthMiddleware crea el middleware de autenticación: valida los JWT con config
+// y las claves de keys, rechazando los que figuran en revoked (si no es nil), los
+// tokens de acceso personales (gym_pat_...) y, si dev no es nil, los tokens
+// "dev:<nombre>"
+func NewAuthMiddleware(config JWTConfig, keys *Keyring, revoked *RevocationList, dev *DevAuth) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Permitir preflight requests
+			if r.Method == "OPTIONS" {
+				next.ServeHTTP(w, r)
+				return
+			}
 
-	// Parsear el token sin validar primero para obtener el kid
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		// Verificar que el algoritmo sea compatible (RSA o ECDSA)
-		switch token.Method.(type) {
-		case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA:
-			// Algoritmos soportados
-		default:
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
+			// Permitir health check sin autenticación
+			if strings.HasSuffix(r.URL.Path, "/health") {
+				next.ServeHTTP(w, r)
+				return
+			}
 
-		// Obtener el kid del header
-		kid, ok := token.Header["kid"].(string)
-		if !ok {
-			return nil, fmt.Errorf("kid not found in token header")
-		}
+			// Los archivos subidos se autorizan con la firma de su URL (ver storage.Local)
+			if strings.HasPrefix(r.URL.Path, "/media/") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Obtener token JWT del header
+			authHeader := r.Header.Get("Authorization")
 
-		// Buscar la clave correspondiente en JWKS
-		for _, key := range jwks.Keys {
-			if key.Kid == kid {
-				// Convertir JWK a clave pública (RSA o ECDSA)
-				publicKey, err := JWKToPublicKey(key)
-				if err != nil {
-					return nil, fmt.Errorf("error converting JWK to public key: %v", err)
+			// EventSource no permite enviar headers: solo para el stream SSE se acepta el
+			// token por query, que queda en logs y proxies
+			if authHeader == "" && r.Method == http.MethodGet && r.URL.Path == timerEventsPath {
+				if token := r.URL.Query().Get("access_token"); token != "" {
+					authHeader = "Bearer " + token
 				}
-				return publicKey, nil
 			}
-		}
 
-		return nil, fmt.Errorf("key with kid %s not found in JWKS", kid)
-	})
-
-	if err != nil {
-		return "", fmt.Errorf("error parsing token: %v", err)
-	}
+			if authHeader == "" {
+				writeAuthError(w, authError(AuthErrMissingToken, "Authorization header requerido"))
+				return
+			}
 
-	if !token.Valid {
-		return "", fmt.Errorf("token is not valid")
-	}
+			// Verificar formato "Bearer <token>"
+			parts := strings.Split(authHeader, " ")
+			if len(parts) != 2 || parts[0] != "Bearer" {
+				writeAuthError(w, authError(AuthErrInvalidHeader, "Formato de autorización inválido"))
+				return
+			}
 
-	// Extraer el user ID de los claims
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return "", fmt.Errorf("invalid token claims")
-	}
+			tokenString := parts[1]
+
+			var principal *Principal
+			var err error
+			if strings.HasPrefix(tokenString, AccessTokenPrefix) {
+				// Token de acceso personal (scripts, atajos del teléfono)
+				principal, err = authenticateAccessToken(tokenString, r)
+			} else if dev != nil && strings.HasPrefix(tokenString, devTokenPrefix) {
+				// Modo desarrollo: usuarios ficticios de DEV_AUTH_USERS
+				principal, err = dev.principal(tokenString)
+			} else {
+				// Validar JWT de Supabase o de otro emisor OIDC
+				principal, err = validateJWT(tokenString, config, keys)
+				if err == nil && revoked != nil && revoked.Revoked(principal) {
+					err = authError(AuthErrTokenRevoked, "el token fue revocado")
+				}
+			}
+			if err != nil {
+				writeAuthError(w, err)
+				return
+			}
 
-	// El user ID en Supabase JWT está en el claim "sub"
-	userID, ok := claims["sub"].(string)
-	if !ok || userID == "" {
-		return "", fmt.Errorf("user ID not found in token claims")
+			next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+		})
 	}
-
-	return userID, nil
 }
 
-// validateWithSecret valida JWT con secret (legacy/desarrollo)
-func validateWithSecret(tokenString, secret string) (string, error) {
-	// Parsear y validar el token con secret
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		// Verificar que el método de firma sea el esperado
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("método de firma inesperado: %v", token.Header["alg"])
-		}
-		return []byte(secret), nil
-	})
-
+// validateJWT valida un JWT de un emisor confiable (firma, issuer, audience,
+// vigencia y rol, ver JWTConfig) y devuelve su identidad según los claims que
+// configura el emisor: el user ID, el email y el rol de la aplicación (en
+// Supabase, sub, email y app_metadata.role). Los errores son *AuthError
+func validateJWT(tokenString string, config JWTConfig, keys *Keyring) (*Principal, error) {
+	claims, issuer, err := parseJWT(tokenString, config, keys)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	// Verificar que el token sea válido
-	if !token.Valid {
-		return "", fmt.Errorf("token inválido")
+	principal := &Principal{
+		UserID:    claimString(claims, issuer.UserIDClaim),
+		Email:     claimString(claims, issuer.EmailClaim),
+		Role:      claimRole(claims, issuer.RoleClaim),
+		TokenType: TokenTypeJWT,
+		// Los tokens de Supabase no traen jti: se identifican por su sesión
+		TokenID:   claimString(claims, "jti"),
+		SessionID: claimString(claims, "session_id"),
 	}
-
-	// Extraer claims
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return "", fmt.Errorf("claims inválidos")
+	if principal.TokenID == "" {
+		principal.TokenID = principal.SessionID
 	}
-
-	// Verificar expiración
-	if exp, ok := claims["exp"].(float64); ok {
-		if time.Now().Unix() > int64(exp) {
-			return "", fmt.Errorf("token expirado")
-		}
+	if iat, err := claims.GetIssuedAt(); err == nil && iat != nil {
+		principal.IssuedAt = iat.Time
 	}
-
-	// Extraer user_id (sub claim)
-	if sub, ok := claims["sub"].(string); ok {
-		return sub, nil
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		principal.ExpiresAt = exp.Time
 	}
-
-	return "", fmt.Errorf("user_id no encontrado en token")
+	return principal, nil
 }
 
 // GetUserInfoFromSupabase obtiene información del usuario desde Supabase Auth
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+)
+
+// Alcances de los tokens de acceso personales
+const (
+	// ScopeRead permite todas las consultas (GET)
+	ScopeRead = "read"
+	// ScopeWorkoutsWrite permite registrar, editar y borrar series, sesiones y el cronómetro
+	ScopeWorkoutsWrite = "workouts:write"
+)
+
+// AccessTokenScopes son los alcances válidos
+var AccessTokenScopes = []string{ScopeRead, ScopeWorkoutsWrite}
+
+// AccessToken representa un token de acceso personal. El token completo nunca se
+// devuelve después de crearlo
+type AccessToken struct {
+	ID         int        `json:"id" db:"id"`
+	Name       string     `json:"name" db:"name"`
+	Prefix     string     `json:"prefix" db:"token_prefix"`
+	Scopes     []string   `json:"scopes" db:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at" db:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at" db:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+// CreateAccessTokenRequest representa la estructura para crear un token
+type CreateAccessTokenRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays *int     `json:"expires_in_days,omitempty"`
+}
+
+// CreatedAccessToken es la respuesta al crear un token: la única vez que se
+// devuelve Token
+type CreatedAccessToken struct {
+	AccessToken
+	Token string `json:"token"`
+}
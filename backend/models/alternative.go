@@ -0,0 +1,13 @@
+package models
+
+// ExerciseAlternative representa un ejercicio sugerido para reemplazar a otro
+type ExerciseAlternative struct {
+	ExerciseID      int      `json:"exercise_id"`
+	Name            string   `json:"name"`
+	EquipmentID     *int     `json:"equipment_id"`
+	Equipment       *string  `json:"equipment"`
+	EquipmentStatus *string  `json:"equipment_status"`
+	Score           float64  `json:"score"`
+	SharedPrimary   []string `json:"shared_primary"`
+	SharedSecondary []string `json:"shared_secondary"`
+}
@@ -0,0 +1,74 @@
+package models
+
+// EquipmentCategories son los valores del enum equipment_category
+var EquipmentCategories = []string{"pesas_libres", "maquinas", "cables", "rack", "cardio", "accesorios"}
+
+// MuscleGroupRoles son los valores del enum muscle_groups_role (grupo muscular de un ejercicio)
+var MuscleGroupRoles = []string{
+	"pecho", "espalda", "hombros", "biceps", "triceps",
+	"piernas", "gluteos", "abdominales", "antebrazos", "pantorrillas",
+}
+
+// MuscleGroupCategories son los valores del enum muscle_groups_category
+var MuscleGroupCategories = []string{"empuje", "tirar", "piernas", "core"}
+
+// Roles de un músculo dentro de un ejercicio (exercise_muscle_groups.role)
+const (
+	MuscleRolePrimary   = "primary"
+	MuscleRoleSecondary = "secondary"
+)
+
+// MuscleGroup representa un músculo o grupo muscular del catálogo
+type MuscleGroup struct {
+	ID       int     `json:"id" db:"id"`
+	Name     string  `json:"name" db:"name"`
+	Category *string `json:"category" db:"category"`
+}
+
+// ExerciseRequest representa la estructura para crear o actualizar un ejercicio
+type ExerciseRequest struct {
+	Name            string  `json:"name" validate:"required"`
+	MuscleGroup     string  `json:"muscle_group" validate:"required"`
+	EquipmentID     *int    `json:"equipment_id"`
+	VideoURL        *string `json:"video_url"`
+	MeasurementMode string  `json:"measurement_mode"`
+}
+
+// CustomExerciseRequest representa la estructura para crear o actualizar un
+// ejercicio privado del usuario, junto con sus músculos
+type CustomExerciseRequest struct {
+	ExerciseRequest
+	MuscleGroups []ExerciseMuscleGroupLink `json:"muscle_groups"`
+}
+
+// EquipmentRequest representa la estructura para crear o actualizar un equipo
+type EquipmentRequest struct {
+	Name           string    `json:"name" validate:"required"`
+	Category       string    `json:"category" validate:"required"`
+	Observations   *string   `json:"observations"`
+	ImageURL       *string   `json:"image_url"`
+	LoadType       *string   `json:"load_type"`
+	BarWeight      *float64  `json:"bar_weight"`
+	Plates         []float64 `json:"plates"`
+	StackIncrement *float64  `json:"stack_increment"`
+	DumbbellStep   *float64  `json:"dumbbell_step"`
+	MinWeight      *float64  `json:"min_weight"`
+	MaxWeight      *float64  `json:"max_weight"`
+}
+
+// MuscleGroupRequest representa la estructura para crear o actualizar un grupo muscular
+type MuscleGroupRequest struct {
+	Name     string  `json:"name" validate:"required"`
+	Category *string `json:"category" validate:"required"`
+}
+
+// ExerciseMuscleGroupLink vincula un ejercicio con un músculo y su rol
+type ExerciseMuscleGroupLink struct {
+	MuscleGroupID int    `json:"muscle_group_id" validate:"required"`
+	Role          string `json:"role" validate:"required,oneof=primary secondary"`
+}
+
+// SetExerciseMuscleGroupsRequest reemplaza los músculos vinculados a un ejercicio
+type SetExerciseMuscleGroupsRequest struct {
+	Links []ExerciseMuscleGroupLink `json:"links"`
+}
@@ -4,18 +4,98 @@ import (
 	"time"
 )
 
+// Tipos de carga de un equipo
+const (
+	LoadTypePlates     = "plates"
+	LoadTypeStack      = "stack"
+	LoadTypeDumbbells  = "dumbbells"
+	LoadTypeBodyweight = "bodyweight"
+)
+
 // Equipment representa un equipo de gimnasio
 type Equipment struct {
-	ID           int     `json:"id" db:"id"`
-	Name         string  `json:"name" db:"name"`
-	Category     string  `json:"category" db:"category"`
-	Observations *string `json:"observations" db:"observations"`
-	ImageURL     *string `json:"image_url" db:"image_url"`
-	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	ID             int       `json:"id" db:"id"`
+	Name           string    `json:"name" db:"name"`
+	Category       string    `json:"category" db:"category"`
+	Observations   *string   `json:"observations" db:"observations"`
+	ImageURL       *string   `json:"image_url" db:"image_url"`
+	LoadType       *string   `json:"load_type" db:"load_type"`
+	BarWeight      *float64  `json:"bar_weight" db:"bar_weight"`
+	Plates         []float64 `json:"plates" db:"plates"`
+	StackIncrement *float64  `json:"stack_increment" db:"stack_increment"`
+	DumbbellStep   *float64  `json:"dumbbell_step" db:"dumbbell_step"`
+	MinWeight      *float64  `json:"min_weight" db:"min_weight"`
+	MaxWeight      *float64  `json:"max_weight" db:"max_weight"`
+	Status         string    `json:"status" db:"status"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+	// ImageThumbnailURL solo se completa si la imagen se subió (POST /equipment/{id}/image)
+	ImageThumbnailURL *string `json:"image_thumbnail_url,omitempty" db:"-"`
+	// Exercises solo se completa con include=exercises
+	Exercises []Exercise `json:"exercises,omitempty" db:"-"`
 }
 
 // EquipmentFilter representa filtros para buscar equipos
 type EquipmentFilter struct {
 	Category string `json:"category"`
 	Search   string `json:"search"`
+	Status   string `json:"status"`
+}
+
+// Estados de disponibilidad de un equipo
+const (
+	EquipmentAvailable  = "available"
+	EquipmentOutOfOrder = "out_of_order"
+	EquipmentRemoved    = "removed"
+)
+
+// EquipmentStatuses son los estados válidos de un equipo
+var EquipmentStatuses = []string{EquipmentAvailable, EquipmentOutOfOrder, EquipmentRemoved}
+
+// EquipmentStatusChange representa un cambio en el historial de estado de un equipo
+type EquipmentStatusChange struct {
+	ID          int       `json:"id" db:"id"`
+	EquipmentID int       `json:"equipment_id" db:"equipment_id"`
+	Status      string    `json:"status" db:"status"`
+	Note        *string   `json:"note" db:"note"`
+	ChangedBy   *string   `json:"changed_by" db:"changed_by"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// UpdateEquipmentStatusRequest representa la estructura para cambiar el estado de un equipo
+type UpdateEquipmentStatusRequest struct {
+	Status string  `json:"status" validate:"required,oneof=available out_of_order removed"`
+	Note   *string `json:"note"`
+}
+
+// EquipmentReport representa el reporte de un usuario sobre un equipo roto
+type EquipmentReport struct {
+	ID          int        `json:"id" db:"id"`
+	EquipmentID int        `json:"equipment_id" db:"equipment_id"`
+	UserID      string     `json:"user_id" db:"user_id"`
+	Description string     `json:"description" db:"description"`
+	Resolved    bool       `json:"resolved" db:"resolved"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	ResolvedAt  *time.Time `json:"resolved_at" db:"resolved_at"`
+}
+
+// CreateEquipmentReportRequest representa la estructura para reportar un equipo roto
+type CreateEquipmentReportRequest struct {
+	Description string `json:"description" validate:"required"`
+}
+
+// PlateCount representa la cantidad de discos de un peso por lado
+type PlateCount struct {
+	Weight float64 `json:"weight"`
+	Count  int     `json:"count"`
+}
+
+// LoadCalculation representa la carga alcanzable para un peso objetivo
+type LoadCalculation struct {
+	EquipmentID int          `json:"equipment_id"`
+	LoadType    *string      `json:"load_type"`
+	Target      float64      `json:"target"`
+	Achievable  float64      `json:"achievable"`
+	Difference  float64      `json:"difference"`
+	BarWeight   *float64     `json:"bar_weight,omitempty"`
+	PerSide     []PlateCount `json:"per_side,omitempty"`
 }
@@ -14,11 +14,34 @@ type Exercise struct {
 	Equipment        string   `json:"equipment" db:"equipment"`
 	VideoURL         *string  `json:"video_url" db:"video_url"`
 	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+	MeasurementMode  string   `json:"measurement_mode" db:"measurement_mode"`
+	OwnerID          *string  `json:"owner_id,omitempty" db:"owner_id"`
+	// Instructions solo se completa en GET /exercises/{id}
+	Instructions *ExerciseInstructions `json:"instructions,omitempty" db:"-"`
 }
 
+// Modos de medición de un ejercicio
+const (
+	MeasurementWeightReps = "weight_reps"
+	MeasurementReps       = "reps"
+	MeasurementTime       = "time"
+)
+
+// MeasurementModes son los modos de medición válidos
+var MeasurementModes = []string{MeasurementWeightReps, MeasurementReps, MeasurementTime}
+
 // ExerciseFilter representa filtros para buscar ejercicios
 type ExerciseFilter struct {
-	MuscleGroup string `json:"muscle_group"`
-	Equipment   string `json:"equipment"`
-	Search      string `json:"search"`
+	MuscleGroup     string `json:"muscle_group"`
+	Equipment       string `json:"equipment"`
+	Search          string `json:"search"`
+	PrimaryMuscle   string `json:"primary_muscle"`
+	SecondaryMuscle string `json:"secondary_muscle"`
+	Fields          string `json:"fields"`
+	Sort            string `json:"sort"`
+	Order           string `json:"order"`
+	Limit           int    `json:"limit"`
+	Offset          int    `json:"offset"`
+	// UserID limita el listado al catálogo compartido y los ejercicios propios del usuario
+	UserID string `json:"-"`
 }
@@ -0,0 +1,30 @@
+package models
+
+// Secciones de las instrucciones de un ejercicio (exercise_instructions.section)
+const (
+	InstructionSetup   = "setup"
+	InstructionStep    = "step"
+	InstructionMistake = "mistake"
+	InstructionCue     = "cue"
+)
+
+// ExerciseInstructions representa la técnica de un ejercicio: la preparación,
+// los pasos de la ejecución en orden, los errores comunes y las indicaciones
+// breves para recordar durante la serie
+type ExerciseInstructions struct {
+	Locale         string   `json:"locale"`
+	Setup          []string `json:"setup"`
+	Steps          []string `json:"steps"`
+	CommonMistakes []string `json:"common_mistakes"`
+	Cues           []string `json:"cues"`
+}
+
+// Sections devuelve la lista de cada sección, indexada por su nombre en la base
+func (i *ExerciseInstructions) Sections() map[string]*[]string {
+	return map[string]*[]string{
+		InstructionSetup:   &i.Setup,
+		InstructionStep:    &i.Steps,
+		InstructionMistake: &i.CommonMistakes,
+		InstructionCue:     &i.Cues,
+	}
+}
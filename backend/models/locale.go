@@ -0,0 +1,41 @@
+package models
+
+// Idiomas del catálogo. Los textos de las tablas están en DefaultLocale y el
+// resto se guarda en catalog_translations
+const (
+	LocaleES      = "es"
+	LocaleEN      = "en"
+	DefaultLocale = LocaleES
+)
+
+// Locales son los idiomas soportados
+var Locales = []string{LocaleES, LocaleEN}
+
+// Tipos de entrada traducibles (catalog_translations.entity_type)
+const (
+	TranslationExercise    = "exercise"
+	TranslationEquipment   = "equipment"
+	TranslationMuscleGroup = "muscle_group"
+)
+
+// Campos traducibles (catalog_translations.field)
+const (
+	TranslationFieldName         = "name"
+	TranslationFieldObservations = "observations"
+)
+
+// TranslationRequest representa la traducción de una entrada a un idioma. Los
+// campos vacíos o ausentes eliminan la traducción (se usa el idioma por defecto)
+type TranslationRequest struct {
+	Name         *string `json:"name"`
+	Observations *string `json:"observations"`
+}
+
+// Translation representa la traducción guardada de una entrada
+type Translation struct {
+	EntityType   string  `json:"entity_type"`
+	EntityID     int     `json:"entity_id"`
+	Locale       string  `json:"locale"`
+	Name         *string `json:"name"`
+	Observations *string `json:"observations,omitempty"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// Tipos de archivo subido
+const (
+	MediaImage = "image"
+	MediaVideo = "video"
+)
+
+// Media representa un archivo subido. Las URLs son firmadas y vencen (ver
+// MEDIA_URL_TTL), por lo que el cliente no debe guardarlas
+type Media struct {
+	ID           int       `json:"id" db:"id"`
+	Kind         string    `json:"kind" db:"kind"`
+	ContentType  string    `json:"content_type" db:"content_type"`
+	SizeBytes    int64     `json:"size_bytes" db:"size_bytes"`
+	URL          string    `json:"url" db:"-"`
+	ThumbnailURL *string   `json:"thumbnail_url" db:"-"`
+	UploadedBy   *string   `json:"uploaded_by" db:"uploaded_by"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	StorageKey   string    `json:"-" db:"storage_key"`
+	ThumbnailKey *string   `json:"-" db:"thumbnail_key"`
+}
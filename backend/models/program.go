@@ -0,0 +1,95 @@
+package models
+
+import (
+	"time"
+)
+
+// RoutineTemplate representa una rutina reutilizable (lista ordenada de ejercicios)
+type RoutineTemplate struct {
+	ID        int                       `json:"id" db:"id"`
+	Name      string                    `json:"name" db:"name"`
+	Exercises []RoutineTemplateExercise `json:"exercises"`
+	CreatedAt time.Time                 `json:"created_at" db:"created_at"`
+}
+
+// RoutineTemplateExercise representa un ejercicio dentro de una rutina
+type RoutineTemplateExercise struct {
+	ID           int    `json:"id" db:"id"`
+	TemplateID   int    `json:"template_id" db:"template_id"`
+	ExerciseID   int    `json:"exercise_id" db:"exercise_id"`
+	ExerciseName string `json:"exercise_name" db:"exercise_name"`
+	Position     int    `json:"position" db:"position"`
+	Sets         int    `json:"sets" db:"sets"`
+	Reps         int    `json:"reps" db:"reps"`
+}
+
+// Program representa un programa de entrenamiento de varias semanas
+type Program struct {
+	ID              int          `json:"id" db:"id"`
+	Name            string       `json:"name" db:"name"`
+	Description     *string      `json:"description" db:"description"`
+	Weeks           int          `json:"weeks" db:"weeks"`
+	WeightIncrement float64      `json:"weight_increment" db:"weight_increment"`
+	DeloadEvery     *int         `json:"deload_every" db:"deload_every"`
+	DeloadPercent   float64      `json:"deload_percent" db:"deload_percent"`
+	Days            []ProgramDay `json:"days,omitempty"`
+	CreatedAt       time.Time    `json:"created_at" db:"created_at"`
+}
+
+// ProgramDay asigna una rutina a un día de una semana del programa
+type ProgramDay struct {
+	ID           int    `json:"id" db:"id"`
+	ProgramID    int    `json:"program_id" db:"program_id"`
+	Week         int    `json:"week" db:"week"`
+	DayOfWeek    int    `json:"day_of_week" db:"day_of_week"`
+	TemplateID   int    `json:"template_id" db:"template_id"`
+	TemplateName string `json:"template_name" db:"template_name"`
+}
+
+// ProgramEnrollment representa la inscripción de un usuario en un programa
+type ProgramEnrollment struct {
+	ID          int       `json:"id" db:"id"`
+	UserID      string    `json:"user_id" db:"user_id"`
+	ProgramID   int       `json:"program_id" db:"program_id"`
+	ProgramName string    `json:"program_name" db:"program_name"`
+	StartDate   time.Time `json:"start_date" db:"start_date"`
+	Active      bool      `json:"active" db:"active"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// EnrollProgramRequest representa la estructura para inscribirse en un programa
+type EnrollProgramRequest struct {
+	StartDate *time.Time `json:"start_date"`
+}
+
+// PlannedExercise representa un ejercicio planificado con su carga objetivo
+type PlannedExercise struct {
+	ExerciseID   int      `json:"exercise_id"`
+	ExerciseName string   `json:"exercise_name"`
+	Sets         int      `json:"sets"`
+	Reps         int      `json:"reps"`
+	TargetWeight *float64 `json:"target_weight"`
+	LastWeight   *float64 `json:"last_weight"`
+	Reason       string   `json:"reason"`
+	// EquipmentStatus y Substitute se completan si el equipo no está disponible
+	EquipmentStatus *string              `json:"equipment_status,omitempty"`
+	Substitute      *ExerciseAlternative `json:"substitute,omitempty"`
+}
+
+// TodayWorkout representa el entrenamiento planificado para hoy. Si el programa
+// todavía no empezó, NotStarted es true, StartsOn es la fecha de inicio y no hay
+// ejercicios
+type TodayWorkout struct {
+	Date         string            `json:"date"`
+	ProgramID    int               `json:"program_id"`
+	ProgramName  string            `json:"program_name"`
+	Week         int               `json:"week"`
+	DayOfWeek    int               `json:"day_of_week"`
+	Deload       bool              `json:"deload"`
+	RestDay      bool              `json:"rest_day"`
+	NotStarted   bool              `json:"not_started"`
+	StartsOn     *string           `json:"starts_on,omitempty"`
+	TemplateID   *int              `json:"template_id"`
+	TemplateName *string           `json:"template_name"`
+	Exercises    []PlannedExercise `json:"exercises"`
+}
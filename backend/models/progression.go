@@ -0,0 +1,21 @@
+package models
+
+// Estrategias de progresión soportadas
+const (
+	ProgressionDouble = "double"
+	ProgressionLinear = "linear"
+	ProgressionRPE    = "rpe"
+)
+
+// NextTarget representa la sugerencia de peso y repeticiones para la próxima serie
+type NextTarget struct {
+	ExerciseID int      `json:"exercise_id"`
+	Strategy   string   `json:"strategy"`
+	Weight     *float64 `json:"weight"`
+	Reps       int      `json:"reps"`
+	RPE        *float64 `json:"rpe"`
+	Increment  float64  `json:"increment"`
+	LastWeight *float64 `json:"last_weight"`
+	LastReps   *int     `json:"last_reps"`
+	Reason     string   `json:"reason"`
+}
@@ -0,0 +1,16 @@
+package models
+
+// Tipos de resultado de búsqueda
+const (
+	SearchTypeExercise  = "exercise"
+	SearchTypeEquipment = "equipment"
+)
+
+// SearchResult representa un resultado de la búsqueda en el catálogo
+type SearchResult struct {
+	Type         string  `json:"type"`
+	ID           int     `json:"id"`
+	Name         string  `json:"name"`
+	Score        float64 `json:"score"`
+	MatchedAlias *string `json:"matched_alias"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+)
+
+// Estados del cronómetro de descanso
+const (
+	TimerIdle    = "idle"
+	TimerRunning = "running"
+	TimerPaused  = "paused"
+)
+
+// DefaultRestSeconds es el descanso objetivo por defecto (guía de 40–50s por serie)
+const DefaultRestSeconds = 45
+
+// RestTimer representa el cronómetro de descanso de un usuario, compartido entre dispositivos
+type RestTimer struct {
+	UserID           string     `json:"user_id" db:"user_id"`
+	Status           string     `json:"status" db:"status"`
+	TargetSeconds    int        `json:"target_seconds" db:"target_seconds"`
+	StartedAt        *time.Time `json:"started_at" db:"started_at"`
+	AccumulatedMs    int64      `json:"accumulated_ms" db:"accumulated_ms"`
+	LastWorkoutID    *int       `json:"last_workout_id" db:"last_workout_id"`
+	ElapsedSeconds   float64    `json:"elapsed_seconds"`
+	RemainingSeconds float64    `json:"remaining_seconds"`
+	UpdatedAt        time.Time  `json:"updated_at" db:"updated_at"`
+	ServerTime       time.Time  `json:"server_time"`
+}
+
+// StartTimerRequest representa la estructura para iniciar el cronómetro
+type StartTimerRequest struct {
+	TargetSeconds *int `json:"target_seconds" validate:"omitempty,gt=0,lte=600"`
+	WorkoutID     *int `json:"workout_id"`
+}
@@ -15,6 +15,8 @@ type Workout struct {
 	Serie             *int      `json:"serie" db:"serie"`
 	Seconds           *int      `json:"seconds" db:"seconds"`
 	Observations      *string   `json:"observations" db:"observations"`
+	RPE               *float64  `json:"rpe" db:"rpe"`
+	IsWarmup          bool      `json:"is_warmup" db:"is_warmup"`
 	ExerciseSessionID string    `json:"exercise_session_id" db:"exercise_session_id"`
 	CreatedAt         time.Time `json:"created_at" db:"created_at"`
 }
@@ -35,12 +37,15 @@ type WorkoutSession struct {
 
 // CreateWorkoutRequest representa la estructura para crear un workout
 type CreateWorkoutRequest struct {
-	ExerciseID   int     `json:"exercise_id" validate:"required"`
-	Weight       float64 `json:"weight" validate:"required,gt=0"`
-	Reps         int     `json:"reps" validate:"required,gt=0"`
-	Serie        *int    `json:"serie" validate:"omitempty,gt=0"`
-	Seconds      *int    `json:"seconds" validate:"omitempty,gt=0"`
-	Observations *string `json:"observations"`
+	ExerciseID   int      `json:"exercise_id" validate:"required"`
+	// Weight y Reps son 0 si el modo de medición del ejercicio no los usa
+	Weight       float64  `json:"weight" validate:"gte=0"`
+	Reps         int      `json:"reps" validate:"gte=0"`
+	Serie        *int     `json:"serie" validate:"omitempty,gt=0"`
+	Seconds      *int     `json:"seconds" validate:"omitempty,gt=0"`
+	Observations *string  `json:"observations"`
+	RPE          *float64 `json:"rpe" validate:"omitempty,gte=1,lte=10"`
+	IsWarmup     bool     `json:"is_warmup"`
 }
 
 // CreateWorkoutSessionRequest representa la estructura para crear una sesión
@@ -56,3 +61,40 @@ type UpdateWorkoutSessionRequest struct {
 	Mood   *int    `json:"mood" validate:"omitempty,gte=0,lte=3"`
 	Notes  *string `json:"notes"`
 }
+
+// WarmupStep representa un escalón de calentamiento (porcentaje del peso de trabajo y reps)
+type WarmupStep struct {
+	Percent float64 `json:"percent"`
+	Reps    int     `json:"reps"`
+}
+
+// WarmupSettings representa el esquema de calentamiento configurado por un usuario
+type WarmupSettings struct {
+	UserID    string       `json:"user_id" db:"user_id"`
+	Steps     []WarmupStep `json:"steps" db:"steps"`
+	UpdatedAt *time.Time   `json:"updated_at" db:"updated_at"`
+}
+
+// UpdateWarmupSettingsRequest representa la estructura para actualizar el esquema de calentamiento
+type UpdateWarmupSettingsRequest struct {
+	Steps []WarmupStep `json:"steps" validate:"required,min=1,max=10"`
+}
+
+// WarmupSet representa una serie de calentamiento generada
+type WarmupSet struct {
+	Percent float64 `json:"percent"`
+	Weight  float64 `json:"weight"`
+	Reps    int     `json:"reps"`
+}
+
+// WarmupPlan representa la rampa de calentamiento para un peso de trabajo
+type WarmupPlan struct {
+	ExerciseID    int         `json:"exercise_id"`
+	WorkingWeight float64     `json:"working_weight"`
+	Sets          []WarmupSet `json:"sets"`
+}
+
+// CreateWarmupSetsRequest representa la estructura para registrar el calentamiento en la sesión actual
+type CreateWarmupSetsRequest struct {
+	WorkingWeight float64 `json:"working_weight" validate:"required,gt=0"`
+}
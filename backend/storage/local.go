@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Local guarda los archivos en un directorio y los sirve en BaseURL con URLs
+// firmadas con HMAC, para no exponer el directorio completo
+type Local struct {
+	Dir     string
+	BaseURL string
+	secret  []byte
+	now     func() time.Time
+}
+
+// NewLocal crea el almacenamiento local, creando el directorio si no existe
+func NewLocal(dir, baseURL string, secret []byte) (*Local, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("error creando directorio de media: %v", err)
+	}
+	return &Local{Dir: dir, BaseURL: strings.TrimSuffix(baseURL, "/"), secret: secret, now: time.Now}, nil
+}
+
+// Put escribe el archivo en un temporal y lo renombra, para no servir archivos a medio escribir
+func (l *Local) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	if !validKey(key) {
+		return fmt.Errorf("clave inválida: %q", key)
+	}
+	path := l.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Delete elimina el archivo
+func (l *Local) Delete(ctx context.Context, key string) error {
+	if !validKey(key) {
+		return fmt.Errorf("clave inválida: %q", key)
+	}
+	if err := os.Remove(l.path(key)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// SignedURL devuelve BaseURL/key?expires=...&signature=...
+func (l *Local) SignedURL(key string, expires time.Duration) (string, error) {
+	if !validKey(key) {
+		return "", fmt.Errorf("clave inválida: %q", key)
+	}
+	expiresAt := l.now().Add(expires).Unix()
+	query := url.Values{
+		"expires":   {strconv.FormatInt(expiresAt, 10)},
+		"signature": {l.sign(key, expiresAt)},
+	}
+	return l.BaseURL + "/" + (&url.URL{Path: key}).EscapedPath() + "?" + query.Encode(), nil
+}
+
+// ServeHTTP sirve los archivos con firma válida. Se monta con http.StripPrefix,
+// así que r.URL.Path es la clave
+func (l *Local) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, "/")
+	if !validKey(key) {
+		http.NotFound(w, r)
+		return
+	}
+
+	expiresAt, err := strconv.ParseInt(r.URL.Query().Get("expires"), 10, 64)
+	if err != nil || l.now().Unix() > expiresAt {
+		http.Error(w, "URL expirada", http.StatusForbidden)
+		return
+	}
+	if !hmac.Equal([]byte(r.URL.Query().Get("signature")), []byte(l.sign(key, expiresAt))) {
+		http.Error(w, "Firma inválida", http.StatusForbidden)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "private, max-age="+strconv.FormatInt(expiresAt-l.now().Unix(), 10))
+	http.ServeFile(w, r, l.path(key))
+}
+
+func (l *Local) path(key string) string {
+	return filepath.Join(l.Dir, filepath.FromSlash(key))
+}
+
+func (l *Local) sign(key string, expiresAt int64) string {
+	mac := hmac.New(sha256.New, l.secret)
+	fmt.Fprintf(mac, "%s\n%d", key, expiresAt)
+	return hex.EncodeToString(mac.Sum(nil))
+}
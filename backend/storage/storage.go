@@ -0,0 +1,107 @@
+// Package storage guarda los archivos subidos (imágenes de equipos y videos de
+// ejercicios) en un backend intercambiable: el sistema de archivos local o un
+// bucket compatible con S3
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Storage es la interfaz común de los backends de almacenamiento. Las claves son
+// rutas relativas separadas por "/" (p. ej. "images/ab12.jpg")
+type Storage interface {
+	// Put guarda el contenido bajo la clave indicada, reemplazándolo si existe
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Delete elimina el archivo; no es un error si no existe
+	Delete(ctx context.Context, key string) error
+	// SignedURL devuelve una URL de lectura válida durante expires
+	SignedURL(key string, expires time.Duration) (string, error)
+}
+
+// Media es el almacenamiento configurado con Init
+var Media Storage
+
+// URLExpiry es la validez de las URLs firmadas que se devuelven en las respuestas
+var URLExpiry = time.Hour
+
+// Init configura el almacenamiento según STORAGE_DRIVER (local o s3)
+func Init() error {
+	if ttl := os.Getenv("MEDIA_URL_TTL"); ttl != "" {
+		expiry, err := time.ParseDuration(ttl)
+		if err != nil || expiry <= 0 {
+			return fmt.Errorf("MEDIA_URL_TTL inválido: %q", ttl)
+		}
+		URLExpiry = expiry
+	}
+
+	switch driver := os.Getenv("STORAGE_DRIVER"); driver {
+	case "", "local":
+		dir := os.Getenv("MEDIA_DIR")
+		if dir == "" {
+			dir = "./uploads"
+		}
+		baseURL := os.Getenv("MEDIA_BASE_URL")
+		if baseURL == "" {
+			port := os.Getenv("PORT")
+			if port == "" {
+				port = "8080"
+			}
+			baseURL = "http://localhost:" + port + "/media"
+		}
+
+		secret := []byte(os.Getenv("MEDIA_SIGNING_SECRET"))
+		if len(secret) == 0 {
+			// Sin secreto configurado las URLs firmadas dejan de valer al reiniciar
+			secret = make([]byte, 32)
+			if _, err := rand.Read(secret); err != nil {
+				return err
+			}
+		}
+
+		local, err := NewLocal(dir, baseURL, secret)
+		if err != nil {
+			return err
+		}
+		Media = local
+
+	case "s3":
+		pathStyle, _ := strconv.ParseBool(os.Getenv("S3_FORCE_PATH_STYLE"))
+		s3, err := NewS3(S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+			PathStyle: pathStyle,
+		})
+		if err != nil {
+			return err
+		}
+		Media = s3
+
+	default:
+		return fmt.Errorf("STORAGE_DRIVER no soportado: %s (local o s3)", driver)
+	}
+
+	return nil
+}
+
+// validKey indica si la clave es una ruta relativa sin segmentos vacíos ni ".."
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, `\`) {
+		return false
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return false
+		}
+	}
+	return true
+}
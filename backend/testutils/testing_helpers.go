@@ -2,13 +2,14 @@ package testutils
 
 import (
 	"bytes"
-	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/gorilla/mux"
+
+	"github.com/goalritmo/gym/backend/middleware"
 )
 
 // TestRequest encapsula una request de prueba
@@ -18,6 +19,8 @@ type TestRequest struct {
 	Body        interface{}
 	Headers     map[string]string
 	UserID      string
+	// Role es el rol del usuario (por defecto middleware.RoleUser)
+	Role        string
 	QueryParams map[string]string
 }
 
@@ -68,9 +71,14 @@ func (suite *APITestSuite) MakeRequest(req TestRequest) *httptest.ResponseRecord
 		httpReq.URL.RawQuery = q.Encode()
 	}
 
-	// Agregar user_id al contexto si se especifica
+	// Agregar la identidad al contexto si se especifica
 	if req.UserID != "" {
-		ctx := context.WithValue(httpReq.Context(), "user_id", req.UserID)
+		role := req.Role
+		if role == "" {
+			role = middleware.RoleUser
+		}
+		principal := &middleware.Principal{UserID: req.UserID, Role: role, TokenType: middleware.TokenTypeDev}
+		ctx := middleware.WithPrincipal(httpReq.Context(), principal)
 		httpReq = httpReq.WithContext(ctx)
 	}
 